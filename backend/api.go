@@ -0,0 +1,540 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// The HTTP API lets a phone or another PC on the LAN manage this host. Each
+// route calls the same bound App method as the window, so both behave the
+// same. Requests carry the session token from /api/login as
+// "Authorization: Bearer <token>". Only WebSocket upgrades may pass it as
+// ?token=, since browsers can't set headers on those.
+//
+// The API speaks plain HTTP, so it only listens on this PC unless LAN access
+// is turned on explicitly, and logins are throttled per client address.
+const (
+	defaultAPIAddress = "127.0.0.1:8765"
+	apiPingInterval   = 30 * time.Second
+	apiEventBuffer    = 256
+	apiLoginAttempts  = 5 // Per client address per apiLoginWindow
+	apiLoginWindow    = time.Minute
+)
+
+// APIStatus describes the HTTP API on this PC
+type APIStatus struct {
+	Running bool   `json:"running"`
+	Address string `json:"address"`
+}
+
+var (
+	apiMu     sync.Mutex
+	apiServer *http.Server
+	apiAddr   string
+)
+
+// StartAPI serves the HTTP API on address (e.g. "127.0.0.1:8765") until
+// StopAPI. An empty address uses the saved settings. Addresses other than
+// loopback need onLAN.
+func (a *App) StartAPI(token string, address string, onLAN bool) string {
+	if _, err := a.authenticate(token); err != nil {
+		return "Error: " + err.Error()
+	}
+	return a.startAPI(address, onLAN)
+}
+
+func (a *App) startAPI(address string, onLAN bool) string {
+	if address == "" {
+		settings := loadHostSettings()
+		address, onLAN = settings.APIAddress, settings.APIOnLAN
+	}
+	if address == "" {
+		address = defaultAPIAddress
+	}
+	if !onLAN && !loopbackAddress(address) {
+		return "Error: " + address + " is reachable from other PCs; turn on LAN access to listen there"
+	}
+
+	apiMu.Lock()
+	defer apiMu.Unlock()
+	if apiServer != nil {
+		if apiAddr == address {
+			return "Success: API already running on " + address
+		}
+		apiServer.Close()
+		apiServer = nil
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	srv := &http.Server{Handler: a.apiHandler(), ReadHeaderTimeout: 10 * time.Second}
+	apiServer, apiAddr = srv, listener.Addr().String()
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			a.Log("❌ API server stopped: " + err.Error())
+		}
+	}()
+
+	a.Log("🌐 API listening on " + apiAddr)
+	return "Success: API listening on " + apiAddr
+}
+
+// StopAPI shuts the HTTP API down
+func (a *App) StopAPI(token string) string {
+	if _, err := a.authenticate(token); err != nil {
+		return "Error: " + err.Error()
+	}
+	return a.stopAPI()
+}
+
+func (a *App) stopAPI() string {
+	apiMu.Lock()
+	defer apiMu.Unlock()
+	if apiServer == nil {
+		return "Success"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	apiServer.Shutdown(ctx)
+	apiServer, apiAddr = nil, ""
+	a.Log("🌐 API stopped.")
+	return "Success"
+}
+
+// GetAPIStatus reports whether the HTTP API is running and where
+func (a *App) GetAPIStatus() APIStatus {
+	apiMu.Lock()
+	defer apiMu.Unlock()
+	return APIStatus{Running: apiServer != nil, Address: apiAddr}
+}
+
+// SetAPIAddress saves the API's listen address and applies it now.
+// An empty address turns the API off. onLAN allows addresses other than
+// loopback, which expose the plain-HTTP login to the network.
+func (a *App) SetAPIAddress(token string, address string, onLAN bool) string {
+	if _, err := a.authenticate(token); err != nil {
+		return "Error: " + err.Error()
+	}
+	address = strings.TrimSpace(address)
+	if address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return "Error: Address must look like 127.0.0.1:8765 or 192.168.1.10:8765"
+		}
+		if !onLAN && !loopbackAddress(address) {
+			return "Error: " + address + " is reachable from other PCs; turn on LAN access to listen there"
+		}
+	}
+	err := updateHostSettings(func(s *HostSettings) { s.APIAddress, s.APIOnLAN = address, onLAN })
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if address == "" {
+		return a.stopAPI()
+	}
+	return a.startAPI(address, onLAN)
+}
+
+// loopbackAddress reports whether a listen address only accepts
+// connections from this PC. An empty host means every interface.
+func loopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// startSavedAPI starts the API if this PC has it turned on
+func (a *App) startSavedAPI() {
+	if loadHostSettings().APIAddress == "" {
+		return
+	}
+	if res := a.startAPI("", false); strings.HasPrefix(res, "Error") {
+		a.Log("⚠️ Could not start the API: " + strings.TrimPrefix(res, "Error: "))
+	}
+}
+
+// --- ROUTES ---
+
+// apiRoute is a handler for an authenticated request
+type apiRoute func(w http.ResponseWriter, r *http.Request, token string, username string)
+
+func (a *App) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/login", newLoginLimiter().wrap(a.apiLogin))
+
+	mux.Handle("POST /api/unlock", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		var body struct {
+			Password string `json:"password"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		writeResult(w, a.UnlockKeys(token, body.Password))
+	}))
+	mux.Handle("GET /api/servers", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		writeJSON(w, http.StatusOK, a.GetMyServers(token))
+	}))
+	mux.Handle("POST /api/servers/{id}/start", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		writeResult(w, a.StartServer(r.PathValue("id"), token))
+	}))
+	mux.Handle("POST /api/servers/{id}/stop", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		writeResult(w, a.StopServer(r.PathValue("id"), token))
+	}))
+	mux.Handle("POST /api/servers/{id}/console", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		var body struct {
+			Command string `json:"command"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		writeResult(w, a.SendConsoleCommand(r.PathValue("id"), token, body.Command))
+	}))
+	mux.Handle("GET /api/servers/{id}/players", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, username string) {
+		id := r.PathValue("id")
+		if !a.apiMember(w, id, username) {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"online": a.GetOnlinePlayers(id, token),
+			"lists":  a.GetPlayerLists(id, token),
+		})
+	}))
+	mux.Handle("GET /api/servers/{id}/chat", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		writeJSON(w, http.StatusOK, a.GetChatHistory(r.PathValue("id"), token))
+	}))
+	mux.Handle("GET /api/servers/{id}/properties", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, username string) {
+		id := r.PathValue("id")
+		if !a.apiMember(w, id, username) {
+			return
+		}
+		writeJSON(w, http.StatusOK, a.GetServerProperties(id, token))
+	}))
+	mux.Handle("PUT /api/servers/{id}/properties", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		var values map[string]string // Only the keys to change
+		if !readJSON(w, r, &values) {
+			return
+		}
+		writeResult(w, a.SetServerProperties(r.PathValue("id"), token, values))
+	}))
+	mux.Handle("GET /api/servers/{id}/gamerules", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, username string) {
+		id := r.PathValue("id")
+		if !a.apiMember(w, id, username) {
+			return
+		}
+		writeJSON(w, http.StatusOK, a.GetGameRules(id, token))
+	}))
+	mux.Handle("PUT /api/servers/{id}/gamerules/{name}", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		var body struct {
+			Value string `json:"value"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		writeResult(w, a.SetGameRule(r.PathValue("id"), token, r.PathValue("name"), body.Value))
+	}))
+	mux.Handle("GET /api/servers/{id}/plugins", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, username string) {
+		id := r.PathValue("id")
+		if !a.apiMember(w, id, username) {
+			return
+		}
+		if q := r.URL.Query(); q.Has("q") {
+			writeJSON(w, http.StatusOK, a.SearchPlugins(id, token, q.Get("provider"), q.Get("q")))
+			return
+		}
+		writeJSON(w, http.StatusOK, a.ListPlugins(id, token))
+	}))
+	mux.Handle("POST /api/servers/{id}/plugins", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		var body struct {
+			Provider  string `json:"provider"`
+			ProjectID string `json:"project_id"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		writeResult(w, a.InstallPlugin(r.PathValue("id"), token, body.Provider, body.ProjectID))
+	}))
+	mux.Handle("POST /api/servers/{id}/plugins/update", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		writeResult(w, a.UpdatePlugins(r.PathValue("id"), token))
+	}))
+	mux.Handle("DELETE /api/servers/{id}/plugins/{provider}/{project}", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, _ string) {
+		writeResult(w, a.UninstallPlugin(r.PathValue("id"), token, r.PathValue("provider"), r.PathValue("project")))
+	}))
+	mux.Handle("GET /api/servers/{id}/export", a.apiAuth(func(w http.ResponseWriter, r *http.Request, _, username string) {
+		id := r.PathValue("id")
+		if !a.apiMember(w, id, username) {
+			return
+		}
+		q := r.URL.Query()
+		options := ExportOptions{
+			Snapshot:   q.Get("snapshot"),
+			Configs:    q.Get("configs") == "true",
+			PlayerData: q.Get("player_data") == "true",
+			Flat:       q.Get("flat") == "true",
+		}
+		// The zip is staged on disk first, so a running server's autosave is
+		// back on before a slow client starts downloading
+		instances := a.getInstancePath("")
+		os.MkdirAll(instances, 0755)
+		staged, err := os.CreateTemp(instances, ".export-*.zip")
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"ok": false, "error": err.Error()})
+			return
+		}
+		staged.Close()
+		defer os.Remove(staged.Name())
+		err = a.exportWorld(id, username, "download to "+r.RemoteAddr, options, func(entries []exportEntry, onProgress func(int, int64)) error {
+			return writeExport(staged.Name(), entries, onProgress)
+		})
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"ok": false, "error": err.Error()})
+			return
+		}
+		file, err := os.Open(staged.Name())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"ok": false, "error": err.Error()})
+			return
+		}
+		defer file.Close()
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="world-`+id+`.zip"`)
+		http.ServeContent(w, r, "", time.Time{}, file)
+	}))
+	mux.Handle("GET /api/servers/{id}/sync", a.apiAuth(func(w http.ResponseWriter, r *http.Request, token, username string) {
+		id := r.PathValue("id")
+		if !a.apiMember(w, id, username) {
+			return
+		}
+		writeJSON(w, http.StatusOK, a.GetSyncStatus(id, token))
+	}))
+	mux.Handle("GET /api/events", a.apiAuth(a.apiEvents))
+	return mux
+}
+
+func (a *App) apiLogin(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	res := a.Login(body.Username, body.Password)
+	if !strings.HasPrefix(res, "Success:") {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"ok": false, "error": strings.TrimPrefix(res, "Error: ")})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "token": strings.TrimPrefix(res, "Success:")})
+}
+
+// loginLimiter throttles /api/login per client address, since each attempt
+// costs a bcrypt check
+type loginLimiter struct {
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+}
+
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{attempts: map[string][]time.Time{}}
+}
+
+// allow records an attempt from host, unless it made too many recently
+func (l *loginLimiter) allow(host string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for h, times := range l.attempts {
+		recent := times[:0]
+		for _, t := range times {
+			if now.Sub(t) < apiLoginWindow {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) == 0 {
+			delete(l.attempts, h)
+		} else {
+			l.attempts[h] = recent
+		}
+	}
+	if len(l.attempts[host]) >= apiLoginAttempts {
+		return false
+	}
+	l.attempts[host] = append(l.attempts[host], now)
+	return true
+}
+
+func (l *loginLimiter) wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if !l.allow(host, time.Now()) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(apiLoginWindow.Seconds())))
+			writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{"ok": false, "error": "too many login attempts, try again later"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// apiAuth checks the session token before calling the route
+func (a *App) apiAuth(route apiRoute) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if websocket.IsWebSocketUpgrade(r) {
+			token = r.URL.Query().Get("token")
+		}
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		username, err := a.authenticate(token)
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"ok": false, "error": err.Error()})
+			return
+		}
+		route(w, r, token, username)
+	})
+}
+
+// apiMember answers non-members with a 403 where the App method would
+// just return an empty result
+func (a *App) apiMember(w http.ResponseWriter, serverID string, username string) bool {
+	if !a.isMember(serverID, username) {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"ok": false, "error": "You are not a member of this server"})
+		return false
+	}
+	return true
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"ok": false, "error": "invalid JSON body"})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeResult turns a bound method's "Success..."/"Error: ..." string into a response
+func writeResult(w http.ResponseWriter, res string) {
+	if strings.HasPrefix(res, "Error") {
+		message := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(res, "Error"), ":"))
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"ok": false, "error": message})
+		return
+	}
+	message := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(res, "Success"), ":"))
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "message": message})
+}
+
+// --- EVENT STREAM ---
+
+// apiUpgrader keeps the default origin check: clients that send no Origin
+// (apps, scripts) may connect, browsers only from a page served by this host
+var apiUpgrader = websocket.Upgrader{}
+
+// apiEvents streams bus events as JSON over a WebSocket. Optional filters:
+// ?server=<id> and ?topics=console,log. Only events of the user's servers
+// are sent; app-wide messages without a server may be about anyone's.
+func (a *App) apiEvents(w http.ResponseWriter, r *http.Request, token string, username string) {
+	serverFilter := r.URL.Query().Get("server")
+	topics := map[string]bool{}
+	for _, t := range strings.Split(r.URL.Query().Get("topics"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			topics[t] = true
+		}
+	}
+
+	conn, err := apiUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade already wrote the error
+	}
+	defer conn.Close()
+
+	// Publish is synchronous, so the subscriber only queues; a slow client
+	// loses events instead of stalling the app
+	queue := make(chan Event, apiEventBuffer)
+	unsubscribe := a.events.Subscribe("", func(e Event) {
+		select {
+		case queue <- e:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	// The reader notices when the client goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Membership is cached between pings, so a removed member stops
+	// receiving events within apiPingInterval
+	member := map[string]bool{}
+	allowed := func(e Event) bool {
+		if len(topics) > 0 && !topics[e.Topic] {
+			return false
+		}
+		if e.ServerID == "" || (serverFilter != "" && e.ServerID != serverFilter) {
+			return false
+		}
+		ok, seen := member[e.ServerID]
+		if !seen {
+			ok = a.isMember(e.ServerID, username)
+			member[e.ServerID] = ok
+		}
+		return ok
+	}
+
+	ping := time.NewTicker(apiPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case e := <-queue:
+			if !allowed(e) {
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ping.C:
+			// End the stream once the session is revoked
+			if _, err := a.authenticate(token); err != nil {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session ended"))
+				return
+			}
+			clear(member)
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
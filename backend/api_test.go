@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoopbackAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{"127.0.0.1:8765", true},
+		{"localhost:8765", true},
+		{"[::1]:8765", true},
+		{":8765", false},
+		{"0.0.0.0:8765", false},
+		{"192.168.1.10:8765", false},
+		{"127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := loopbackAddress(tt.address); got != tt.want {
+			t.Errorf("loopbackAddress(%q) = %v, want %v", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestLoginLimiter(t *testing.T) {
+	l := newLoginLimiter()
+	now := time.Now()
+	for i := 0; i < apiLoginAttempts; i++ {
+		if !l.allow("10.0.0.2", now) {
+			t.Fatalf("attempt %d was refused", i+1)
+		}
+	}
+	if l.allow("10.0.0.2", now) {
+		t.Error("an attempt over the limit was allowed")
+	}
+	if !l.allow("10.0.0.3", now) {
+		t.Error("another address was throttled")
+	}
+	if !l.allow("10.0.0.2", now.Add(apiLoginWindow)) {
+		t.Error("the limit didn't reset after the window")
+	}
+}
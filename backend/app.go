@@ -4,17 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings" // Add this to imports at top!
-	"time"
-
-	"github.com/wailsapp/wails/v2/pkg/runtime"
-	"go.mongodb.org/mongo-driver/bson" // Add this
-	// Add this
-	// Add this
 )
 
 // Build-time variables (set via -ldflags during compilation)
@@ -27,12 +20,43 @@ var (
 
 // App struct
 type App struct {
-	ctx context.Context
+	ctx   context.Context
+	store Store       // Users, servers, versions & locks (MongoDB or embedded)
+	procs *Supervisor // Running Minecraft servers on this PC, keyed by serverID
+	keys  *Keyring    // Unlocked user keys & decrypted cloud credentials (memory only)
+
+	versions *VersionCatalog  // Server types & versions from upstream APIs (cached)
+	plugins  []PluginProvider // Plugin sources (Modrinth, Hangar)
+	events   *EventBus        // Typed events for the UI (and anything else listening)
+	headless bool             // No Wails window (CLI / daemon)
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	return NewAppWithStore(nil)
+}
+
+// NewAppWithStore creates an App on top of an existing store (embedded mode, tests)
+func NewAppWithStore(store Store) *App {
+	a := &App{
+		store:    store,
+		procs:    NewSupervisor(),
+		keys:     NewKeyring(),
+		versions: NewVersionCatalog(versionCatalogTTL, DefaultVersionProviders()...),
+		plugins:  DefaultPluginProviders(),
+		events:   NewEventBus(),
+	}
+	a.events.Subscribe("", printEvents)
+	return a
+}
+
+// NewHeadlessApp creates an App without a window for the CLI and daemon.
+// Nothing is printed; events only reach the subscribers of the given bus.
+func NewHeadlessApp(store Store, events *EventBus) *App {
+	a := NewAppWithStore(store)
+	a.events = events
+	a.headless = true
+	return a
 }
 
 // getAppDir returns the directory where the .exe is running
@@ -60,40 +84,53 @@ func ensureDataDir() string {
 	return dataDir
 }
 
-// getPlayitBin returns the absolute path to playit.exe
+// getPlayitBin returns the path to the playit agent: ours next to the app,
+// else one on the system PATH, else where we download it
 func getPlayitBin() string {
-	appDir := getAppDir()
-	return filepath.Join(appDir, "playit.exe")
+	bundled := filepath.Join(getAppDir(), exeName("playit"))
+	if _, err := os.Stat(bundled); err == nil {
+		return bundled
+	}
+	if path, err := exec.LookPath("playit"); err == nil {
+		return path
+	}
+	return bundled
 }
 
-// getRcloneConfig returns the absolute path to rclone.conf
-func getRcloneConfig() string {
-	appDir := getAppDir()
-	return filepath.Join(appDir, "rclone.conf")
+// removeLegacyRcloneConfig deletes plaintext rclone.conf files written by
+// older versions. Credentials are now passed to rclone in memory.
+func (a *App) removeLegacyRcloneConfig() {
+	for _, path := range []string{filepath.Join(getAppDir(), "rclone.conf"), "rclone.conf"} {
+		if err := os.Remove(path); err == nil {
+			a.Log("🧹 Removed old plaintext cloud credentials: " + path)
+		}
+	}
 }
 
 // Startup is called when the app starts.
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
-
-	// --- DATABASE CONNECTION TEST ---
-	// Use build-time injected credentials or environment override
-	connStr := os.Getenv("MONGODB_URI")
-	if connStr == "" {
-		connStr = MongoDBURI // Injected at build time via ldflags
-	}
-	if connStr == "" {
-		// Fallback for development without build flags
-		connStr = "mongodb://localhost:27017"
+	if !a.headless {
+		a.bridgeToFrontend()
 	}
 
-	_, err := ConnectDB(connStr)
-	if err != nil {
-		a.Log(fmt.Sprintf("❌ CRITICAL: Database connection failed: %v", err))
+	// --- DATABASE CONNECTION ---
+	if a.store == nil {
+		store, err := OpenStore()
+		if err != nil {
+			a.Log(fmt.Sprintf("❌ CRITICAL: Database connection failed: %v", err))
+			store = newOfflineStore(err) // Every call reports the failure
+		}
+		a.store = store
 	}
 	// --------------------------------
 
-	a.SeedVersions()
+	a.removeLegacyRcloneConfig()
+	go a.seedVersions() // Network fetch, don't block the window
+	a.resumeUploads()   // Sessions whose upload failed last time
+	if !a.headless {
+		a.startSavedAPI() // The daemon starts it from its own flags
+	}
 }
 
 // Greet returns a greeting for the given name
@@ -101,67 +138,70 @@ func (a *App) Greet(name string) string {
 	return fmt.Sprintf("Hello %s, It's showtime!", name)
 }
 
-// Add this helper if you haven't already
+// Log sends a plain system message. It's a shim over the event bus;
+// new code should publish a typed event instead.
 func (a *App) Log(message string) {
-	fmt.Println(message) // Print to VS Code
-	if a.ctx != nil {
-		runtime.EventsEmit(a.ctx, "server-log", message) // Send to Frontend
-	}
+	a.publish(Event{
+		Topic:    TopicLog,
+		Source:   SourceApp,
+		Severity: severityOf(message),
+		Message:  message,
+	})
 }
 
 // SendConsoleCommand injects a command into the running Minecraft server
-func (a *App) SendConsoleCommand(serverID string, username string, command string) string {
+func (a *App) SendConsoleCommand(serverID string, token string, command string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return a.sendConsoleCommand(serverID, username, command)
+}
+
+// sendConsoleCommand runs a console command on behalf of an authenticated user
+func (a *App) sendConsoleCommand(serverID string, username string, command string) string {
 	// Permission check: Only owner or admins can send console commands
-	if !a.IsAdmin(serverID, username) {
+	if !a.isAdmin(serverID, username) {
 		return "Error: Only admins can send console commands"
 	}
 
-	// Security: Only allow if this is the currently running server
-	// (In a multi-server app, you'd check a map of activeCmds.
-	// For now, we use the global activeCmd/stdinPipe you set up earlier)
-
-	if activeCmd == nil || stdinPipe == nil {
+	// Route the command to this server's own process only
+	if !a.procs.IsRunning(serverID) {
 		return "Error: Server is not online."
 	}
 
-	// Write command to stdin (Minecraft console)
-	// Note: Minecraft commands need a newline "\n" at the end
-	_, err := stdinPipe.Write([]byte(command + "\n"))
+	// Prefer RCON so we get the command's output back. Until the server
+	// has finished starting RCON isn't listening, so it goes to stdin.
+	reply, err := a.consoleCommand(serverID, command)
 	if err != nil {
 		return "Error: Failed to send command."
 	}
 
 	a.Log("💻 Command Sent: " + command)
-	return "Success"
-}
-
-// SaveWorldSetting saves a world setting to the database (So the UI remembers your toggles)
-func (a *App) SaveWorldSetting(serverID string, username string, key string, value interface{}) string {
-	// Permission check: Only owner or admins can modify world settings
-	if !a.IsAdmin(serverID, username) {
-		return "Error: Only admins can modify world settings"
-	}
-
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx := context.TODO()
-
-	// Update specific field in the map: world_settings.keepInventory
-	updateField := fmt.Sprintf("world_settings.%s", key)
-
-	_, err := collection.UpdateOne(ctx, bson.M{"_id": serverID}, bson.M{
-		"$set": bson.M{updateField: value},
+	if reply == "" {
+		return "Success"
+	}
+	// RCON output doesn't show up in the server log, so echo it to the console
+	a.publish(Event{
+		Topic:    TopicConsole,
+		Source:   SourceMinecraft,
+		ServerID: serverID,
+		Message:  reply,
+		Payload:  ConsoleLine{Stream: "rcon"},
 	})
+	return "Success: " + reply
+}
 
-	if err != nil {
-		return "Error saving setting"
-	}
-	return "Success"
+// SaveWorldSetting saves a world setting to the database (So the UI remembers your toggles).
+// It is SetGameRule with the value as the UI sends it.
+func (a *App) SaveWorldSetting(serverID string, token string, key string, value interface{}) string {
+	return a.SetGameRule(serverID, token, key, settingString(value))
 }
 
 // AuthorizeDrive runs the interactive Rclone login flow
 // It returns the full config string (not just the token)
 func (a *App) AuthorizeDrive(clientID string, clientSecret string) string {
-	rcloneBin := getToolPath("rclone.exe")
+	rcloneBin := getToolPath("rclone")
 
 	// 1. Use build-time injected credentials or environment override
 	// (Credentials are provided by app owner via build process)
@@ -223,31 +263,25 @@ token = %s
 
 // forceUnlock resets the server status without syncing files
 func (a *App) forceUnlock(serverID string) {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	a.stopHeartbeat(serverID)
+	ctx, cancel := dbContext()
 	defer cancel()
 
-	filter := bson.M{"_id": serverID}
-	update := bson.M{
-		"$set": bson.M{
-			"lock.is_running": false,
-			"lock.hosted_by":  "",
-			"lock.hosted_at":  time.Time{},
-			"lock.port":       0,
-		},
-	}
-	collection.UpdateOne(ctx, filter, update)
-
+	a.store.ReleaseLock(ctx, serverID, "")
+	a.setState(serverID, StateStopped)
 }
 
 // CheckUserHasPlayit returns true if user has playit config in their account
-func (a *App) CheckUserHasPlayit(username string) bool {
-	collection := DB.Client.Database("mc_roam").Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (a *App) CheckUserHasPlayit(token string) bool {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := dbContext()
 	defer cancel()
 
-	var user User
-	err := collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	user, err := a.store.GetUser(ctx, username)
 	if err != nil {
 		return false
 	}
@@ -257,6 +291,11 @@ func (a *App) CheckUserHasPlayit(username string) bool {
 
 // getInstancePath generates a unique folder for each server locally using absolute path
 func (a *App) getInstancePath(serverID string) string {
+	return InstancePath(serverID)
+}
+
+// InstancePath is the local folder of a server on this PC
+func InstancePath(serverID string) string {
 	dataDir := ensureDataDir()
 	return filepath.Join(dataDir, "instances", serverID)
 }
@@ -265,14 +304,18 @@ func (a *App) getInstancePath(serverID string) string {
 // ADMIN MANAGEMENT SYSTEM
 // ============================================
 
-// IsAdmin checks if a user is owner or admin of a server
-func (a *App) IsAdmin(serverID string, username string) bool {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// IsAdmin checks if the logged-in user is owner or admin of a server
+func (a *App) IsAdmin(serverID string, token string) bool {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return false
+	}
+	return a.isAdmin(serverID, username)
+}
 
-	var server ServerGroup
-	err := collection.FindOne(ctx, bson.M{"_id": serverID}).Decode(&server)
+// isAdmin checks if a user is owner or admin of a server
+func (a *App) isAdmin(serverID string, username string) bool {
+	server, err := a.getServer(serverID)
 	if err != nil {
 		return false
 	}
@@ -292,15 +335,40 @@ func (a *App) IsAdmin(serverID string, username string) bool {
 	return false
 }
 
+// getServer fetches a server group by ID
+func (a *App) getServer(serverID string) (ServerGroup, error) {
+	ctx, cancel := dbContext()
+	defer cancel()
+
+	return a.store.GetServer(ctx, serverID)
+}
+
+// isMember checks if a user belongs to a server group
+func (a *App) isMember(serverID string, username string) bool {
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return false
+	}
+	for _, member := range server.Members {
+		if member == username {
+			return true
+		}
+	}
+	return false
+}
+
 // SetAdmin adds a user to the server's admin list
-func (a *App) SetAdmin(serverID string, targetUsername string, requesterUsername string) string {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (a *App) SetAdmin(serverID string, targetUsername string, token string) string {
+	requesterUsername, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx, cancel := dbContext()
 	defer cancel()
 
 	// 1. Fetch server
-	var server ServerGroup
-	err := collection.FindOne(ctx, bson.M{"_id": serverID}).Decode(&server)
+	server, err := a.store.GetServer(ctx, serverID)
 	if err != nil {
 		return "Error: Server not found"
 	}
@@ -335,11 +403,7 @@ func (a *App) SetAdmin(serverID string, targetUsername string, requesterUsername
 	}
 
 	// 6. Add to admins list
-	_, err = collection.UpdateOne(
-		ctx,
-		bson.M{"_id": serverID},
-		bson.M{"$push": bson.M{"admins": targetUsername}},
-	)
+	err = a.store.AddAdmin(ctx, serverID, targetUsername)
 	if err != nil {
 		return "Error: Failed to update database"
 	}
@@ -349,14 +413,17 @@ func (a *App) SetAdmin(serverID string, targetUsername string, requesterUsername
 }
 
 // RemoveAdmin removes a user from the server's admin list
-func (a *App) RemoveAdmin(serverID string, targetUsername string, requesterUsername string) string {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (a *App) RemoveAdmin(serverID string, targetUsername string, token string) string {
+	requesterUsername, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx, cancel := dbContext()
 	defer cancel()
 
 	// 1. Fetch server
-	var server ServerGroup
-	err := collection.FindOne(ctx, bson.M{"_id": serverID}).Decode(&server)
+	server, err := a.store.GetServer(ctx, serverID)
 	if err != nil {
 		return "Error: Server not found"
 	}
@@ -372,11 +439,7 @@ func (a *App) RemoveAdmin(serverID string, targetUsername string, requesterUsern
 	}
 
 	// 4. Remove from admins list
-	_, err = collection.UpdateOne(
-		ctx,
-		bson.M{"_id": serverID},
-		bson.M{"$pull": bson.M{"admins": targetUsername}},
-	)
+	err = a.store.RemoveAdmin(ctx, serverID, targetUsername)
 	if err != nil {
 		return "Error: Failed to update database"
 	}
@@ -386,13 +449,12 @@ func (a *App) RemoveAdmin(serverID string, targetUsername string, requesterUsern
 }
 
 // GetAdmins returns the list of server admins (including owner)
-func (a *App) GetAdmins(serverID string) []string {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	var server ServerGroup
-	err := collection.FindOne(ctx, bson.M{"_id": serverID}).Decode(&server)
+func (a *App) GetAdmins(serverID string, token string) []string {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return []string{}
+	}
+	server, err := a.getServer(serverID)
 	if err != nil {
 		return []string{}
 	}
@@ -403,16 +465,3 @@ func (a *App) GetAdmins(serverID string) []string {
 
 	return admins
 }
-
-// DownloadFile is a helper to download a file from a URL and return the response
-func DownloadFile(url string) (*http.Response, error) {
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("bad status: %s", resp.Status)
-	}
-	return resp, nil
-}
@@ -0,0 +1,205 @@
+package backend
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// extractArchive unpacks a .zip or .tar.gz into dest
+func extractArchive(archive string, dest string) error {
+	lower := strings.ToLower(archive)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return extractZip(archive, dest)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return extractTarGz(archive, dest)
+	}
+	return fmt.Errorf("unsupported archive: %s", filepath.Base(archive))
+}
+
+// safeJoin resolves an archive entry name under dest, refusing entries
+// that would escape it ("../../evil", absolute paths)
+func safeJoin(dest string, name string) (string, error) {
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dest, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q escapes the target folder", name)
+	}
+	return target, nil
+}
+
+// plainName reports whether name is a single file or folder name, safe to
+// join to a directory: no separators, no drive, not "." or ".."
+func plainName(name string) bool {
+	return name != "" && name != "." && name != ".." &&
+		!strings.ContainsAny(name, `/\`) && filepath.VolumeName(name) == ""
+}
+
+func extractZip(archive string, dest string) error {
+	return extractZipDir(archive, "", dest)
+}
+
+// extractZipDir unpacks the entries under dir/ (all entries if dir is
+// empty) into dest, without the dir/ prefix
+func extractZipDir(archive string, dir string, dest string) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	prefix := ""
+	if dir != "" {
+		prefix = strings.TrimSuffix(dir, "/") + "/"
+	}
+	for _, file := range reader.File {
+		if !strings.HasPrefix(file.Name, prefix) || file.Name == prefix {
+			continue
+		}
+		target, err := safeJoin(dest, strings.TrimPrefix(file.Name, prefix))
+		if err != nil {
+			return err
+		}
+		if file.FileInfo().IsDir() {
+			os.MkdirAll(target, 0755)
+			continue
+		}
+		src, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFileFrom(target, src, file.Mode())
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTarGz(archive string, dest string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := safeJoin(dest, header.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := checkNoLinks(dest, filepath.Dir(target)); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFileFrom(target, tr, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// Only links that stay inside the archive (JDKs have a few)
+			if !safeLink(dest, header.Name, header.Linkname) {
+				return fmt.Errorf("archive link %q points outside the target folder", header.Name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// safeLink reports whether a link at name pointing to linkname stays
+// inside dest. ".." may only lead the link: after a folder that may itself
+// be a link it would climb out of wherever that link goes.
+func safeLink(dest string, name string, linkname string) bool {
+	if linkname == "" || filepath.IsAbs(linkname) || filepath.VolumeName(linkname) != "" {
+		return false
+	}
+	climbing := true
+	for _, part := range strings.Split(filepath.ToSlash(linkname), "/") {
+		switch {
+		case part == "..":
+			if !climbing {
+				return false
+			}
+		case part != "" && part != ".":
+			climbing = false
+		}
+	}
+	_, err := safeJoin(dest, filepath.Join(filepath.Dir(filepath.FromSlash(name)), linkname))
+	return err == nil
+}
+
+// checkNoLinks refuses to write into dir if a folder between dest and dir
+// is a link, which an archive could point anywhere
+func checkNoLinks(dest string, dir string) error {
+	rel, err := filepath.Rel(dest, dir)
+	if err != nil || rel == "." {
+		return err
+	}
+	path, link := dest, ""
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		path, link = filepath.Join(path, part), filepath.Join(link, part)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("archive entry writes through the link %q", filepath.ToSlash(link))
+		}
+	}
+	return nil
+}
+
+// writeFileFrom copies r to path, creating parent folders
+func writeFileFrom(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
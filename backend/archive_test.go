@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	name, link, body string
+	dir              bool
+}
+
+// writeTarGz builds a .tar.gz from entries, in order
+func writeTarGz(t *testing.T, entries []tarEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		switch {
+		case e.link != "":
+			h.Typeflag, h.Linkname, h.Size = tar.TypeSymlink, e.link, 0
+		case e.dir:
+			h.Typeflag, h.Mode, h.Size = tar.TypeDir, 0755, 0
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(e.body))
+	}
+	tw.Close()
+	gz.Close()
+	f.Close()
+	return path
+}
+
+func TestExtractTarGz(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		wantErr string
+	}{
+		{
+			name: "jdk links",
+			entries: []tarEntry{
+				{name: "jdk/legal/java.base/LICENSE", body: "gpl"},
+				{name: "jdk/legal/java.desktop/LICENSE", link: "../java.base/LICENSE"},
+				{name: "jdk/bin/java", body: "java"},
+				{name: "jdk/Contents/Home", link: "../"},
+			},
+		},
+		{
+			name: "link chain out of dest",
+			entries: []tarEntry{
+				{name: "x", link: "."},
+				{name: "y", link: "x/.."},
+				{name: "y/escaped.txt", body: "escaped"},
+			},
+			wantErr: "points outside",
+		},
+		{
+			name:    "link out of dest",
+			entries: []tarEntry{{name: "a/b", link: "../../etc"}},
+			wantErr: "points outside",
+		},
+		{name: "absolute link", entries: []tarEntry{{name: "a", link: "/etc"}}, wantErr: "points outside"},
+		{
+			name: "write through a link",
+			entries: []tarEntry{
+				{name: "real", dir: true},
+				{name: "x", link: "real"},
+				{name: "x/file.txt", body: "through"},
+			},
+			wantErr: "writes through the link",
+		},
+		{name: "entry out of dest", entries: []tarEntry{{name: "../escaped.txt", body: "escaped"}}, wantErr: "escapes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "dest")
+			os.Mkdir(dest, 0755)
+
+			err := extractTarGz(writeTarGz(t, tt.entries), dest)
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("extractTarGz error = %v, want %q", err, tt.wantErr)
+			}
+			if _, err := os.Stat(filepath.Join(parent, "escaped.txt")); err == nil {
+				t.Error("a file was written outside dest")
+			}
+		})
+	}
+}
@@ -1,24 +1,18 @@
 package backend
 
 import (
-	"context"
 	"fmt"
 
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // Register creates a new user in MongoDB
 func (a *App) Register(username string, password string) string {
-	collection := DB.Client.Database("mc_roam").Collection("users")
-
 	// 1. Check if user already exists
-	ctx, cancel := context.WithTimeout(context.Background(), 5*1e9) // 5 seconds
+	ctx, cancel := dbContext()
 	defer cancel()
 
-	var existingUser User
-	err := collection.FindOne(ctx, bson.M{"username": username}).Decode(&existingUser)
+	_, err := a.store.GetUser(ctx, username)
 	if err == nil {
 		return "Error: Username already exists"
 	}
@@ -35,8 +29,15 @@ func (a *App) Register(username string, password string) string {
 		PasswordHash: string(hashedBytes),
 	}
 
+	// 3.5. Give the user a key pair so groups can share cloud credentials with them.
+	// The private key is encrypted with their password.
+	newUser.PublicKey, newUser.EncryptedPrivateKey, newUser.KeySalt, _, err = newUserKeys(username, password)
+	if err != nil {
+		return "Error: Could not create keys"
+	}
+
 	// 4. Insert into DB
-	_, err = collection.InsertOne(ctx, newUser)
+	err = a.store.CreateUser(ctx, newUser)
 	if err != nil {
 		return fmt.Sprintf("Error: Database insert failed: %v", err)
 	}
@@ -44,17 +45,14 @@ func (a *App) Register(username string, password string) string {
 	return "Success: User registered!"
 }
 
-// Login verifies credentials
+// Login verifies credentials and returns "Success:<session token>"
 func (a *App) Login(username string, password string) string {
-	collection := DB.Client.Database("mc_roam").Collection("users")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*1e9)
+	ctx, cancel := dbContext()
 	defer cancel()
 
 	// 1. Find the user
-	var user User
-	err := collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
-	if err == mongo.ErrNoDocuments {
+	user, err := a.store.GetUser(ctx, username)
+	if err == ErrNotFound {
 		return "Error: User not found"
 	} else if err != nil {
 		return "Error: Database error"
@@ -66,5 +64,18 @@ func (a *App) Login(username string, password string) string {
 		return "Error: Invalid password"
 	}
 
-	return "Success: Logged in as " + user.Username
+	// 3. Unlock the user's keys for this session (creates them for older accounts)
+	if err := a.unlockIdentity(user, password); err != nil {
+		a.Log("⚠️ Could not unlock your keys: " + err.Error())
+	} else {
+		a.kickUploads() // Queued uploads were waiting for the cloud credentials
+	}
+
+	// 4. Issue a session token. The frontend sends it back instead of the username.
+	token, err := a.createSession(user.Username)
+	if err != nil {
+		return "Error: Could not create session"
+	}
+
+	return "Success:" + token
 }
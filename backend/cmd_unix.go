@@ -1,7 +1,14 @@
+//go:build !windows
+
 package backend
 
-import "os/exec"
+import (
+	"os/exec"
+	"syscall"
+)
 
+// prepareCommand puts the child in its own process group, so a Ctrl+C in
+// our terminal doesn't reach it and we decide when it stops
 func prepareCommand(cmd *exec.Cmd) {
-	// Do nothing on Linux/Mac
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
@@ -0,0 +1,16 @@
+//go:build windows
+
+package backend
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// createNoWindow is CREATE_NO_WINDOW
+const createNoWindow = 0x08000000
+
+// prepareCommand stops child processes from flashing a console window
+func prepareCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: createNoWindow}
+}
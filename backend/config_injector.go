@@ -1,21 +1,48 @@
 package backend
 
 import (
-	"os"
+	"bufio"
+	"fmt"
+	"strings"
 )
 
-// InjectRcloneConfig creates the rclone.conf file programmatically
-// This allows friends to use the app without running 'rclone config' manually.
-// InjectConfig writes the server's Rclone credentials to a local file
-func (a *App) InjectConfig(configContent string) error {
-	// We write to rclone.conf next to the binary
-	configPath := getRcloneConfig()
+// remoteName is the rclone remote every server group's config defines
+const remoteName = "mc-remote"
 
-	// Write the file (0600 means read/write only by the app, for safety)
-	err := os.WriteFile(configPath, []byte(configContent), 0600)
-	if err != nil {
-		return err
-	}
+// rcloneEnv turns a stored rclone config into environment variables, so the
+// credentials are handed to rclone in memory and never written to disk.
+// [mc-remote] token = {...}  ->  RCLONE_CONFIG_MC_REMOTE_TOKEN={...}
+func rcloneEnv(configContent string) ([]string, error) {
+	prefix := "RCLONE_CONFIG_" + strings.ToUpper(strings.ReplaceAll(remoteName, "-", "_")) + "_"
 
-	return nil
+	var env []string
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(configContent))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // OAuth tokens can be long
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != remoteName {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		env = append(env, prefix+key+"="+strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(env) == 0 {
+		return nil, fmt.Errorf("cloud config has no [%s] section", remoteName)
+	}
+	return env, nil
 }
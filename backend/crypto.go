@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/scrypt"
+)
+
+// deriveKey stretches a password (or invite code) into a 32-byte AES key
+func deriveKey(secret string, salt string) ([]byte, error) {
+	return scrypt.Key([]byte(secret), []byte(salt), 1<<15, 8, 1, 32)
+}
+
+// sealSecret encrypts plaintext with AES-256-GCM and returns base64(nonce+ciphertext)
+func sealSecret(key []byte, plaintext []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openSecret reverses sealSecret
+func openSecret(key []byte, encoded string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// newGroupKey returns a fresh random 32-byte group key
+func newGroupKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// wrapForUser encrypts a group key to a member's public key
+func wrapForUser(groupKey []byte, publicKey string) (string, error) {
+	pub, err := decodeKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := box.SealAnonymous(nil, groupKey, pub, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// unwrapForUser decrypts a key share with the member's key pair
+func unwrapForUser(share string, identity *Identity) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(share)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := box.OpenAnonymous(nil, sealed, identity.Public, identity.Private)
+	if !ok {
+		return nil, fmt.Errorf("key share does not belong to %s", identity.Username)
+	}
+	return key, nil
+}
+
+// decodeKey parses a base64 curve25519 key
+func decodeKey(encoded string) (*[32]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != 32 {
+		return nil, fmt.Errorf("invalid key")
+	}
+	var key [32]byte
+	copy(key[:], raw)
+	return &key, nil
+}
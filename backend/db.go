@@ -3,6 +3,9 @@ package backend
 import (
 	"context"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -14,7 +17,36 @@ type DBClient struct {
 	Client *mongo.Client
 }
 
-var DB *DBClient // Global instance (simple for now)
+// OpenStore picks the storage backend.
+// MC_ROAM_STORE=embedded keeps everything in a local file (offline LAN use),
+// otherwise we connect to MongoDB.
+func OpenStore() (Store, error) {
+	if os.Getenv("MC_ROAM_STORE") == "embedded" {
+		path := filepath.Join(ensureDataDir(), "store.db")
+		log.Println("📦 Backend: Using embedded store at", path)
+		store, err := NewMemoryStore(path)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+
+	// Use build-time injected credentials or environment override
+	connStr := os.Getenv("MONGODB_URI")
+	if connStr == "" {
+		connStr = MongoDBURI // Injected at build time via ldflags
+	}
+	if connStr == "" {
+		// Fallback for development without build flags
+		connStr = "mongodb://localhost:27017"
+	}
+
+	client, err := ConnectDB(connStr)
+	if err != nil {
+		return nil, err
+	}
+	return NewMongoStore(client), nil
+}
 
 // ConnectDB attempts to connect to MongoDB Cloud
 func ConnectDB(connectionString string) (*DBClient, error) {
@@ -37,10 +69,9 @@ func ConnectDB(connectionString string) (*DBClient, error) {
 		return nil, fmt.Errorf("ping failed: %v", err)
 	}
 
-	fmt.Println("✅ Backend: Successfully connected to MongoDB!")
+	log.Println("✅ Backend: Successfully connected to MongoDB!")
 
-	DB = &DBClient{Client: client}
-	return DB, nil
+	return &DBClient{Client: client}, nil
 }
 
 // Disconnect closes the connection
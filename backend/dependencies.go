@@ -2,25 +2,44 @@ package backend
 
 import (
 	"archive/zip"
-	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 )
 
-// getToolPath returns the absolute path to a tool in the bin folder
+// exeName adds the executable extension used on this OS
+func exeName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// getToolPath returns the path to a tool: the one in our bin folder, else
+// one on the system PATH. If neither exists it returns where we install it.
 func getToolPath(toolName string) string {
-	appDir := getAppDir()
-	return filepath.Join(appDir, "bin", toolName)
+	bundled := filepath.Join(getAppDir(), "bin", exeName(toolName))
+	if _, err := os.Stat(bundled); err == nil {
+		return bundled
+	}
+	if path, err := exec.LookPath(toolName); err == nil {
+		return path
+	}
+	return bundled
 }
 
 // CheckDependencies verifies if all required tools exist
 func (a *App) CheckDependencies() bool {
-	rclonePath := getToolPath("rclone.exe")
+	rclonePath := getToolPath("rclone")
 	if _, err := os.Stat(rclonePath); os.IsNotExist(err) {
-		a.Log("⚠️ Missing: rclone.exe")
+		a.Log("⚠️ Missing: rclone")
 		return false
 	}
 	a.Log("✅ All dependencies present")
@@ -33,7 +52,7 @@ func (a *App) InstallDependencies() error {
 	os.MkdirAll(binDir, 0755)
 
 	// Check if rclone is missing
-	rclonePath := getToolPath("rclone.exe")
+	rclonePath := getToolPath("rclone")
 	if _, err := os.Stat(rclonePath); os.IsNotExist(err) {
 		a.Log("⬇️ Downloading Rclone core tools...")
 		if err := a.downloadRclone(binDir); err != nil {
@@ -45,61 +64,101 @@ func (a *App) InstallDependencies() error {
 	return nil
 }
 
-// downloadRclone fetches the official Rclone zip and extracts rclone.exe
-func (a *App) downloadRclone(binDir string) error {
-	url := "https://downloads.rclone.org/v1.66.0/rclone-v1.66.0-windows-amd64.zip"
+// Pinned rclone release. SHA256SUMS is the release manifest we verify against.
+const (
+	rcloneVersion = "v1.66.0"
+	rcloneBaseURL = "https://downloads.rclone.org/" + rcloneVersion + "/"
+	rcloneSumsURL = rcloneBaseURL + "SHA256SUMS"
+)
+
+// rcloneArchive names the release archive for this OS and CPU,
+// e.g. rclone-v1.66.0-linux-arm64.zip
+func rcloneArchive() (string, error) {
+	osName := map[string]string{"windows": "windows", "linux": "linux", "darwin": "osx", "freebsd": "freebsd"}[runtime.GOOS]
+	arch := map[string]string{"amd64": "amd64", "arm64": "arm64", "386": "386", "arm": "arm-v7"}[runtime.GOARCH]
+	if osName == "" || arch == "" {
+		return "", fmt.Errorf("no rclone build for %s/%s; install rclone and put it on the PATH", runtime.GOOS, runtime.GOARCH)
+	}
+	return "rclone-" + rcloneVersion + "-" + osName + "-" + arch + ".zip", nil
+}
 
-	// Download the Zip
-	resp, err := http.Get(url)
+// downloadRclone fetches the official Rclone zip for this platform and extracts the binary
+func (a *App) downloadRclone(binDir string) error {
+	rcloneZip, err := rcloneArchive()
 	if err != nil {
-		return fmt.Errorf("download failed: %v", err)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	sums, err := getBody(ctx, nil, rcloneSumsURL)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("could not fetch rclone checksums: %v", err)
+	}
+	checksum := checksumFromSums(string(sums), rcloneZip)
+	if checksum == "" {
+		return fmt.Errorf("%s is not listed in rclone's SHA256SUMS", rcloneZip)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("download failed with status: %d", resp.StatusCode)
+	// Download the Zip (verified, resumable)
+	zipPath := filepath.Join(binDir, rcloneZip)
+	if err := a.download(Download{URL: rcloneBaseURL + rcloneZip, Dest: zipPath, Checksum: checksum, Name: "Rclone"}); err != nil {
+		return fmt.Errorf("download failed: %v", err)
 	}
+	defer os.Remove(zipPath)
 
 	a.Log("📦 Extracting Rclone...")
 
-	// Read the body into memory
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read download: %v", err)
-	}
-
 	// Open Zip
-	zipReader, err := zip.NewReader(bytes.NewReader(bodyBytes), int64(len(bodyBytes)))
+	zipReader, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return fmt.Errorf("failed to read zip: %v", err)
 	}
+	defer zipReader.Close()
 
-	// Find and extract rclone.exe (it's usually inside a folder)
+	// Find and extract the binary (it's usually inside a folder)
+	binName := exeName("rclone")
 	for _, file := range zipReader.File {
-		if filepath.Base(file.Name) == "rclone.exe" {
-			// Found it! Extract it.
+		if path.Base(file.Name) == binName {
+			// Found it! Extract it next to the target and swap it in.
 			zippedFile, err := file.Open()
 			if err != nil {
 				return fmt.Errorf("failed to open file in zip: %v", err)
 			}
 			defer zippedFile.Close()
 
-			targetPath := filepath.Join(binDir, "rclone.exe")
-			outputFile, err := os.Create(targetPath)
+			targetPath := filepath.Join(binDir, binName)
+			tmpPath := targetPath + ".part"
+			outputFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
 			if err != nil {
 				return fmt.Errorf("failed to create target file: %v", err)
 			}
-			defer outputFile.Close()
 
 			_, err = io.Copy(outputFile, zippedFile)
+			outputFile.Close()
 			if err != nil {
+				os.Remove(tmpPath)
 				return fmt.Errorf("failed to write file: %v", err)
 			}
+			if err := os.Rename(tmpPath, targetPath); err != nil {
+				return fmt.Errorf("failed to install %s: %v", binName, err)
+			}
 
 			a.Log("✅ Rclone installed successfully!")
 			return nil
 		}
 	}
 
-	return fmt.Errorf("rclone.exe not found in downloaded zip")
+	return fmt.Errorf("%s not found in downloaded zip", binName)
+}
+
+// checksumFromSums finds a file in a "sha256sum"-style manifest.
+// Lines look like "<hex>  <filename>". Signature lines are skipped.
+func checksumFromSums(sums string, filename string) string {
+	for _, line := range strings.Split(sums, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == filename {
+			return checksumOf("sha256", fields[0])
+		}
+	}
+	return ""
 }
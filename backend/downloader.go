@@ -0,0 +1,263 @@
+package backend
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Download describes one file to fetch
+type Download struct {
+	URL      string
+	Dest     string
+	Checksum string // "sha256:<hex>", "sha1:<hex>"... Empty skips verification.
+	Name     string // Shown in the UI, e.g. "server.jar"
+}
+
+// DownloadProgress is the payload of TopicDownload events
+type DownloadProgress struct {
+	Name       string `json:"name"`
+	Downloaded int64  `json:"downloaded"`
+	Total      int64  `json:"total"` // 0 if the server didn't say
+	Done       bool   `json:"done"`
+	Error      string `json:"error,omitempty"`
+}
+
+// errPermanent marks download failures that retrying won't fix
+var errPermanent = errors.New("permanent download failure")
+
+// Downloader fetches files into a ".part" file next to the destination,
+// resumes it with Range requests, verifies the checksum and renames it
+// into place. A failed download never leaves a half-written destination.
+type Downloader struct {
+	Client     *http.Client
+	Retries    int           // Extra attempts after the first
+	Backoff    time.Duration // Doubled after each failed attempt
+	OnProgress func(DownloadProgress)
+}
+
+// NewDownloader returns a downloader with sane defaults
+func NewDownloader(onProgress func(DownloadProgress)) *Downloader {
+	return &Downloader{
+		// No overall timeout: big jars on slow links are fine as long as bytes flow
+		Client:     &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, ResponseHeaderTimeout: 60 * time.Second}},
+		Retries:    4,
+		Backoff:    2 * time.Second,
+		OnProgress: onProgress,
+	}
+}
+
+// Fetch downloads one file, retrying with backoff
+func (d *Downloader) Fetch(ctx context.Context, dl Download) error {
+	if dl.Name == "" {
+		dl.Name = filepath.Base(dl.Dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dl.Dest), 0755); err != nil {
+		return err
+	}
+	part := dl.Dest + ".part"
+
+	var err error
+	backoff := d.Backoff
+	for attempt := 0; attempt <= d.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		err = d.attempt(ctx, dl, part)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, errPermanent) || ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil {
+		d.report(DownloadProgress{Name: dl.Name, Done: true, Error: err.Error()})
+		return err
+	}
+
+	// Atomic swap: the destination is either the old file or the verified new one
+	if err := os.Rename(part, dl.Dest); err != nil {
+		return err
+	}
+	return nil
+}
+
+// attempt runs one request, continuing from whatever is already in the part file
+func (d *Downloader) attempt(ctx context.Context, dl Download, part string) error {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dl.URL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req.Header.Set("User-Agent", "mc-roam")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	total := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		flags |= os.O_APPEND
+		if total >= 0 {
+			total += offset
+		}
+	case resp.StatusCode == http.StatusOK:
+		// Server ignored the Range header: start over
+		flags |= os.O_TRUNC
+		offset = 0
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The part file is complete (or bigger than the file): verify what we have
+		if err := verifyChecksum(part, dl.Checksum); err != nil {
+			os.Remove(part)
+			return err
+		}
+		d.report(DownloadProgress{Name: dl.Name, Downloaded: offset, Total: offset, Done: true})
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %s returned %s", errPermanent, dl.URL, resp.Status)
+	default:
+		return fmt.Errorf("%s returned %s", dl.URL, resp.Status)
+	}
+
+	out, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+
+	progress := &progressWriter{d: d, name: dl.Name, done: offset, total: total}
+	_, copyErr := io.Copy(out, io.TeeReader(resp.Body, progress))
+	closeErr := out.Close()
+	if copyErr != nil {
+		return copyErr // Keep the part file so the next attempt resumes
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	if err := verifyChecksum(part, dl.Checksum); err != nil {
+		// A bad resume or a corrupted transfer: throw it away and retry from zero
+		os.Remove(part)
+		return err
+	}
+	d.report(DownloadProgress{Name: dl.Name, Downloaded: progress.done, Total: progress.done, Done: true})
+	return nil
+}
+
+func (d *Downloader) report(p DownloadProgress) {
+	if d.OnProgress != nil {
+		d.OnProgress(p)
+	}
+}
+
+// progressWriter counts bytes and reports at most a few times per second
+type progressWriter struct {
+	d          *Downloader
+	name       string
+	done       int64
+	total      int64
+	lastReport time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if time.Since(p.lastReport) >= 250*time.Millisecond {
+		p.lastReport = time.Now()
+		p.d.report(DownloadProgress{Name: p.name, Downloaded: p.done, Total: p.total})
+	}
+	return len(b), nil
+}
+
+// verifyChecksum hashes a file and compares it with an "algo:hex" checksum
+func verifyChecksum(path string, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	algo, want, ok := strings.Cut(checksum, ":")
+	if !ok {
+		return fmt.Errorf("%w: malformed checksum %q", errPermanent, checksum)
+	}
+
+	var h hash.Hash
+	switch strings.ToLower(algo) {
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return fmt.Errorf("%w: unsupported checksum %q", errPermanent, algo)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	got := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", filepath.Base(path), want, got)
+	}
+	return nil
+}
+
+// download fetches a file with progress sent to the UI
+func (a *App) download(dl Download) error {
+	if dl.Name == "" {
+		dl.Name = filepath.Base(dl.Dest)
+	}
+	if dl.Checksum == "" {
+		a.Log(fmt.Sprintf("⚠️ No checksum published for %s, it can't be verified.", dl.Name))
+	}
+
+	d := NewDownloader(func(p DownloadProgress) {
+		severity := SeverityInfo
+		if p.Error != "" {
+			severity = SeverityError
+		}
+		a.publish(Event{Topic: TopicDownload, Source: SourceApp, Severity: severity, Payload: p})
+	})
+	if err := d.Fetch(context.Background(), dl); err != nil {
+		return err
+	}
+	if dl.Checksum != "" {
+		a.Log(fmt.Sprintf("🔒 %s verified (%s)", dl.Name, strings.SplitN(dl.Checksum, ":", 2)[0]))
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var jarBytes = bytes.Repeat([]byte("minecraft server jar "), 4096)
+
+func jarChecksum() string {
+	sum := sha256.Sum256(jarBytes)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// jarFixture serves jarBytes with Range support, failing the first
+// failures requests with status
+func jarFixture(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		http.ServeContent(w, r, "server.jar", time.Time{}, bytes.NewReader(jarBytes))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func testDownloader() *Downloader {
+	return &Downloader{Client: http.DefaultClient, Retries: 2, Backoff: time.Millisecond}
+}
+
+func TestFetchVerifiesAndRenames(t *testing.T) {
+	srv, _ := jarFixture(t, 0, 0)
+	dest := filepath.Join(t.TempDir(), "server.jar")
+
+	var last DownloadProgress
+	d := testDownloader()
+	d.OnProgress = func(p DownloadProgress) { last = p }
+	if err := d.Fetch(context.Background(), Download{URL: srv.URL, Dest: dest, Checksum: jarChecksum()}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil || !bytes.Equal(got, jarBytes) {
+		t.Fatalf("destination has %d bytes (%v), want the jar", len(got), err)
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Error("part file left behind")
+	}
+	if !last.Done || last.Downloaded != int64(len(jarBytes)) {
+		t.Errorf("last progress = %+v", last)
+	}
+}
+
+func TestFetchChecksumMismatch(t *testing.T) {
+	srv, hits := jarFixture(t, 0, 0)
+	dest := filepath.Join(t.TempDir(), "server.jar")
+	os.WriteFile(dest, []byte("old jar"), 0644)
+
+	err := testDownloader().Fetch(context.Background(), Download{URL: srv.URL, Dest: dest, Checksum: "sha256:" + strings.Repeat("0", 64)})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Fetch error = %v, want a checksum mismatch", err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != "old jar" {
+		t.Error("a failed download replaced the destination")
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Error("a corrupt part file was kept")
+	}
+	if hits.Load() != 3 {
+		t.Errorf("%d requests, want 3 (a corrupt transfer is retried)", hits.Load())
+	}
+}
+
+func TestFetchResumesPartFile(t *testing.T) {
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "server.jar", time.Time{}, bytes.NewReader(jarBytes))
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "server.jar")
+	half := len(jarBytes) / 2
+	os.WriteFile(dest+".part", jarBytes[:half], 0644)
+
+	if err := testDownloader().Fetch(context.Background(), Download{URL: srv.URL, Dest: dest, Checksum: jarChecksum()}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes="+strconv.Itoa(half)+"-" {
+		t.Errorf("Range headers = %q, want one resume from %d", ranges, half)
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, jarBytes) {
+		t.Error("resumed file differs from the jar")
+	}
+}
+
+func TestFetchRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32
+		status   int
+		wantErr  bool
+		wantHits int32
+	}{
+		{name: "server errors are retried", failures: 2, status: http.StatusBadGateway, wantHits: 3},
+		{name: "rate limits are retried", failures: 1, status: http.StatusTooManyRequests, wantHits: 2},
+		{name: "retries run out", failures: 5, status: http.StatusServiceUnavailable, wantErr: true, wantHits: 3},
+		{name: "not found is permanent", failures: 5, status: http.StatusNotFound, wantErr: true, wantHits: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := jarFixture(t, tt.failures, tt.status)
+			dest := filepath.Join(t.TempDir(), "server.jar")
+			err := testDownloader().Fetch(context.Background(), Download{URL: srv.URL, Dest: dest, Checksum: jarChecksum()})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if hits.Load() != tt.wantHits {
+				t.Errorf("%d requests, want %d", hits.Load(), tt.wantHits)
+			}
+			if _, statErr := os.Stat(dest); tt.wantErr != os.IsNotExist(statErr) {
+				t.Errorf("destination exists = %v after err %v", statErr == nil, err)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	os.WriteFile(path, []byte("abc"), 0644)
+	tests := []struct {
+		checksum string
+		ok       bool
+	}{
+		{"", true},
+		{"sha1:a9993e364706816aba3e25717850c26c9cd0d89d", true},
+		{"SHA1:A9993E364706816ABA3E25717850C26C9CD0D89D", true},
+		{"md5:900150983cd24fb0d6963f7d28e17f72", true},
+		{"sha256:ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", true},
+		{"sha256:0000", false},
+		{"crc32:352441c2", false},
+		{"nocolon", false},
+	}
+	for _, tt := range tests {
+		if err := verifyChecksum(path, tt.checksum); (err == nil) != tt.ok {
+			t.Errorf("verifyChecksum(%q) = %v, want ok=%v", tt.checksum, err, tt.ok)
+		}
+	}
+}
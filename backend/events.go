@@ -0,0 +1,213 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Event topics. The frontend receives each one as "bus:<topic>".
+const (
+	TopicLog       = "log"       // Human-readable system messages (what App.Log used to send)
+	TopicConsole   = "console"   // Lines printed by a Minecraft server or the Playit agent
+	TopicSync      = "sync"      // Rclone transfer progress
+	TopicLifecycle = "lifecycle" // A server changed state (syncing, running, stopped...)
+	TopicTunnel    = "tunnel"    // A server got a public address
+	TopicDownload  = "download"  // Jar & tool download progress
+	TopicGame      = "game"      // Parsed server log: joins, chat, deaths, ready, crashes
+	TopicExport    = "export"    // World export progress
+)
+
+// Event sources
+const (
+	SourceApp       = "app"
+	SourceMinecraft = "minecraft"
+	SourceRclone    = "rclone"
+	SourcePlayit    = "playit"
+)
+
+// Severity of an event
+type Severity string
+
+const (
+	SeverityInfo  Severity = "info"
+	SeverityWarn  Severity = "warn"
+	SeverityError Severity = "error"
+)
+
+// Event is one typed message on the bus
+type Event struct {
+	Topic    string      `json:"topic"`
+	Source   string      `json:"source"`
+	ServerID string      `json:"serverId,omitempty"`
+	Severity Severity    `json:"severity"`
+	Time     time.Time   `json:"time"`
+	Message  string      `json:"message,omitempty"`
+	Payload  interface{} `json:"payload,omitempty"`
+}
+
+// ConsoleLine is the payload of TopicConsole events
+type ConsoleLine struct {
+	Stream string `json:"stream"` // "stdout", "stderr" or "rcon" (a command reply)
+}
+
+// SyncProgress is the payload of TopicSync events
+type SyncProgress struct {
+	Direction SyncDirection `json:"direction"`
+	Phase     string        `json:"phase"`   // "start", "progress", "done" or "error"
+	Percent   int           `json:"percent"` // -1 if unknown
+}
+
+// ServerState is the payload of TopicLifecycle events
+type ServerState struct {
+	State string `json:"state"`
+}
+
+// Lifecycle states
+const (
+	StateSyncingDown = "syncing-down"
+	StateInstalling  = "installing"
+	StateStarting    = "starting"
+	StateRunning     = "running"
+	StateStopping    = "stopping"
+	StateSyncingUp   = "syncing-up"
+	StateStopped     = "stopped"
+	StateExited      = "exited" // The game quit by itself; the session isn't uploaded yet
+	StateCrashed     = "crashed"
+)
+
+// TunnelAddress is the payload of TopicTunnel events
+type TunnelAddress struct {
+	Address string `json:"address"`
+}
+
+// EventBus fans events out to subscribers. Publish is synchronous, so
+// subscribers must be quick (emit, print, append) and never publish back.
+type EventBus struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]subscription
+}
+
+type subscription struct {
+	topic string // "" receives every topic
+	fn    func(Event)
+}
+
+// NewEventBus creates an empty bus
+func NewEventBus() *EventBus {
+	return &EventBus{subs: map[int]subscription{}}
+}
+
+// Subscribe registers fn for a topic ("" for all) and returns an unsubscribe func
+func (b *EventBus) Subscribe(topic string, fn func(Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subs[id] = subscription{topic: topic, fn: fn}
+	return func() {
+		b.mu.Lock()
+		delete(b.subs, id)
+		b.mu.Unlock()
+	}
+}
+
+// Publish stamps and delivers an event
+func (b *EventBus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Severity == "" {
+		e.Severity = SeverityInfo
+	}
+
+	b.mu.Lock()
+	targets := make([]func(Event), 0, len(b.subs))
+	for _, s := range b.subs {
+		if s.topic == "" || s.topic == e.Topic {
+			targets = append(targets, s.fn)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, fn := range targets {
+		fn(e)
+	}
+}
+
+// legacyText renders an event the way the old "server-log" strings looked,
+// so the system log pane keeps working unchanged.
+func legacyText(e Event) string {
+	switch e.Source {
+	case SourceMinecraft:
+		return "[MC]: " + e.Message
+	case SourceRclone:
+		if e.Severity == SeverityError {
+			return "[Sync Error]: " + e.Message
+		}
+		return "[Sync]: " + e.Message
+	case SourcePlayit:
+		return "[Playit]: " + e.Message
+	}
+	return e.Message
+}
+
+// Text renders the event as one line for logs and terminals
+func (e Event) Text() string {
+	return legacyText(e)
+}
+
+// printEvents writes every event with text to stdout (dev console / CLI)
+func printEvents(e Event) {
+	if e.Message != "" {
+		fmt.Println(legacyText(e))
+	}
+}
+
+// bridgeToFrontend forwards bus events to the Wails window
+func (a *App) bridgeToFrontend() {
+	a.events.Subscribe("", func(e Event) {
+		if a.ctx == nil {
+			return
+		}
+		runtime.EventsEmit(a.ctx, "bus:"+e.Topic, e)
+		if e.Message != "" {
+			runtime.EventsEmit(a.ctx, "server-log", legacyText(e)) // Compatibility
+		}
+	})
+}
+
+// publish sends an event on the app's bus
+func (a *App) publish(e Event) {
+	a.events.Publish(e)
+}
+
+// setState announces a server lifecycle change
+func (a *App) setState(serverID string, state string) {
+	severity := SeverityInfo
+	if state == StateCrashed {
+		severity = SeverityError
+	}
+	a.publish(Event{
+		Topic:    TopicLifecycle,
+		Source:   SourceApp,
+		ServerID: serverID,
+		Severity: severity,
+		Payload:  ServerState{State: state},
+	})
+}
+
+// severityOf guesses a severity from the emoji convention used in log messages
+func severityOf(message string) Severity {
+	switch {
+	case strings.HasPrefix(message, "❌"):
+		return SeverityError
+	case strings.HasPrefix(message, "⚠️"):
+		return SeverityWarn
+	}
+	return SeverityInfo
+}
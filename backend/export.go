@@ -0,0 +1,396 @@
+package backend
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mc-roam/backend/properties"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// exportFlushTimeout bounds the wait for "save-all flush" to finish
+const exportFlushTimeout = 2 * time.Minute
+
+// ExportOptions chooses what goes into a world export
+type ExportOptions struct {
+	Snapshot   string `json:"snapshot,omitempty"` // Export a snapshot instead of this PC's copy
+	Configs    bool   `json:"configs"`            // server.properties, ops, whitelist, bans
+	PlayerData bool   `json:"player_data"`        // playerdata/, stats/, advancements/
+	Flat       bool   `json:"flat"`               // World files at the archive root (.mcworld style), not in a folder
+}
+
+// ExportProgress is the payload of TopicExport events
+type ExportProgress struct {
+	Phase      string `json:"phase"` // "saving", "downloading", "writing", "done" or "error"
+	Files      int    `json:"files"`
+	TotalFiles int    `json:"total_files"`
+	Bytes      int64  `json:"bytes"`
+	TotalBytes int64  `json:"total_bytes"`
+	Dest       string `json:"dest"`
+	Error      string `json:"error,omitempty"`
+}
+
+// playerDataDirs hold per-player files inside a world
+var playerDataDirs = []string{"playerdata", "stats", "advancements", "players"}
+
+// exportEntry is one file to write: its path on disk and in the archive
+type exportEntry struct {
+	src  string
+	name string
+	size int64
+}
+
+// ChooseExportFile opens a save dialog for ExportWorld
+func (a *App) ChooseExportFile(serverID string) string {
+	if a.ctx == nil {
+		return ""
+	}
+	name := "world"
+	if server, err := a.getServer(serverID); err == nil {
+		name = server.Name
+	}
+	dest, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export world",
+		DefaultFilename: name + ".zip",
+		Filters:         []runtime.FileFilter{{DisplayName: "Zip archive (*.zip)", Pattern: "*.zip"}},
+	})
+	if err != nil {
+		return ""
+	}
+	return dest
+}
+
+// ExportWorld writes the world to a zip at dest, from this PC's copy or
+// a snapshot. Every dimension ends up inside the world folder, so the
+// archive opens in singleplayer even when it came from a Bukkit server.
+// A server running here is told to stop saving while its files are read.
+func (a *App) ExportWorld(serverID string, token string, dest string, options ExportOptions) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if !a.isMember(serverID, username) {
+		return "Error: Only members can export the world"
+	}
+	if dest == "" {
+		return "Error: Choose where to save the export"
+	}
+
+	files := 0
+	err = a.exportWorld(serverID, username, dest, options, func(entries []exportEntry, onProgress func(int, int64)) error {
+		files = len(entries)
+		return writeExport(dest, entries, onProgress)
+	})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return fmt.Sprintf("Success: Exported %d files to %s", files, dest)
+}
+
+// exportWorld picks the files to export and hands them to write, with
+// progress events for dest (a path, or where an API download goes). Only
+// admins may pause saving on a server running here, as with other console
+// commands.
+func (a *App) exportWorld(serverID string, username string, dest string, options ExportOptions, write func(entries []exportEntry, onProgress func(file int, written int64)) error) error {
+	if options.Flat && options.Configs {
+		return fmt.Errorf("server configs can't go in a flat (.mcworld style) archive")
+	}
+
+	progress := ExportProgress{Dest: dest}
+	report := func(phase string) {
+		progress.Phase = phase
+		a.publish(Event{Topic: TopicExport, Source: SourceApp, ServerID: serverID, Payload: progress})
+	}
+	fail := func(err error) error {
+		progress.Error = err.Error()
+		report("error")
+		return err
+	}
+
+	dir := a.getInstancePath(serverID)
+	if options.Snapshot != "" {
+		// Snapshots live in the cloud; bring one down next to the instances
+		if _, err := time.Parse(snapshotTimeLayout, options.Snapshot); err != nil {
+			return fmt.Errorf("invalid snapshot name")
+		}
+		report("downloading")
+		staging, err := a.downloadSnapshot(serverID, options.Snapshot)
+		if err != nil {
+			return fail(err)
+		}
+		defer os.RemoveAll(staging)
+		dir = staging
+	} else if a.procs.IsRunning(serverID) {
+		if !a.isAdmin(serverID, username) {
+			return fmt.Errorf("the server is running; only admins can export it now (or export a snapshot)")
+		}
+		report("saving")
+		resume, err := a.pauseSaving(serverID)
+		if err != nil {
+			return fail(err)
+		}
+		defer resume()
+	} else if server, err := a.getServer(serverID); err == nil && server.Lock.IsRunning {
+		a.Log("⚠️ The server is running on another PC; exporting this PC's last synced copy")
+	}
+
+	entries, err := exportEntries(dir, options)
+	if err != nil {
+		return fail(err)
+	}
+	for _, e := range entries {
+		progress.TotalFiles++
+		progress.TotalBytes += e.size
+	}
+
+	a.Log(fmt.Sprintf("📦 Exporting world (%d files) to %s...", len(entries), dest))
+	report("writing")
+	last := time.Now()
+	err = write(entries, func(file int, written int64) {
+		progress.Files, progress.Bytes = file, written
+		if time.Since(last) > 250*time.Millisecond {
+			last = time.Now()
+			report("writing")
+		}
+	})
+	if err != nil {
+		return fail(err)
+	}
+	report("done")
+	a.Log("✅ World exported to " + dest)
+	return nil
+}
+
+// downloadSnapshot copies a snapshot to a temporary folder
+func (a *App) downloadSnapshot(serverID string, name string) (string, error) {
+	instances := a.getInstancePath("")
+	os.MkdirAll(instances, 0755)
+	staging, err := os.MkdirTemp(instances, ".export-")
+	if err != nil {
+		return "", err
+	}
+	a.Log(fmt.Sprintf("⬇️ Downloading snapshot %s...", name))
+	cmd, err := a.rcloneCommand(serverID, "copy", snapshotRoot(serverID)+"/"+name, staging, "--transfers", "4")
+	if err == nil {
+		var output []byte
+		if output, err = cmd.CombinedOutput(); err != nil {
+			err = fmt.Errorf("snapshot download failed: %v (%s)", err, strings.TrimSpace(string(output)))
+		}
+	}
+	if err != nil {
+		os.RemoveAll(staging)
+		return "", err
+	}
+	return staging, nil
+}
+
+// savePauses counts the exports reading each running server, so autosave
+// only comes back on when the last one is done
+var (
+	savePausesMu sync.Mutex
+	savePauses   = map[string]*savePause{}
+)
+
+type savePause struct {
+	mu    sync.Mutex
+	count int
+}
+
+// pauseSaving flushes a running server's world to disk and turns off
+// autosave so the files don't change mid-read. The returned func turns it
+// back on once no other export holds the pause.
+func (a *App) pauseSaving(serverID string) (func(), error) {
+	savePausesMu.Lock()
+	p, ok := savePauses[serverID]
+	if !ok {
+		p = &savePause{}
+		savePauses[serverID] = p
+	}
+	savePausesMu.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.count--; p.count == 0 {
+				a.resumeSaving(serverID)
+			}
+		})
+	}
+	if p.count > 0 {
+		p.count++ // Already flushed and paused for another export
+		return release, nil
+	}
+
+	// "Saved the game" ("Saved the world" before 1.13) is printed when the
+	// flush is done, whether the command went over RCON or stdin
+	saved := make(chan struct{}, 1)
+	unsubscribe := a.events.Subscribe(TopicConsole, func(e Event) {
+		if e.ServerID == serverID && strings.Contains(e.Message, "Saved the ") {
+			select {
+			case saved <- struct{}{}:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+
+	if _, err := a.consoleCommand(serverID, "save-off"); err != nil {
+		return nil, err
+	}
+	a.Log("💾 Saving the world before export...")
+	reply, err := a.consoleCommand(serverID, "save-all flush")
+	if err != nil {
+		a.resumeSaving(serverID)
+		return nil, err
+	}
+	if !strings.Contains(reply, "Saved the ") {
+		select {
+		case <-saved:
+		case <-time.After(exportFlushTimeout):
+			a.resumeSaving(serverID)
+			return nil, fmt.Errorf("the server didn't finish saving")
+		}
+	}
+	p.count = 1
+	return release, nil
+}
+
+func (a *App) resumeSaving(serverID string) {
+	if _, err := a.consoleCommand(serverID, "save-on"); err != nil {
+		a.Log("⚠️ Could not turn autosave back on: " + err.Error())
+	}
+}
+
+// exportEntries lists the files to export from a server folder
+func exportEntries(dir string, options ExportOptions) ([]exportEntry, error) {
+	levelName := "world"
+	if props, err := properties.Load(filepath.Join(dir, "server.properties")); err == nil {
+		if name, ok := props.Get("level-name"); ok && name != "" {
+			levelName = name
+		}
+	}
+	if !plainName(levelName) {
+		return nil, fmt.Errorf("invalid level-name %q in server.properties", levelName)
+	}
+	world := filepath.Join(dir, levelName)
+	if !fileExists(filepath.Join(world, "level.dat")) {
+		return nil, fmt.Errorf("no world found at %s", levelName)
+	}
+
+	prefix := ""
+	if !options.Flat {
+		prefix = levelName + "/"
+	}
+	var entries []exportEntry
+	add := func(src string, name string) error {
+		return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(src, p)
+			rel = filepath.ToSlash(rel)
+			if info.IsDir() {
+				if !options.PlayerData && containsString(playerDataDirs, rel) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() || info.Name() == "session.lock" {
+				return nil
+			}
+			entries = append(entries, exportEntry{src: p, name: path.Join(name, rel), size: info.Size()})
+			return nil
+		})
+	}
+
+	if err := add(world, prefix); err != nil {
+		return nil, err
+	}
+	// Bukkit layout: move the dimensions back inside the world
+	for _, dim := range []struct{ suffix, name string }{{"_nether", "DIM-1"}, {"_the_end", "DIM1"}} {
+		src := filepath.Join(dir, levelName+dim.suffix, dim.name)
+		if dirExists(src) && !dirExists(filepath.Join(world, dim.name)) {
+			if err := add(src, prefix+dim.name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if options.Configs {
+		for _, name := range importedConfigs {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.Mode().IsRegular() {
+				entries = append(entries, exportEntry{src: filepath.Join(dir, name), name: name, size: info.Size()})
+			}
+		}
+	}
+	return entries, nil
+}
+
+// writeExport writes entries into a zip at dest. It writes to a .part file
+// first, so a failed export never leaves a half-written archive.
+func writeExport(dest string, entries []exportEntry, onProgress func(file int, written int64)) error {
+	part := dest + ".part"
+	out, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(part) // No-op after the rename
+
+	if err := writeZip(out, entries, onProgress); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(part, dest)
+}
+
+// writeZip streams entries as a zip into w
+func writeZip(w io.Writer, entries []exportEntry, onProgress func(file int, written int64)) error {
+	zw := zip.NewWriter(w)
+	var written int64
+	for i, e := range entries {
+		n, err := addToZip(zw, e)
+		if err != nil {
+			return fmt.Errorf("%s: %v", e.name, err)
+		}
+		written += n
+		onProgress(i+1, written)
+	}
+	return zw.Close()
+}
+
+func addToZip(zw *zip.Writer, e exportEntry) (int64, error) {
+	in, err := os.Open(e.src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return 0, err
+	}
+	header.Name = e.name
+	header.Method = zip.Deflate
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(w, in)
+}
@@ -0,0 +1,28 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportEntriesLevelName(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "server")
+	os.MkdirAll(filepath.Join(dir, "world"), 0755)
+	os.WriteFile(filepath.Join(dir, "world", "level.dat"), nil, 0644)
+	// A world next to the server folder, which a crafted level-name could reach
+	os.MkdirAll(filepath.Join(parent, "other"), 0755)
+	os.WriteFile(filepath.Join(parent, "other", "level.dat"), nil, 0644)
+
+	if _, err := exportEntries(dir, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"../other", filepath.Join(parent, "other"), ".."} {
+		os.WriteFile(filepath.Join(dir, "server.properties"), []byte("level-name="+name+"\n"), 0644)
+		if _, err := exportEntries(dir, ExportOptions{}); err == nil || !strings.Contains(err.Error(), "invalid level-name") {
+			t.Errorf("exportEntries with level-name %q error = %v", name, err)
+		}
+	}
+}
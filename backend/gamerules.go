@@ -0,0 +1,441 @@
+package backend
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GameRule describes one /gamerule and the versions that have it
+type GameRule struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"` // "bool" or "int"
+	Default string `json:"default"`
+
+	since string // First Minecraft version with the rule ("" = always)
+	until string // First version without it ("" = still there)
+}
+
+// GameRuleState is a rule with the group's saved value and, while the server
+// runs on this PC, the value the world actually has
+type GameRuleState struct {
+	GameRule
+	Value string `json:"value"` // Live value, else saved value, else default
+	Saved bool   `json:"saved"` // Stored in world_settings
+	Live  bool   `json:"live"`  // Read from the running server
+}
+
+// difficultySetting is kept in world_settings next to the gamerules but set
+// with /difficulty
+const difficultySetting = "difficulty"
+
+var difficulties = []string{"peaceful", "easy", "normal", "hard"}
+
+// gameRuleCatalog lists the vanilla gamerules
+var gameRuleCatalog = []GameRule{
+	{Name: "announceAdvancements", Type: "bool", Default: "true", since: "1.12"},
+	{Name: "blockExplosionDropDecay", Type: "bool", Default: "true", since: "1.19.3"},
+	{Name: "commandBlockOutput", Type: "bool", Default: "true"},
+	{Name: "commandModificationBlockLimit", Type: "int", Default: "32768", since: "1.19.4"},
+	{Name: "disableElytraMovementCheck", Type: "bool", Default: "false"},
+	{Name: "disablePlayerMovementCheck", Type: "bool", Default: "false", since: "1.21.2"},
+	{Name: "disableRaids", Type: "bool", Default: "false", since: "1.14.3"},
+	{Name: "doDaylightCycle", Type: "bool", Default: "true"},
+	{Name: "doEntityDrops", Type: "bool", Default: "true"},
+	{Name: "doFireTick", Type: "bool", Default: "true"},
+	{Name: "doImmediateRespawn", Type: "bool", Default: "false", since: "1.15"},
+	{Name: "doInsomnia", Type: "bool", Default: "true", since: "1.15"},
+	{Name: "doLimitedCrafting", Type: "bool", Default: "false", since: "1.12"},
+	{Name: "doMobLoot", Type: "bool", Default: "true"},
+	{Name: "doMobSpawning", Type: "bool", Default: "true"},
+	{Name: "doPatrolSpawning", Type: "bool", Default: "true", since: "1.15.2"},
+	{Name: "doTileDrops", Type: "bool", Default: "true"},
+	{Name: "doTraderSpawning", Type: "bool", Default: "true", since: "1.15.2"},
+	{Name: "doVinesSpread", Type: "bool", Default: "true", since: "1.19.3"},
+	{Name: "doWardenSpawning", Type: "bool", Default: "true", since: "1.19"},
+	{Name: "doWeatherCycle", Type: "bool", Default: "true", since: "1.11"},
+	{Name: "drowningDamage", Type: "bool", Default: "true", since: "1.15"},
+	{Name: "enderPearlsVanishOnDeath", Type: "bool", Default: "true", since: "1.20.2"},
+	{Name: "fallDamage", Type: "bool", Default: "true", since: "1.15"},
+	{Name: "fireDamage", Type: "bool", Default: "true", since: "1.15"},
+	{Name: "forgiveDeadPlayers", Type: "bool", Default: "true", since: "1.16"},
+	{Name: "freezeDamage", Type: "bool", Default: "true", since: "1.17"},
+	{Name: "globalSoundEvents", Type: "bool", Default: "true", since: "1.19.3"},
+	{Name: "keepInventory", Type: "bool", Default: "false"},
+	{Name: "lavaSourceConversion", Type: "bool", Default: "false", since: "1.19.3"},
+	{Name: "logAdminCommands", Type: "bool", Default: "true"},
+	{Name: "maxCommandChainLength", Type: "int", Default: "65536", since: "1.12"},
+	{Name: "maxCommandForkCount", Type: "int", Default: "65536", since: "1.20.3"},
+	{Name: "maxEntityCramming", Type: "int", Default: "24", since: "1.11"},
+	{Name: "mobExplosionDropDecay", Type: "bool", Default: "true", since: "1.19.3"},
+	{Name: "mobGriefing", Type: "bool", Default: "true"},
+	{Name: "naturalRegeneration", Type: "bool", Default: "true"},
+	{Name: "playersSleepingPercentage", Type: "int", Default: "100", since: "1.17"},
+	{Name: "projectilesCanBreakBlocks", Type: "bool", Default: "true", since: "1.20.5"},
+	{Name: "randomTickSpeed", Type: "int", Default: "3"},
+	{Name: "reducedDebugInfo", Type: "bool", Default: "false"},
+	{Name: "sendCommandFeedback", Type: "bool", Default: "true"},
+	{Name: "showDeathMessages", Type: "bool", Default: "true"},
+	{Name: "snowAccumulationHeight", Type: "int", Default: "1", since: "1.19.3"},
+	{Name: "spawnChunkRadius", Type: "int", Default: "2", since: "1.20.5"},
+	{Name: "spawnRadius", Type: "int", Default: "10"},
+	{Name: "spectatorsGenerateChunks", Type: "bool", Default: "true"},
+	{Name: "tntExplosionDropDecay", Type: "bool", Default: "false", since: "1.19.3"},
+	{Name: "universalAnger", Type: "bool", Default: "false", since: "1.16"},
+	{Name: "waterSourceConversion", Type: "bool", Default: "true", since: "1.19.3"},
+}
+
+var (
+	gameRuleNameRe  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	gameRuleValueRe = regexp.MustCompile(`(?:currently set to: | = )(\S+)\s*$`) // 1.13+ / older
+)
+
+// liveRulesTTL is how long the values read from a running server are
+// reused. Reading them takes one RCON round trip per rule.
+const liveRulesTTL = time.Minute
+
+// liveRules caches the live gamerule values of servers running here
+type liveRules struct {
+	readAt time.Time
+	values map[string]string
+}
+
+var (
+	liveRulesMu sync.Mutex
+	liveRuleSet = map[string]*liveRules{}
+)
+
+// gameRulesFor lists the rules a Minecraft version has. Snapshots and
+// unknown versions get every rule.
+func gameRulesFor(version string) []GameRule {
+	var rules []GameRule
+	for _, rule := range gameRuleCatalog {
+		if rule.in(version) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func lookupGameRule(version string, name string) (GameRule, bool) {
+	for _, rule := range gameRuleCatalog {
+		if rule.Name == name && rule.in(version) {
+			return rule, true
+		}
+	}
+	return GameRule{}, false
+}
+
+func (r GameRule) in(version string) bool {
+	if !isReleaseVersion(version) {
+		return true
+	}
+	if r.since != "" && compareVersions(version, r.since) < 0 {
+		return false
+	}
+	return r.until == "" || compareVersions(version, r.until) < 0
+}
+
+// isReleaseVersion reports whether a version looks like 1.20.4
+func isReleaseVersion(version string) bool {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if _, err := strconv.Atoi(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize checks a value against the rule's type and returns it in the
+// form the game expects
+func (r GameRule) normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch r.Type {
+	case "bool":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return strconv.FormatBool(b), nil
+		}
+		return "", fmt.Errorf("%s must be true or false", r.Name)
+	case "int":
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("%s must be a whole number of 0 or more", r.Name)
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+	return value, nil
+}
+
+// settingString turns a world_settings value (bool, number or string from
+// the UI) into its command form
+func settingString(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// consoleCommand runs a command on a server hosted here, over RCON when it
+// is up (so we get a reply) and through stdin otherwise
+func (a *App) consoleCommand(serverID string, command string) (string, error) {
+	if !a.procs.IsRunning(serverID) {
+		return "", fmt.Errorf("server is not online")
+	}
+	reply, err := a.rconCommand(serverID, command)
+	if err != nil {
+		if err := a.procs.Send(serverID, command); err != nil {
+			return "", fmt.Errorf("failed to send command")
+		}
+		return "", nil
+	}
+	return reply, nil
+}
+
+// readGameRule asks the running server for a rule's value. Only RCON
+// returns the answer, so this fails until the server is ready.
+func (a *App) readGameRule(serverID string, name string) (string, bool) {
+	value, ok, _ := a.queryGameRule(serverID, name)
+	return value, ok
+}
+
+// queryGameRule is readGameRule that also says whether RCON itself failed
+// (as opposed to the server not knowing the rule)
+func (a *App) queryGameRule(serverID string, name string) (string, bool, error) {
+	if !a.procs.IsRunning(serverID) {
+		return "", false, fmt.Errorf("server is not online")
+	}
+	reply, err := a.rconCommand(serverID, "gamerule "+name)
+	if err != nil {
+		return "", false, err
+	}
+	m := gameRuleValueRe.FindStringSubmatch(strings.TrimSpace(reply))
+	if m == nil {
+		return "", false, nil
+	}
+	return m[1], true, nil
+}
+
+// liveGameRules returns the running server's values for rules, reusing a
+// recent read. Nothing is returned (or cached) if RCON isn't up yet.
+func (a *App) liveGameRules(serverID string, rules []GameRule) map[string]string {
+	if !a.procs.IsRunning(serverID) {
+		return nil
+	}
+	liveRulesMu.Lock()
+	cached := liveRuleSet[serverID]
+	liveRulesMu.Unlock()
+	if cached != nil && time.Since(cached.readAt) < liveRulesTTL {
+		return cached.values
+	}
+
+	values := map[string]string{}
+	for _, rule := range rules {
+		value, ok, err := a.queryGameRule(serverID, rule.Name)
+		if err != nil {
+			return nil // Don't wait out a timeout for every rule
+		}
+		if ok {
+			values[rule.Name] = value
+		}
+	}
+	liveRulesMu.Lock()
+	liveRuleSet[serverID] = &liveRules{readAt: time.Now(), values: values}
+	liveRulesMu.Unlock()
+	return values
+}
+
+// setLiveGameRule records a value we just applied, so the cache stays right
+func setLiveGameRule(serverID string, name string, value string) {
+	liveRulesMu.Lock()
+	defer liveRulesMu.Unlock()
+	if cached := liveRuleSet[serverID]; cached != nil {
+		values := make(map[string]string, len(cached.values)+1)
+		for k, v := range cached.values {
+			values[k] = v
+		}
+		values[name] = value
+		liveRuleSet[serverID] = &liveRules{readAt: cached.readAt, values: values}
+	}
+}
+
+// forgetLiveGameRules drops the cache when the server stops
+func forgetLiveGameRules(serverID string) {
+	liveRulesMu.Lock()
+	delete(liveRuleSet, serverID)
+	liveRulesMu.Unlock()
+}
+
+// customGameRule describes a rule outside the catalog (mods, newer versions)
+// from the running server's current value: "true"/"false" means bool,
+// anything else int
+func (a *App) customGameRule(serverID string, name string) (GameRule, bool) {
+	if !gameRuleNameRe.MatchString(name) {
+		return GameRule{}, false
+	}
+	current, ok := a.readGameRule(serverID, name)
+	if !ok {
+		return GameRule{}, false
+	}
+	kind := "int"
+	if current == "true" || current == "false" {
+		kind = "bool"
+	}
+	return GameRule{Name: name, Type: kind}, true
+}
+
+// GetGameRules lists the gamerules of the server's version with the group's
+// saved values, and the live ones when the server runs on this PC
+func (a *App) GetGameRules(serverID string, token string) []GameRuleState {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return []GameRuleState{}
+	}
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return []GameRuleState{}
+	}
+
+	rules := gameRulesFor(server.Version)
+	live := a.liveGameRules(serverID, rules)
+	states := []GameRuleState{}
+	for _, rule := range rules {
+		state := GameRuleState{GameRule: rule, Value: rule.Default}
+		if saved, ok := server.WorldSettings[rule.Name]; ok {
+			state.Value, state.Saved = settingString(saved), true
+		}
+		if value, ok := live[rule.Name]; ok {
+			state.Value, state.Live = value, true
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// SetGameRule validates a gamerule (or "difficulty"), saves it for the group
+// and applies it right away if the server runs on this PC. Rules outside the
+// catalog (mods, newer versions) are accepted if the running server has them.
+func (a *App) SetGameRule(serverID string, token string, name string, value string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	// Permission check: Only owner or admins can modify world settings
+	if !a.isAdmin(serverID, username) {
+		return "Error: Only admins can modify world settings"
+	}
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return "Error: Server not found"
+	}
+
+	var stored interface{}
+	var command string
+	switch rule, known := lookupGameRule(server.Version, name); {
+	case name == difficultySetting:
+		value = strings.ToLower(strings.TrimSpace(value))
+		if !containsString(difficulties, value) {
+			return "Error: difficulty must be one of " + strings.Join(difficulties, ", ")
+		}
+		stored, command = value, "difficulty "+value
+	case known:
+		if value, err = rule.normalize(value); err != nil {
+			return "Error: " + err.Error()
+		}
+		stored, command = typedSetting(rule.Type, value), "gamerule "+name+" "+value
+	default:
+		if !gameRuleNameRe.MatchString(name) {
+			return "Error: Invalid gamerule name"
+		}
+		custom, ok := a.customGameRule(serverID, name)
+		if !ok {
+			return fmt.Sprintf("Error: %s is not a gamerule in %s", name, server.Version)
+		}
+		if value, err = custom.normalize(value); err != nil {
+			return "Error: " + err.Error()
+		}
+		stored, command = typedSetting(custom.Type, value), "gamerule "+name+" "+value
+	}
+
+	ctx, cancel := dbContext()
+	defer cancel()
+	// Update specific field in the map: world_settings.keepInventory
+	if err := a.store.SetWorldSetting(ctx, serverID, name, stored); err != nil {
+		return "Error saving setting"
+	}
+
+	if !a.procs.IsRunning(serverID) {
+		return "Success: Saved. It applies when the server next starts."
+	}
+	if _, err := a.consoleCommand(serverID, command); err != nil {
+		return "Error: Saved, but could not apply it now: " + err.Error()
+	}
+	if name != difficultySetting {
+		setLiveGameRule(serverID, name, value)
+	}
+	a.Log(fmt.Sprintf("🌍 %s set to %s", name, value))
+	return "Success"
+}
+
+// typedSetting stores bools and numbers as such so the UI gets them back typed
+func typedSetting(kind string, value string) interface{} {
+	switch kind {
+	case "bool":
+		return value == "true"
+	case "int":
+		n, _ := strconv.Atoi(value)
+		return n
+	}
+	return value
+}
+
+// applyWorldSettings re-applies the group's saved gamerules and difficulty
+// once the server is ready, so the world plays the same whoever hosts it
+func (a *App) applyWorldSettings(serverID string) {
+	server, err := a.getServer(serverID)
+	if err != nil || len(server.WorldSettings) == 0 {
+		return
+	}
+
+	applied := 0
+	for name, saved := range server.WorldSettings {
+		value := settingString(saved)
+		var command string
+		if name == difficultySetting {
+			if !containsString(difficulties, value) {
+				continue
+			}
+			command = "difficulty " + value
+		} else {
+			rule, ok := lookupGameRule(server.Version, name)
+			if !ok {
+				if rule, ok = a.customGameRule(serverID, name); !ok {
+					continue // Not a rule on this server (old entry, or a mod that isn't installed)
+				}
+			}
+			if value, err = rule.normalize(value); err != nil {
+				a.Log(fmt.Sprintf("⚠️ Skipping saved %s: %v", name, err))
+				continue
+			}
+			command = "gamerule " + name + " " + value
+		}
+		if _, err := a.consoleCommand(serverID, command); err != nil {
+			a.Log("⚠️ Could not apply world settings: " + err.Error())
+			return
+		}
+		applied++
+	}
+	forgetLiveGameRules(serverID)
+	if applied > 0 {
+		a.Log(fmt.Sprintf("🌍 Applied %d saved world settings", applied))
+	}
+}
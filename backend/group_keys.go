@@ -0,0 +1,494 @@
+package backend
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// Identity is a user's unlocked key pair, held in memory only
+type Identity struct {
+	Username string
+	Public   *[32]byte
+	Private  *[32]byte
+}
+
+// Keyring holds unlocked identities and decrypted rclone remotes for this PC
+type Keyring struct {
+	mu         sync.Mutex
+	identities map[string]*Identity // by username
+	remotes    map[string][]string  // serverID -> rclone env vars
+}
+
+// NewKeyring creates an empty keyring
+func NewKeyring() *Keyring {
+	return &Keyring{
+		identities: map[string]*Identity{},
+		remotes:    map[string][]string{},
+	}
+}
+
+// forget drops a user's keys and every decrypted remote from memory
+func (k *Keyring) forget(username string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.identities, username)
+	k.remotes = map[string][]string{}
+}
+
+func (k *Keyring) all() []*Identity {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	list := make([]*Identity, 0, len(k.identities))
+	for _, id := range k.identities {
+		list = append(list, id)
+	}
+	return list
+}
+
+// newUserKeys creates a key pair and encrypts the private half with the password
+func newUserKeys(username string, password string) (publicKey string, encryptedPrivateKey string, salt string, id *Identity, err error) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return
+	}
+	salt = randomHex(16)
+	wrapKey, err := deriveKey(password, salt)
+	if err != nil {
+		return
+	}
+	encryptedPrivateKey, err = sealSecret(wrapKey, priv[:])
+	if err != nil {
+		return
+	}
+	publicKey = base64.StdEncoding.EncodeToString(pub[:])
+	id = &Identity{Username: username, Public: pub, Private: priv}
+	return
+}
+
+// unlockIdentity decrypts (or creates, for older accounts) the user's key pair
+// with their password and keeps it in the keyring.
+func (a *App) unlockIdentity(user User, password string) error {
+	var id *Identity
+
+	if user.PublicKey == "" {
+		// First login since keys were introduced: create a key pair
+		pub, encPriv, salt, newID, err := newUserKeys(user.Username, password)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := dbContext()
+		defer cancel()
+		if err := a.store.SetUserKeys(ctx, user.Username, pub, encPriv, salt); err != nil {
+			return err
+		}
+		id = newID
+	} else {
+		wrapKey, err := deriveKey(password, user.KeySalt)
+		if err != nil {
+			return err
+		}
+		raw, err := openSecret(wrapKey, user.EncryptedPrivateKey)
+		if err != nil || len(raw) != 32 {
+			return fmt.Errorf("could not unlock your keys")
+		}
+		pub, err := decodeKey(user.PublicKey)
+		if err != nil {
+			return err
+		}
+		var priv [32]byte
+		copy(priv[:], raw)
+		id = &Identity{Username: user.Username, Public: pub, Private: &priv}
+	}
+
+	a.keys.mu.Lock()
+	a.keys.identities[user.Username] = id
+	a.keys.mu.Unlock()
+	return nil
+}
+
+// UnlockKeys re-unlocks the user's keys for an existing session
+// (e.g. a remembered token after the app restarted).
+func (a *App) UnlockKeys(token string, password string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	ctx, cancel := dbContext()
+	defer cancel()
+
+	user, err := a.store.GetUser(ctx, username)
+	if err != nil {
+		return "Error: User not found"
+	}
+	if err := a.unlockIdentity(user, password); err != nil {
+		return "Error: " + err.Error()
+	}
+	a.kickUploads()
+	return "Success"
+}
+
+// inviteLookupSalt salts the hash JoinServer finds a group by. It differs
+// from inviteKey's salt (the server ID), so the stored hash can't open the
+// invite share.
+const inviteLookupSalt = "mc-roam invite lookup"
+
+// generateInviteCode returns a random code like "K7QF-2MXA-..." (120 bits)
+func generateInviteCode() string {
+	raw := make([]byte, 15)
+	rand.Read(raw)
+	code := base32.StdEncoding.EncodeToString(raw)
+	groups := make([]string, 0, len(code)/4)
+	for i := 0; i < len(code); i += 4 {
+		groups = append(groups, code[i:i+4])
+	}
+	return strings.Join(groups, "-")
+}
+
+// normalizeInviteCode ignores case, dashes and spaces in a typed code
+func normalizeInviteCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, code)
+}
+
+// inviteHash is the lookup hash of an invite code
+func inviteHash(inviteCode string) (string, error) {
+	key, err := deriveKey(normalizeInviteCode(inviteCode), inviteLookupSalt)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// inviteKey derives the key protecting the invite share of a group key
+func inviteKey(serverID string, inviteCode string) ([]byte, error) {
+	return deriveKey(normalizeInviteCode(inviteCode), serverID)
+}
+
+// sealInvite prepares what the DB keeps of an invite code
+func sealInvite(serverID string, inviteCode string, groupKey []byte) (GroupInvite, error) {
+	var invite GroupInvite
+	var err error
+	if invite.Hash, err = inviteHash(inviteCode); err != nil {
+		return GroupInvite{}, err
+	}
+	if invite.EncryptedCode, err = sealSecret(groupKey, []byte(inviteCode)); err != nil {
+		return GroupInvite{}, err
+	}
+	iKey, err := inviteKey(serverID, inviteCode)
+	if err != nil {
+		return GroupInvite{}, err
+	}
+	if invite.KeyShare, err = sealSecret(iKey, groupKey); err != nil {
+		return GroupInvite{}, err
+	}
+	return invite, nil
+}
+
+// inviteCode decrypts a group's invite code for a member, or returns ""
+func (a *App) inviteCode(server ServerGroup) string {
+	if server.EncryptedInviteCode == "" {
+		return ""
+	}
+	key, err := a.groupKey(server)
+	if err != nil {
+		return ""
+	}
+	code, err := openSecret(key, server.EncryptedInviteCode)
+	if err != nil {
+		return ""
+	}
+	return string(code)
+}
+
+// sealCredentials encrypts an rclone config under a fresh group key and
+// shares the key with every member that has a public key.
+func (a *App) sealCredentials(serverID string, members []string, config string, inviteCode string) (encConfig string, shares map[string]string, invite GroupInvite, groupKey []byte, err error) {
+	groupKey = newGroupKey()
+
+	encConfig, err = sealSecret(groupKey, []byte(config))
+	if err != nil {
+		return
+	}
+
+	shares = map[string]string{}
+	ctx, cancel := dbContext()
+	defer cancel()
+	for _, member := range members {
+		user, err := a.store.GetUser(ctx, member)
+		if err != nil || user.PublicKey == "" {
+			continue // They get a share later, see grantPendingShares
+		}
+		if share, err := wrapForUser(groupKey, user.PublicKey); err == nil {
+			shares[member] = share
+		}
+	}
+
+	invite, err = sealInvite(serverID, inviteCode, groupKey)
+	return
+}
+
+// groupKey unwraps a server's group key with any identity unlocked on this PC
+func (a *App) groupKey(server ServerGroup) ([]byte, error) {
+	for _, id := range a.keys.all() {
+		share, ok := server.KeyShares[id.Username]
+		if !ok {
+			continue
+		}
+		if key, err := unwrapForUser(share, id); err == nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("you don't have access to this server's cloud credentials (log in again, or ask the owner)")
+}
+
+// grantPendingShares gives the group key to members who don't have a share yet
+// (joined before they had keys, or the server was migrated without them)
+func (a *App) grantPendingShares(server ServerGroup, groupKey []byte) {
+	ctx, cancel := dbContext()
+	defer cancel()
+	for _, member := range server.Members {
+		if _, ok := server.KeyShares[member]; ok {
+			continue
+		}
+		user, err := a.store.GetUser(ctx, member)
+		if err != nil || user.PublicKey == "" {
+			continue
+		}
+		if share, err := wrapForUser(groupKey, user.PublicKey); err == nil {
+			a.store.SetKeyShare(ctx, server.ID, member, share)
+		}
+	}
+}
+
+// remoteEnv returns the environment that defines "mc-remote" for a server.
+// Credentials are decrypted once per session and never written to disk.
+func (a *App) remoteEnv(serverID string) ([]string, error) {
+	a.keys.mu.Lock()
+	env, ok := a.keys.remotes[serverID]
+	a.keys.mu.Unlock()
+	if ok {
+		return env, nil
+	}
+
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return nil, fmt.Errorf("server not found")
+	}
+
+	var config string
+	switch {
+	case server.EncryptedRcloneConfig != "":
+		key, err := a.groupKey(server)
+		if err != nil {
+			return nil, err
+		}
+		plain, err := openSecret(key, server.EncryptedRcloneConfig)
+		if err != nil {
+			return nil, fmt.Errorf("could not decrypt cloud credentials")
+		}
+		config = string(plain)
+		a.grantPendingShares(server, key)
+		if server.InviteHash == "" {
+			a.replaceLegacyInvite(server, key)
+		}
+
+	case server.RcloneConfig != "":
+		// Legacy plaintext config: encrypt it now so it stops sitting in the DB
+		config = server.RcloneConfig
+		if err := a.migrateCredentials(server); err != nil {
+			a.Log("⚠️ Could not encrypt legacy cloud credentials: " + err.Error())
+		}
+
+	default:
+		return nil, fmt.Errorf("this server has no Cloud Config set up")
+	}
+
+	env, err = rcloneEnv(config)
+	if err != nil {
+		return nil, err
+	}
+
+	a.keys.mu.Lock()
+	a.keys.remotes[serverID] = env
+	a.keys.mu.Unlock()
+	return env, nil
+}
+
+// forgetRemote drops decrypted credentials for a server from memory
+func (a *App) forgetRemote(serverID string) {
+	a.keys.mu.Lock()
+	delete(a.keys.remotes, serverID)
+	a.keys.mu.Unlock()
+}
+
+// replaceLegacyInvite swaps a plaintext invite code from an older version
+// for a new random one that is only stored hashed and sealed
+func (a *App) replaceLegacyInvite(server ServerGroup, groupKey []byte) {
+	invite, err := sealInvite(server.ID, generateInviteCode(), groupKey)
+	if err == nil {
+		ctx, cancel := dbContext()
+		defer cancel()
+		err = a.store.SetInvite(ctx, server.ID, invite)
+	}
+	if err != nil {
+		a.Log("⚠️ Could not replace the old invite code: " + err.Error())
+		return
+	}
+	a.Log("🔐 " + server.Name + " has a new invite code; the old one no longer works.")
+}
+
+// migrateCredentials encrypts a legacy plaintext config in place. The old
+// invite code was stored in plaintext too, so it is replaced.
+func (a *App) migrateCredentials(server ServerGroup) error {
+	enc, shares, invite, _, err := a.sealCredentials(server.ID, server.Members, server.RcloneConfig, generateInviteCode())
+	if err != nil {
+		return err
+	}
+	if len(shares) == 0 {
+		return fmt.Errorf("no member has unlocked keys yet")
+	}
+	ctx, cancel := dbContext()
+	defer cancel()
+	if err := a.store.SetServerCredentials(ctx, server.ID, enc, shares, invite); err != nil {
+		return err
+	}
+	a.Log("🔐 Cloud credentials are now encrypted for group members only.")
+	return nil
+}
+
+// rotateCredentials re-encrypts a config under a new group key and a new
+// invite code, so removed members and old invite codes lose access.
+func (a *App) rotateCredentials(serverID string, members []string, config string) (string, error) {
+	inviteCode := generateInviteCode()
+	enc, shares, invite, _, err := a.sealCredentials(serverID, members, config, inviteCode)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := dbContext()
+	defer cancel()
+	if err := a.store.SetServerCredentials(ctx, serverID, enc, shares, invite); err != nil {
+		return "", err
+	}
+	a.forgetRemote(serverID)
+	return inviteCode, nil
+}
+
+// currentConfig decrypts a server's rclone config for rotation
+func (a *App) currentConfig(server ServerGroup) (string, error) {
+	if server.EncryptedRcloneConfig == "" {
+		return server.RcloneConfig, nil
+	}
+	key, err := a.groupKey(server)
+	if err != nil {
+		return "", err
+	}
+	plain, err := openSecret(key, server.EncryptedRcloneConfig)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt cloud credentials")
+	}
+	return string(plain), nil
+}
+
+// RemoveMember kicks a member out of a group (Owner only) and rotates the
+// group key and invite code so they can't decrypt future credentials.
+func (a *App) RemoveMember(serverID string, targetUsername string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return "Error: Server not found"
+	}
+	if server.OwnerID != username {
+		return "Error: Only the server owner can remove members"
+	}
+	if targetUsername == server.OwnerID {
+		return "Error: The owner can't be removed"
+	}
+
+	config, err := a.currentConfig(server)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx, cancel := dbContext()
+	defer cancel()
+	if err := a.store.RemoveMember(ctx, serverID, targetUsername); err != nil {
+		return "Error: Failed to update database"
+	}
+
+	remaining := removeString(server.Members, targetUsername)
+	if config != "" {
+		if _, err := a.rotateCredentials(serverID, remaining, config); err != nil {
+			return "Error: Member removed, but key rotation failed: " + err.Error()
+		}
+	}
+
+	a.Log(fmt.Sprintf("🚪 %s was removed. Group key and invite code rotated.", targetUsername))
+	a.Log("💡 They may have seen the old Drive token. Re-authorize Drive and use Update Cloud Config to fully revoke it.")
+	return "Success"
+}
+
+// UpdateCloudConfig replaces the group's rclone config (Owner only),
+// e.g. after re-authorizing Drive to revoke a leaked token.
+func (a *App) UpdateCloudConfig(serverID string, token string, configString string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return "Error: Server not found"
+	}
+	if server.OwnerID != username {
+		return "Error: Only the server owner can change cloud credentials"
+	}
+	if _, err := rcloneEnv(configString); err != nil {
+		return "Error: " + err.Error()
+	}
+
+	if _, err := a.rotateCredentials(serverID, server.Members, configString); err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Success: Cloud credentials updated"
+}
+
+// grantShareFromInvite unwraps the group key with an invite code and shares it with a new member
+func (a *App) grantShareFromInvite(server ServerGroup, inviteCode string, username string) error {
+	iKey, err := inviteKey(server.ID, inviteCode)
+	if err != nil {
+		return err
+	}
+	groupKey, err := openSecret(iKey, server.InviteKeyShare)
+	if err != nil {
+		return fmt.Errorf("invite code does not match the group key")
+	}
+
+	ctx, cancel := dbContext()
+	defer cancel()
+	user, err := a.store.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user.PublicKey == "" {
+		return fmt.Errorf("your account has no keys yet")
+	}
+	share, err := wrapForUser(groupKey, user.PublicKey)
+	if err != nil {
+		return err
+	}
+	return a.store.SetKeyShare(ctx, server.ID, username, share)
+}
@@ -0,0 +1,145 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestSealSecret(t *testing.T) {
+	key, other := newGroupKey(), newGroupKey()
+	sealed, err := sealSecret(key, []byte("rclone config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := sealSecret(key, []byte("rclone config")); again == sealed {
+		t.Error("sealing twice gave the same ciphertext (nonce reused)")
+	}
+	if got, err := openSecret(key, sealed); err != nil || string(got) != "rclone config" {
+		t.Fatalf("openSecret = %q, %v", got, err)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(sealed)
+	raw[len(raw)-1] ^= 1
+	tests := []struct {
+		name    string
+		key     []byte
+		encoded string
+	}{
+		{name: "wrong key", key: other, encoded: sealed},
+		{name: "tampered", key: key, encoded: base64.StdEncoding.EncodeToString(raw)},
+		{name: "too short", key: key, encoded: base64.StdEncoding.EncodeToString([]byte("short"))},
+		{name: "not base64", key: key, encoded: "!!!"},
+		{name: "bad key size", key: key[:5], encoded: sealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := openSecret(tt.key, tt.encoded); err == nil {
+				t.Errorf("openSecret = %q, want an error", got)
+			}
+		})
+	}
+}
+
+func TestSealInvite(t *testing.T) {
+	groupKey := newGroupKey()
+	code := generateInviteCode()
+	invite, err := sealInvite("srv", code, groupKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The code as typed: lower case, no dashes, stray spaces
+	typed := " " + strings.ToLower(strings.ReplaceAll(code, "-", "")) + " "
+	if hash, _ := inviteHash(typed); hash != invite.Hash {
+		t.Error("typed code doesn't hash to the stored lookup hash")
+	}
+	iKey, err := inviteKey("srv", typed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := openSecret(iKey, invite.KeyShare); err != nil || !bytes.Equal(got, groupKey) {
+		t.Errorf("invite share opened with the code = %x, %v", got, err)
+	}
+	if got, err := openSecret(groupKey, invite.EncryptedCode); err != nil || string(got) != code {
+		t.Errorf("code opened with the group key = %q, %v", got, err)
+	}
+
+	wrong := generateInviteCode()
+	if hash, _ := inviteHash(wrong); hash == invite.Hash {
+		t.Error("another code has the same lookup hash")
+	}
+	for _, tt := range []struct{ name, serverID, code string }{
+		{"wrong code", "srv", wrong},
+		{"other server", "other", code},
+	} {
+		iKey, err := inviteKey(tt.serverID, tt.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := openSecret(iKey, invite.KeyShare); err == nil {
+			t.Errorf("%s opened the invite share", tt.name)
+		}
+	}
+}
+
+func TestJoinWithInvite(t *testing.T) {
+	a, _, _ := newTestApp(t)
+	ctx := context.Background()
+
+	groupKey := newGroupKey()
+	code := generateInviteCode()
+	invite, err := sealInvite("srv", code, groupKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.store.SetInvite(ctx, "srv", invite); err != nil {
+		t.Fatal(err)
+	}
+
+	pub, encPriv, salt, _, err := newUserKeys("bob", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.store.CreateUser(ctx, User{Username: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.store.SetUserKeys(ctx, "bob", pub, encPriv, salt); err != nil {
+		t.Fatal(err)
+	}
+	user, err := a.store.GetUser(ctx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.unlockIdentity(user, "wrong passphrase"); err == nil || !strings.Contains(err.Error(), "could not unlock") {
+		t.Errorf("unlock with the wrong passphrase = %v", err)
+	}
+	if err := a.unlockIdentity(user, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := a.store.GetServer(ctx, "srv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.grantShareFromInvite(server, generateInviteCode(), "bob"); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("join with the wrong code = %v", err)
+	}
+	if err := a.grantShareFromInvite(server, strings.ToLower(code), "bob"); err != nil {
+		t.Fatalf("join with the code: %v", err)
+	}
+
+	// bob can now open the group key and see the code
+	server, err = a.store.GetServer(ctx, "srv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := a.groupKey(server); err != nil || !bytes.Equal(got, groupKey) {
+		t.Errorf("groupKey = %x, %v", got, err)
+	}
+	if got := a.inviteCode(server); got != code {
+		t.Errorf("inviteCode = %q, want %q", got, code)
+	}
+}
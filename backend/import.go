@@ -0,0 +1,263 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mc-roam/backend/properties"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// importedConfigs are the server files copied along with the world
+var importedConfigs = []string{"server.properties", "ops.json", "whitelist.json", "banned-players.json", "banned-ips.json"}
+
+// importSource is a world or server folder laid out for a new group
+type importSource struct {
+	Root      string // Server folder (or the world itself for a bare world)
+	LevelName string // World folder name in the new group
+	World     string // Folder with level.dat
+	Nether    string // Bukkit layout: <level>_nether, empty otherwise
+	End       string // Bukkit layout: <level>_the_end, empty otherwise
+	Version   string // From level.dat, empty for pre-1.9 worlds
+	Type      string // Guessed server type, empty if it needs choosing
+}
+
+// bukkit reports whether the dimensions are in their own folders
+func (s importSource) bukkit() bool {
+	return s.Nether != "" || s.End != ""
+}
+
+// inspectImport finds the world in a server folder (or a bare world folder)
+// and guesses the server it ran on
+func inspectImport(root string) (importSource, error) {
+	// A zipped folder: look inside its single top folder
+	if entries, err := os.ReadDir(root); err == nil && len(entries) == 1 && entries[0].IsDir() && !fileExists(filepath.Join(root, "level.dat")) {
+		root = filepath.Join(root, entries[0].Name())
+	}
+
+	src := importSource{Root: root, LevelName: "world"}
+	if fileExists(filepath.Join(root, "level.dat")) {
+		src.World = root // Just a world, e.g. from a singleplayer saves folder
+	} else {
+		if props, err := properties.Load(filepath.Join(root, "server.properties")); err == nil {
+			if name, ok := props.Get("level-name"); ok && name != "" {
+				if !plainName(name) {
+					return importSource{}, fmt.Errorf("invalid level-name %q in server.properties", name)
+				}
+				src.LevelName = name
+			}
+		}
+		if fileExists(filepath.Join(root, src.LevelName, "level.dat")) {
+			src.World = filepath.Join(root, src.LevelName)
+		} else {
+			// No server.properties (or a stale one): take the folder with level.dat
+			entries, _ := os.ReadDir(root)
+			for _, e := range entries {
+				name := e.Name()
+				if e.IsDir() && fileExists(filepath.Join(root, name, "level.dat")) && !strings.HasSuffix(name, "_nether") && !strings.HasSuffix(name, "_the_end") {
+					src.LevelName, src.World = name, filepath.Join(root, name)
+					break
+				}
+			}
+		}
+		if src.World == "" {
+			return importSource{}, fmt.Errorf("no world found (no folder with a level.dat)")
+		}
+		if dir := filepath.Join(root, src.LevelName+"_nether"); dirExists(filepath.Join(dir, "DIM-1")) {
+			src.Nether = dir
+		}
+		if dir := filepath.Join(root, src.LevelName+"_the_end"); dirExists(filepath.Join(dir, "DIM1")) {
+			src.End = dir
+		}
+	}
+
+	if version, err := readLevelVersion(filepath.Join(src.World, "level.dat")); err == nil {
+		src.Version = version
+	}
+	switch {
+	case dirExists(filepath.Join(root, "mods")):
+		// Modded: the loader can't be told from the world
+	case src.bukkit() || dirExists(filepath.Join(root, "plugins")):
+		src.Type = "Paper"
+	default:
+		src.Type = "Vanilla"
+	}
+	return src, nil
+}
+
+// copyInto copies the world and the server's configs into a new instance
+// folder. Vanilla-style servers keep every dimension inside the world
+// folder, so a Bukkit layout is merged for them; Paper and Purpur move a
+// vanilla layout out by themselves on first start.
+func (s importSource) copyInto(dir string, serverType string) error {
+	world := filepath.Join(dir, s.LevelName)
+	if err := copyTree(s.World, world); err != nil {
+		return err
+	}
+	bukkitType := strings.EqualFold(serverType, "Paper") || strings.EqualFold(serverType, "Purpur")
+	for _, dim := range []struct{ dir, name string }{{s.Nether, "DIM-1"}, {s.End, "DIM1"}} {
+		if dim.dir == "" {
+			continue
+		}
+		var err error
+		if bukkitType {
+			err = copyTree(dim.dir, filepath.Join(dir, filepath.Base(dim.dir)))
+		} else {
+			err = copyTree(filepath.Join(dim.dir, dim.name), filepath.Join(world, dim.name))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.World == s.Root {
+		return nil // A bare world has no server files
+	}
+	for _, name := range importedConfigs {
+		if err := copyFile(filepath.Join(s.Root, name), filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTree copies a folder, skipping the running server's session.lock
+func copyTree(src string, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0755)
+		case !info.Mode().IsRegular(), info.Name() == "session.lock":
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeFileFrom(dst, in, 0644)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// ChooseImportSource opens a picker for ImportServer: a folder, or a
+// world archive if folder is false
+func (a *App) ChooseImportSource(folder bool) string {
+	if a.ctx == nil {
+		return ""
+	}
+	var source string
+	var err error
+	if folder {
+		source, err = runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{Title: "Choose a server or world folder"})
+	} else {
+		source, err = runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title:   "Choose a world archive",
+			Filters: []runtime.FileFilter{{DisplayName: "Archives (*.zip, *.tar.gz)", Pattern: "*.zip;*.tar.gz;*.tgz"}},
+		})
+	}
+	if err != nil {
+		return ""
+	}
+	return source
+}
+
+// ImportServer creates a group from an existing server folder, world
+// folder or world archive and uploads it. serverType and version may be
+// empty to detect them (the version from level.dat; modded servers need
+// their type given). Returns the new server ID, like CreateServer.
+func (a *App) ImportServer(serverName string, sourcePath string, serverType string, version string, token string, configString string) string {
+	if _, err := a.authenticate(token); err != nil {
+		return "Error: " + err.Error()
+	}
+
+	// Archives are unpacked next to the instances so copying stays on one disk
+	root := sourcePath
+	if info, err := os.Stat(sourcePath); err != nil {
+		return "Error: " + err.Error()
+	} else if !info.IsDir() {
+		instances := a.getInstancePath("")
+		os.MkdirAll(instances, 0755)
+		staging, err := os.MkdirTemp(instances, ".import-")
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		defer os.RemoveAll(staging)
+		a.Log("📦 Unpacking " + filepath.Base(sourcePath) + "...")
+		if err := extractArchive(sourcePath, staging); err != nil {
+			return "Error: " + err.Error()
+		}
+		root = staging
+	}
+
+	src, err := inspectImport(root)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if serverType == "" {
+		serverType = src.Type
+	}
+	if serverType == "" {
+		return "Error: This server has mods; choose its server type (Fabric, Forge or NeoForge)"
+	}
+	switch {
+	case version == "" && src.Version == "":
+		return "Error: Could not tell the world's Minecraft version; choose one"
+	case version == "":
+		version = src.Version
+	case src.Version != "" && compareVersions(version, src.Version) < 0:
+		return fmt.Sprintf("Error: The world was last played on %s; an older server (%s) can't load it", src.Version, version)
+	}
+
+	// Resolve before creating the group, so a bad version leaves nothing behind
+	versionDoc, err := a.resolveVersion(serverType, version)
+	if err != nil {
+		return fmt.Sprintf("Error: Version not found for %s %s: %v", serverType, version, err)
+	}
+	if serverName == "" {
+		serverName = src.LevelName
+	}
+	layout := "vanilla layout"
+	if src.bukkit() {
+		layout = "Bukkit layout"
+	}
+	a.Log(fmt.Sprintf("📥 Importing world %q (%s, last played on %s) as %s %s", src.LevelName, layout, src.Version, versionDoc.Type, versionDoc.Version))
+
+	serverID := a.CreateServer(serverName, versionDoc.Type, versionDoc.Version, token, configString)
+	if strings.HasPrefix(serverID, "Error") {
+		return serverID
+	}
+	prepare := func(dir string) error {
+		return src.copyInto(dir, versionDoc.Type)
+	}
+	if res := a.installServer(serverID, versionDoc, prepare); strings.HasPrefix(res, "Error") {
+		a.DeleteServer(serverID, token) // Don't leave a group without its world
+		return res
+	}
+	a.Log("✅ Imported " + src.LevelName)
+	return serverID
+}
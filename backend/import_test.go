@@ -0,0 +1,31 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspectImportLevelName(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "survival"), 0755)
+	os.WriteFile(filepath.Join(root, "survival", "level.dat"), nil, 0644)
+	os.WriteFile(filepath.Join(root, "server.properties"), []byte("level-name=survival\n"), 0644)
+
+	src, err := inspectImport(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.LevelName != "survival" || src.World != filepath.Join(root, "survival") {
+		t.Errorf("inspectImport = %+v", src)
+	}
+
+	// A level-name pointing outside the server folder must not be followed
+	for _, name := range []string{"../survival", "/tmp", `..\\survival`, ".."} {
+		os.WriteFile(filepath.Join(root, "server.properties"), []byte("level-name="+name+"\n"), 0644)
+		if _, err := inspectImport(root); err == nil || !strings.Contains(err.Error(), "invalid level-name") {
+			t.Errorf("inspectImport with level-name %q error = %v", name, err)
+		}
+	}
+}
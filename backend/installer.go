@@ -1,45 +1,53 @@
 package backend
 
 import (
-	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
-	"time"
-
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 // InstallServer downloads the server and pushes it to the cloud
-// InstallServer downloads files and uploads them to a SERVER-SPECIFIC cloud folder
-func (a *App) InstallServer(serverID string) string {
+// InstallServer downloads files and uploads them to a SERVER-SPECIFIC cloud folder (Admins only)
+func (a *App) InstallServer(serverID string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if !a.isAdmin(serverID, username) {
+		return "Error: Only admins can install the server"
+	}
+	if server, err := a.getServer(serverID); err == nil && server.Lock.IsRunning {
+		return "Error: Stop the server before reinstalling it."
+	}
+	return a.installLatest(serverID)
+}
 
+// installLatest installs the newest build of the server's type and version
+func (a *App) installLatest(serverID string) string {
 	// 1. Get Server Details from Database
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := dbContext()
 	defer cancel()
 
-	var server ServerGroup
-	err := collection.FindOne(ctx, bson.M{"_id": serverID}).Decode(&server)
+	server, err := a.store.GetServer(ctx, serverID)
 	if err != nil {
 		return fmt.Sprintf("Error: Server not found: %v", err)
 	}
 
-	// 2. Get Version Details (MATCHING TYPE AND VERSION)
-	vCollection := DB.Client.Database("mc_roam").Collection("versions")
-	var versionDoc ServerVersion
-
-	// Query: WHERE type = server.Type AND version = server.Version
-	filter := bson.M{
-		"type":    server.Type,
-		"version": server.Version,
+	// 2. Get Version Details (latest build of server.Type + server.Version)
+	versionDoc, err := a.resolveVersion(server.Type, server.Version)
+	if err != nil {
+		return fmt.Sprintf("Error: Version not found for %s %s: %v", server.Type, server.Version, err)
 	}
+	return a.installServer(serverID, versionDoc, nil)
+}
 
-	err = vCollection.FindOne(ctx, filter).Decode(&versionDoc)
+// installServer installs versionDoc into a clean instance folder, lets
+// prepare add files (modpack mods and configs), and uploads the result
+func (a *App) installServer(serverID string, versionDoc ServerVersion, prepare func(dir string) error) string {
+	// 2.5. Forge/NeoForge installers run on Java too
+	javaPath, err := a.ensureJava(versionDoc.Version)
 	if err != nil {
-		return fmt.Sprintf("Error: Version not found for %s %s: %v", server.Type, server.Version, err)
+		return "Error: " + err.Error()
 	}
 
 	// 3. Calculate Paths (DYNAMICALLY)
@@ -54,6 +62,10 @@ func (a *App) InstallServer(serverID string) string {
 		a.Log("🔒 Backing up Playit configuration...")
 	}
 
+	// Keep the sync base too, so the upload below is known to replace the
+	// cloud state we just downloaded and not some newer one
+	syncBase, hasSyncBase := readSyncBase(localInstance)
+
 	// 4. WIPE OLD DATA (Local)
 	a.Log(fmt.Sprintf("🧹 Cleaning up instance files in %s...", localInstance))
 	os.RemoveAll(localInstance)
@@ -63,19 +75,27 @@ func (a *App) InstallServer(serverID string) string {
 		return fmt.Sprintf("Error: Could not create folder: %v", err)
 	}
 
-	// 6. Download Server Jar using URL from database
-	a.Log(fmt.Sprintf("⬇️ Downloading %s %s Server Jar...", server.Type, server.Version))
-	err = downloadFile(versionDoc.Url, filepath.Join(localInstance, "server.jar"))
-	if err != nil {
-		return fmt.Sprintf("Error: Download failed: %v", err)
+	// 6. Install the server with its type's loader (jar download or installer run)
+	if err := a.installLoader(localInstance, versionDoc, javaPath); err != nil {
+		return fmt.Sprintf("Error: Install failed: %v", err)
 	}
 
-	// 7. Write Config Files
+	// 6.5. Add the modpack's mods and configs
+	if prepare != nil {
+		if err := prepare(localInstance); err != nil {
+			return fmt.Sprintf("Error: Install failed: %v", err)
+		}
+	}
+
+	// 7. Write Config Files (a modpack may bring its own server.properties)
 	eulaContent := "eula=true\n"
 	os.WriteFile(filepath.Join(localInstance, "eula.txt"), []byte(eulaContent), 0644)
 
-	propsContent := "online-mode=false\nspawn-protection=0\n"
-	os.WriteFile(filepath.Join(localInstance, "server.properties"), []byte(propsContent), 0644)
+	propsPath := filepath.Join(localInstance, "server.properties")
+	if _, err := os.Stat(propsPath); os.IsNotExist(err) {
+		propsContent := "online-mode=false\nspawn-protection=0\n"
+		os.WriteFile(propsPath, []byte(propsContent), 0644)
+	}
 
 	// 7.5. RESTORE playit.toml if we backed it up
 	if len(playitBackup) > 0 {
@@ -85,36 +105,18 @@ func (a *App) InstallServer(serverID string) string {
 		}
 	}
 
+	if hasSyncBase {
+		writeSyncBase(localInstance, syncBase)
+	}
+
 	// 8. UPLOAD TO SPECIFIC CLOUD FOLDER
 	a.Log(fmt.Sprintf("🚀 Uploading to Cloud Folder: %s...", remoteFolder))
 
-	// CRITICAL FIX: Use 'remoteFolder' variable, NOT "minecraft-server"
-	err = a.RunSync(SyncUp, remoteFolder, localInstance)
+	// Goes to the group's own folder and starts its version history
+	err = a.syncUp(serverID, localInstance, "installer", false)
 	if err != nil {
 		return fmt.Sprintf("Error: Failed to upload to cloud: %v", err)
 	}
 
 	return "Success: Server Installed & Uploaded!"
 }
-
-// Helper function to download a file
-func downloadFile(url string, destPath string) error {
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("server returned %d", resp.StatusCode)
-	}
-
-	out, err := os.Create(destPath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
-	return err
-}
@@ -0,0 +1,358 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JavaRuntime is one Java installation found on this PC
+type JavaRuntime struct {
+	Path    string `json:"path"`    // The java executable
+	Home    string `json:"home"`    // JAVA_HOME of the install
+	Version string `json:"version"` // e.g. "17.0.10" or "1.8.0_392"
+	Major   int    `json:"major"`   // e.g. 17 or 8
+	Managed bool   `json:"managed"` // Downloaded by us into bin/java
+}
+
+// adoptiumAPI lists the latest Temurin build of a Java feature version
+const adoptiumAPI = "https://api.adoptium.net/v3/assets/latest/%d/hotspot?image_type=jre&vendor=eclipse&os=%s&architecture=%s"
+
+var (
+	javaVersionRe   = regexp.MustCompile(`version "([^"]+)"`)
+	javaReleaseRe   = regexp.MustCompile(`^JAVA_VERSION="([^"]+)"`)
+	mcVersionRe     = regexp.MustCompile(`^1\.(\d+)(?:\.(\d+))?`)
+	javaInstallLock sync.Mutex
+)
+
+// javaExe is the executable name on this OS
+func javaExe() string {
+	if runtime.GOOS == "windows" {
+		return "java.exe"
+	}
+	return "java"
+}
+
+// managedJavaDir is where we unpack Temurin runtimes
+func managedJavaDir() string {
+	return filepath.Join(getAppDir(), "bin", "java")
+}
+
+// requiredJava maps a Minecraft version to the Java major version it needs
+//
+//	1.0 - 1.16.5    Java 8
+//	1.17.x          Java 16
+//	1.18 - 1.20.4   Java 17
+//	1.20.5+         Java 21 (also snapshots and anything we can't parse)
+func requiredJava(mcVersion string) int {
+	m := mcVersionRe.FindStringSubmatch(mcVersion)
+	if m == nil {
+		return 21
+	}
+	minor, _ := strconv.Atoi(m[1])
+	patch, _ := strconv.Atoi(m[2])
+	switch {
+	case minor <= 16:
+		return 8
+	case minor == 17:
+		return 16
+	case minor < 20 || (minor == 20 && patch < 5):
+		return 17
+	}
+	return 21
+}
+
+// javaCompatible reports whether a runtime can run a server that needs required.
+// Old servers (Java 8) break on newer Java; newer ones run fine on later releases.
+func javaCompatible(required int, have int) bool {
+	if required == 8 {
+		return have == 8
+	}
+	return have >= required
+}
+
+// parseJavaMajor turns "1.8.0_392" into 8 and "17.0.10" into 17
+func parseJavaMajor(version string) int {
+	version = strings.TrimPrefix(version, "1.")
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end == 0 {
+		return 0
+	}
+	if end > 0 {
+		version = version[:end]
+	}
+	major, _ := strconv.Atoi(version)
+	return major
+}
+
+// javaCandidates lists java executables that might exist on this PC
+func javaCandidates() []string {
+	var homes []string
+	add := func(pattern string) {
+		matches, _ := filepath.Glob(pattern)
+		homes = append(homes, matches...)
+	}
+
+	add(filepath.Join(managedJavaDir(), "*"))
+	add(filepath.Join(managedJavaDir(), "*", "Contents", "Home"))
+	if home := os.Getenv("JAVA_HOME"); home != "" {
+		homes = append(homes, home)
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+			root := os.Getenv(env)
+			if root == "" {
+				continue
+			}
+			for _, vendor := range []string{"Java", "Eclipse Adoptium", "Eclipse Foundation", "AdoptOpenJDK", "Microsoft", "Zulu", "Amazon Corretto", "BellSoft"} {
+				add(filepath.Join(root, vendor, "*"))
+			}
+		}
+	case "darwin":
+		add("/Library/Java/JavaVirtualMachines/*/Contents/Home")
+	default:
+		add("/usr/lib/jvm/*")
+		add("/usr/java/*")
+		add("/opt/java/*")
+	}
+	if userHome, err := os.UserHomeDir(); err == nil {
+		add(filepath.Join(userHome, ".sdkman", "candidates", "java", "*"))
+		add(filepath.Join(userHome, ".jdks", "*"))
+	}
+
+	var paths []string
+	for _, home := range homes {
+		paths = append(paths, filepath.Join(home, "bin", javaExe()))
+	}
+	// Everything on PATH, not just the first match
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		paths = append(paths, filepath.Join(dir, javaExe()))
+	}
+	return paths
+}
+
+// inspectJava reads a runtime's version, preferring the "release" file
+// (no process start) over running "java -version"
+func inspectJava(javaPath string) (JavaRuntime, error) {
+	if _, err := os.Stat(javaPath); err != nil {
+		return JavaRuntime{}, err
+	}
+	home := filepath.Dir(filepath.Dir(javaPath))
+	rt := JavaRuntime{
+		Path:    javaPath,
+		Home:    home,
+		Managed: strings.HasPrefix(javaPath, managedJavaDir()+string(filepath.Separator)),
+	}
+
+	if file, err := os.Open(filepath.Join(home, "release")); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if m := javaReleaseRe.FindStringSubmatch(scanner.Text()); m != nil {
+				rt.Version = m[1]
+				break
+			}
+		}
+		file.Close()
+	}
+
+	if rt.Version == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, javaPath, "-version")
+		prepareCommand(cmd)
+		out, err := cmd.CombinedOutput() // The version goes to stderr
+		if err != nil {
+			return JavaRuntime{}, err
+		}
+		m := javaVersionRe.FindSubmatch(out)
+		if m == nil {
+			return JavaRuntime{}, fmt.Errorf("can't read java version")
+		}
+		rt.Version = string(m[1])
+	}
+
+	rt.Major = parseJavaMajor(rt.Version)
+	if rt.Major == 0 {
+		return JavaRuntime{}, fmt.Errorf("can't read java version %q", rt.Version)
+	}
+	return rt, nil
+}
+
+// findJavaRuntimes detects every usable Java install, newest first
+func findJavaRuntimes() []JavaRuntime {
+	seen := map[string]bool{}
+	var runtimes []JavaRuntime
+	for _, path := range javaCandidates() {
+		// Symlinks like /usr/bin/java -> /usr/lib/jvm/... would appear twice
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil || seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+
+		rt, err := inspectJava(resolved)
+		if err != nil {
+			continue
+		}
+		runtimes = append(runtimes, rt)
+	}
+	sort.SliceStable(runtimes, func(i, j int) bool { return runtimes[i].Major > runtimes[j].Major })
+	return runtimes
+}
+
+// pickJava chooses the runtime for a Minecraft version: the oldest
+// compatible major version, preferring ones we manage ourselves
+func pickJava(runtimes []JavaRuntime, required int) (JavaRuntime, bool) {
+	var best JavaRuntime
+	found := false
+	for _, rt := range runtimes {
+		if !javaCompatible(required, rt.Major) {
+			continue
+		}
+		if !found || rt.Major < best.Major || (rt.Major == best.Major && rt.Managed && !best.Managed) {
+			best, found = rt, true
+		}
+	}
+	return best, found
+}
+
+// ensureJava returns a java executable for a Minecraft version, downloading
+// Temurin if nothing installed fits
+func (a *App) ensureJava(mcVersion string) (string, error) {
+	required := requiredJava(mcVersion)
+	if rt, ok := pickJava(findJavaRuntimes(), required); ok {
+		a.Log(fmt.Sprintf("☕ Using Java %s for Minecraft %s", rt.Version, mcVersion))
+		return rt.Path, nil
+	}
+
+	a.Log(fmt.Sprintf("☕ Minecraft %s needs Java %d, which isn't installed. Downloading it...", mcVersion, required))
+	rt, err := a.installJava(required)
+	if err != nil {
+		return "", fmt.Errorf("Minecraft %s needs Java %d and it could not be installed (%v). Install Java %d and try again", mcVersion, required, err, required)
+	}
+	return rt.Path, nil
+}
+
+// installJava downloads the latest Temurin JRE for a major version into bin/java
+func (a *App) installJava(major int) (JavaRuntime, error) {
+	javaInstallLock.Lock()
+	defer javaInstallLock.Unlock()
+
+	// Temurin never shipped a Java 16 JRE; 17 runs everything that needs 16
+	if major == 16 {
+		major = 17
+	}
+
+	// Someone may have installed it while we waited for the lock
+	for _, rt := range findJavaRuntimes() {
+		if rt.Managed && rt.Major == major {
+			return rt, nil
+		}
+	}
+
+	osName := map[string]string{"windows": "windows", "darwin": "mac", "linux": "linux"}[runtime.GOOS]
+	arch := map[string]string{"amd64": "x64", "arm64": "aarch64", "386": "x32"}[runtime.GOARCH]
+	if osName == "" || arch == "" {
+		return JavaRuntime{}, fmt.Errorf("no Temurin build for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+
+	var assets []struct {
+		ReleaseName string `json:"release_name"`
+		Binary      struct {
+			Package struct {
+				Name     string `json:"name"`
+				Link     string `json:"link"`
+				Checksum string `json:"checksum"` // sha256
+			} `json:"package"`
+		} `json:"binary"`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err := getJSON(ctx, nil, fmt.Sprintf(adoptiumAPI, major, osName, arch), &assets)
+	cancel()
+	if err != nil {
+		return JavaRuntime{}, fmt.Errorf("could not look up Temurin %d: %v", major, err)
+	}
+	if len(assets) == 0 {
+		return JavaRuntime{}, fmt.Errorf("no Temurin %d JRE for %s/%s", major, osName, arch)
+	}
+	pkg := assets[0].Binary.Package
+
+	javaDir := managedJavaDir()
+	os.MkdirAll(javaDir, 0755)
+	archive := filepath.Join(javaDir, pkg.Name)
+	err = a.download(Download{URL: pkg.Link, Dest: archive, Checksum: checksumOf("sha256", pkg.Checksum), Name: "Java " + strconv.Itoa(major)})
+	if err != nil {
+		return JavaRuntime{}, err
+	}
+	defer os.Remove(archive)
+
+	// Unpack next to the others, then move into place so a half-extracted
+	// runtime is never picked up
+	a.Log(fmt.Sprintf("📦 Extracting Java %d...", major))
+	tmp, err := os.MkdirTemp(javaDir, ".extract-")
+	if err != nil {
+		return JavaRuntime{}, err
+	}
+	defer os.RemoveAll(tmp)
+	if err := extractArchive(archive, tmp); err != nil {
+		return JavaRuntime{}, fmt.Errorf("failed to extract Java: %v", err)
+	}
+
+	// Temurin archives hold a single top folder, e.g. "jdk-21.0.2+13-jre"
+	entries, _ := os.ReadDir(tmp)
+	if len(entries) != 1 || !entries[0].IsDir() {
+		return JavaRuntime{}, fmt.Errorf("unexpected layout in %s", pkg.Name)
+	}
+	target := filepath.Join(javaDir, entries[0].Name())
+	os.RemoveAll(target)
+	if err := os.Rename(filepath.Join(tmp, entries[0].Name()), target); err != nil {
+		return JavaRuntime{}, err
+	}
+
+	home := target
+	if runtime.GOOS == "darwin" {
+		home = filepath.Join(target, "Contents", "Home")
+	}
+	rt, err := inspectJava(filepath.Join(home, "bin", javaExe()))
+	if err != nil {
+		return JavaRuntime{}, fmt.Errorf("installed Java doesn't run: %v", err)
+	}
+	a.Log(fmt.Sprintf("✅ Java %s installed", rt.Version))
+	return rt, nil
+}
+
+// ListJavaRuntimes returns the Java installs found on this PC
+func (a *App) ListJavaRuntimes() []JavaRuntime {
+	runtimes := findJavaRuntimes()
+	if runtimes == nil {
+		return []JavaRuntime{}
+	}
+	return runtimes
+}
+
+// InstallJava downloads a Temurin JRE (e.g. 8, 17 or 21) into the bin folder
+func (a *App) InstallJava(token string, major int) string {
+	if _, err := a.authenticate(token); err != nil {
+		return "Error: " + err.Error()
+	}
+	if major != 8 && major != 11 && major != 16 && major != 17 && major != 21 {
+		return "Error: Unsupported Java version"
+	}
+	rt, err := a.installJava(major)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Success: " + rt.Version
+}
@@ -0,0 +1,333 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Default heap when a group never set a launch profile (what we always used)
+const (
+	defaultHeapMB = 2048
+	minHeapMB     = 512
+	// osReserveMB is left to the OS when the host hasn't set a memory cap
+	osReserveMB = 1024
+)
+
+// Launch presets
+const (
+	PresetNone  = ""
+	PresetAikar = "aikar"
+)
+
+// aikarFlags are Aikar's recommended G1 flags (https://docs.papermc.io/paper/aikars-flags)
+var aikarFlags = []string{
+	"-XX:+UseG1GC", "-XX:+ParallelRefProcEnabled", "-XX:MaxGCPauseMillis=200",
+	"-XX:+UnlockExperimentalVMOptions", "-XX:+DisableExplicitGC", "-XX:+AlwaysPreTouch",
+	"-XX:G1HeapWastePercent=5", "-XX:G1MixedGCCountTarget=4", "-XX:G1MixedGCLiveThresholdPercent=90",
+	"-XX:G1RSetUpdatingPauseTimePercent=5", "-XX:SurvivorRatio=32", "-XX:+PerfDisableSharedMem",
+	"-XX:MaxTenuringThreshold=1", "-Dusing.aikars.flags=https://mcflags.emc.gs", "-Daikars.new.flags=true",
+}
+
+// aikarSizing returns the flags that depend on heap size (bigger heaps above 12GB)
+func aikarSizing(maxMB int) []string {
+	if maxMB >= 12*1024 {
+		return []string{"-XX:G1NewSizePercent=40", "-XX:G1MaxNewSizePercent=50", "-XX:G1HeapRegionSize=16M",
+			"-XX:G1ReservePercent=15", "-XX:InitiatingHeapOccupancyPercent=20"}
+	}
+	return []string{"-XX:G1NewSizePercent=30", "-XX:G1MaxNewSizePercent=40", "-XX:G1HeapRegionSize=8M",
+		"-XX:G1ReservePercent=20", "-XX:InitiatingHeapOccupancyPercent=15"}
+}
+
+// allowedXXFlags are the -XX options a profile may set: garbage collector
+// choice and tuning, and memory region sizes. Anything else (OnError,
+// HeapDumpPath, Flags=...) could run commands or write files on the host.
+var allowedXXFlags = map[string]bool{
+	"UseG1GC": true, "UseZGC": true, "ZGenerational": true, "UseShenandoahGC": true,
+	"UseParallelGC": true, "UseSerialGC": true, "ShenandoahGCMode": true,
+	"ParallelRefProcEnabled": true, "MaxGCPauseMillis": true, "UnlockExperimentalVMOptions": true,
+	"DisableExplicitGC": true, "AlwaysPreTouch": true, "SurvivorRatio": true,
+	"PerfDisableSharedMem": true, "MaxTenuringThreshold": true, "InitiatingHeapOccupancyPercent": true,
+	"ParallelGCThreads": true, "ConcGCThreads": true, "UseStringDeduplication": true,
+	"UseLargePages": true, "UseTransparentHugePages": true, "UseNUMA": true,
+	"UseCompressedOops": true, "SoftMaxHeapSize": true, "MaxDirectMemorySize": true,
+	"MetaspaceSize": true, "MaxMetaspaceSize": true, "ReservedCodeCacheSize": true,
+	"NewRatio": true, "ZAllocationSpikeTolerance": true, "ZCollectionInterval": true,
+	"ZUncommitDelay": true, "ShenandoahGuaranteedGCInterval": true,
+}
+
+// allowedXXPrefixes cover the G1 tuning knobs, e.g. G1HeapRegionSize
+var allowedXXPrefixes = []string{"G1"}
+
+// xxValueRe is what a -XX:Name=value may be set to: numbers, sizes and modes, never paths
+var xxValueRe = regexp.MustCompile(`^[A-Za-z0-9.]+$`)
+
+// propertyKeyRe matches -D property names
+var propertyKeyRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// reservedPropertyPrefixes are JDK and logging properties that change how
+// the JVM loads code or config (e.g. log4j.configurationFile, java.library.path)
+var reservedPropertyPrefixes = []string{"java.", "javax.", "jdk.", "sun.", "com.sun.", "log4j"}
+
+// allowedServerArgs are the server options a profile may pass after "nogui".
+// Options taking paths or ports (--universe, --plugins, --port) are managed by us.
+var allowedServerArgs = map[string]bool{
+	"--forceUpgrade": true, "--eraseCache": true, "--recreateRegionFiles": true,
+	"--safeMode": true, "--bonusChest": true, "--demo": true, "--nojline": true,
+}
+
+// validateJVMArg accepts only GC/memory -XX options and -D properties
+func validateJVMArg(arg string) error {
+	lower := strings.ToLower(arg)
+	switch {
+	case strings.HasPrefix(lower, "-xmx") || strings.HasPrefix(lower, "-xms"):
+		return fmt.Errorf("set memory with the min/max fields, not %s", arg)
+	case strings.HasPrefix(arg, "-XX:"):
+		option := strings.TrimPrefix(arg, "-XX:")
+		name, value, hasValue := strings.Cut(option, "=")
+		if !hasValue {
+			if !strings.HasPrefix(name, "+") && !strings.HasPrefix(name, "-") {
+				return fmt.Errorf("JVM flag %q is not allowed", arg)
+			}
+			name = name[1:]
+		} else if !xxValueRe.MatchString(value) {
+			return fmt.Errorf("JVM flag %q has an invalid value", arg)
+		}
+		if allowedXXFlags[name] {
+			return nil
+		}
+		for _, prefix := range allowedXXPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				return nil
+			}
+		}
+		return fmt.Errorf("JVM flag %q is not allowed", arg)
+	case strings.HasPrefix(arg, "-D"):
+		key, _, _ := strings.Cut(strings.TrimPrefix(arg, "-D"), "=")
+		if !propertyKeyRe.MatchString(key) {
+			return fmt.Errorf("JVM property %q is invalid", arg)
+		}
+		for _, prefix := range reservedPropertyPrefixes {
+			if strings.HasPrefix(strings.ToLower(key), prefix) {
+				return fmt.Errorf("JVM property %q is not allowed", arg)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("JVM flag %q is not allowed (only -XX: GC/memory options and -D properties)", arg)
+}
+
+// javaCommand builds the java arguments for a profile. maxMB is the heap
+// after the host's memory cap was applied; target is the installed server's
+// jar or @argument file, used unless the profile names its own.
+func (p LaunchProfile) javaCommand(minMB int, maxMB int, target string) []string {
+	args := []string{fmt.Sprintf("-Xms%dM", minMB), fmt.Sprintf("-Xmx%dM", maxMB)}
+	if p.Preset == PresetAikar {
+		args = append(args, aikarFlags...)
+		args = append(args, aikarSizing(maxMB)...)
+	}
+	args = append(args, p.JVMArgs...)
+
+	switch {
+	case p.JarName != "":
+		args = append(args, "-jar", p.JarName)
+	case strings.HasPrefix(target, "@"):
+		args = append(args, target) // Argument file, e.g. Forge's @libraries/.../unix_args.txt
+	case target != "":
+		args = append(args, "-jar", target)
+	default:
+		args = append(args, "-jar", "server.jar")
+	}
+
+	args = append(args, "nogui")
+	return append(args, p.ServerArgs...)
+}
+
+// heap returns the profile's min and max heap with defaults filled in
+func (p LaunchProfile) heap() (int, int) {
+	maxMB := p.MaxMemoryMB
+	if maxMB == 0 {
+		maxMB = defaultHeapMB
+	}
+	minMB := p.MinMemoryMB
+	if minMB == 0 || minMB > maxMB {
+		minMB = maxMB
+	}
+	return minMB, maxMB
+}
+
+// validate rejects profiles that can't start or would be unsafe to run
+func (p LaunchProfile) validate() error {
+	if p.MaxMemoryMB != 0 && p.MaxMemoryMB < minHeapMB {
+		return fmt.Errorf("max memory must be at least %d MB", minHeapMB)
+	}
+	if p.MinMemoryMB < 0 || p.MaxMemoryMB < 0 {
+		return fmt.Errorf("memory can't be negative")
+	}
+	if p.MaxMemoryMB != 0 && p.MinMemoryMB > p.MaxMemoryMB {
+		return fmt.Errorf("min memory can't be more than max memory")
+	}
+	if p.Preset != PresetNone && p.Preset != PresetAikar {
+		return fmt.Errorf("unknown preset %q", p.Preset)
+	}
+
+	for _, arg := range p.JVMArgs {
+		if err := validateJVMArg(arg); err != nil {
+			return err
+		}
+	}
+	for _, arg := range p.ServerArgs {
+		if !allowedServerArgs[arg] {
+			return fmt.Errorf("server argument %q is not allowed", arg)
+		}
+	}
+
+	// An @file would be read as more JVM flags, past the checks above;
+	// only installed loaders (launchTarget) launch through one
+	if p.JarName != "" {
+		if strings.HasPrefix(p.JarName, "@") || strings.HasPrefix(p.JarName, "-") {
+			return fmt.Errorf("jar name can't start with @ or -")
+		}
+		clean := filepath.Clean(filepath.FromSlash(p.JarName))
+		if filepath.IsAbs(clean) || strings.HasPrefix(clean, "..") {
+			return fmt.Errorf("jar name must be inside the server folder")
+		}
+		if !strings.HasSuffix(strings.ToLower(p.JarName), ".jar") {
+			return fmt.Errorf("jar name must end in .jar")
+		}
+	}
+	return nil
+}
+
+// UpdateLaunchProfile saves how the group's server is launched (admins only).
+// It takes effect the next time someone starts the server.
+func (a *App) UpdateLaunchProfile(serverID string, token string, profile LaunchProfile) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if !a.isAdmin(serverID, username) {
+		return "Error: Only admins can change launch settings"
+	}
+	if err := profile.validate(); err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx, cancel := dbContext()
+	defer cancel()
+	if err := a.store.SetLaunchProfile(ctx, serverID, profile); err != nil {
+		return "Error: " + err.Error()
+	}
+
+	if a.procs.IsRunning(serverID) {
+		a.Log("ℹ️ Launch settings saved. They apply the next time the server starts.")
+	}
+	return "Success"
+}
+
+// HostSettings are per-PC settings, never synced to the group
+type HostSettings struct {
+	MaxMemoryMB    int    `json:"max_memory_mb"`         // 0 = total RAM minus osReserveMB
+	APIAddress     string `json:"api_address,omitempty"` // Listen address of the HTTP API ("" = off)
+	APIOnLAN       bool   `json:"api_on_lan,omitempty"`  // Let the API listen beyond this PC (plain HTTP)
+	SystemMemoryMB int    `json:"system_memory_mb"`      // Read-only, for the UI
+}
+
+var hostSettingsMu sync.Mutex
+
+func hostSettingsPath() string {
+	return filepath.Join(getAppDir(), "host_settings.json")
+}
+
+func loadHostSettings() HostSettings {
+	hostSettingsMu.Lock()
+	defer hostSettingsMu.Unlock()
+	var settings HostSettings
+	if data, err := os.ReadFile(hostSettingsPath()); err == nil {
+		json.Unmarshal(data, &settings)
+	}
+	settings.SystemMemoryMB = totalMemoryMB()
+	return settings
+}
+
+// GetHostSettings returns this PC's settings
+func (a *App) GetHostSettings() HostSettings {
+	return loadHostSettings()
+}
+
+// SetHostMemoryCap limits how much RAM servers hosted on this PC may use (0 = automatic)
+func (a *App) SetHostMemoryCap(token string, maxMemoryMB int) string {
+	if _, err := a.authenticate(token); err != nil {
+		return "Error: " + err.Error()
+	}
+	if maxMemoryMB != 0 && maxMemoryMB < minHeapMB {
+		return fmt.Sprintf("Error: The cap must be at least %d MB", minHeapMB)
+	}
+	if total := totalMemoryMB(); total > 0 && maxMemoryMB > total {
+		return fmt.Sprintf("Error: This PC only has %d MB of RAM", total)
+	}
+
+	err := updateHostSettings(func(settings *HostSettings) {
+		settings.MaxMemoryMB = maxMemoryMB
+	})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Success"
+}
+
+// updateHostSettings changes the saved settings in place
+func updateHostSettings(change func(*HostSettings)) error {
+	hostSettingsMu.Lock()
+	defer hostSettingsMu.Unlock()
+	var settings HostSettings
+	if data, err := os.ReadFile(hostSettingsPath()); err == nil {
+		json.Unmarshal(data, &settings)
+	}
+	change(&settings)
+	settings.SystemMemoryMB = 0 // Measured on every load, not saved
+	data, _ := json.MarshalIndent(settings, "", "  ")
+	return os.WriteFile(hostSettingsPath(), data, 0644)
+}
+
+// hostHeapLimit is the most heap a server may get on this PC (0 = unknown)
+func hostHeapLimit() int {
+	settings := loadHostSettings()
+	if settings.MaxMemoryMB > 0 {
+		return settings.MaxMemoryMB
+	}
+	if settings.SystemMemoryMB > osReserveMB+minHeapMB {
+		return settings.SystemMemoryMB - osReserveMB
+	}
+	return 0
+}
+
+// launchArgs returns the java arguments for a server on this PC
+func (a *App) launchArgs(serverID string) []string {
+	var profile LaunchProfile
+	if server, err := a.getServer(serverID); err == nil {
+		profile = server.Launch
+	}
+	if err := profile.validate(); err != nil {
+		a.Log("⚠️ Ignoring the group's launch settings: " + err.Error())
+		profile = LaunchProfile{}
+	}
+
+	minMB, maxMB := profile.heap()
+	if limit := hostHeapLimit(); limit > 0 && maxMB > limit {
+		a.Log(fmt.Sprintf("⚠️ Group asks for %d MB but this PC allows %d MB. Using %d MB.", maxMB, limit, limit))
+		maxMB = limit
+		if minMB > maxMB {
+			minMB = maxMB
+		}
+	}
+	target := ""
+	if install, ok := readLoaderInstall(a.getInstancePath(serverID)); ok {
+		target = install.launchTarget()
+	}
+	return profile.javaCommand(minMB, maxMB, target)
+}
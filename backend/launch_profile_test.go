@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLaunchProfileValidateArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile LaunchProfile
+		ok      bool
+	}{
+		{"aikar flags", LaunchProfile{JVMArgs: append(append([]string{}, aikarFlags...), aikarSizing(16384)...)}, true},
+		{"zgc", LaunchProfile{JVMArgs: []string{"-XX:+UseZGC", "-XX:+ZGenerational", "-XX:SoftMaxHeapSize=6G"}}, true},
+		{"properties", LaunchProfile{JVMArgs: []string{"-Dfile.encoding=UTF-8", "-Dpaper.disableChannelLimit=true"}}, true},
+		{"server args", LaunchProfile{ServerArgs: []string{"--forceUpgrade", "--nojline"}}, true},
+		{"heap flag", LaunchProfile{JVMArgs: []string{"-Xmx8G"}}, false},
+		{"on error", LaunchProfile{JVMArgs: []string{"-XX:OnError=calc.exe"}}, false},
+		{"on oom", LaunchProfile{JVMArgs: []string{"-XX:OnOutOfMemoryError=sh -c id"}}, false},
+		{"heap dump path", LaunchProfile{JVMArgs: []string{"-XX:+HeapDumpOnOutOfMemoryError"}}, false},
+		{"flags file", LaunchProfile{JVMArgs: []string{"-XX:Flags=/tmp/flags"}}, false},
+		{"path value", LaunchProfile{JVMArgs: []string{"-XX:G1HeapRegionSize=../x"}}, false},
+		{"agent", LaunchProfile{JVMArgs: []string{"-javaagent:evil.jar"}}, false},
+		{"classpath", LaunchProfile{JVMArgs: []string{"-cp", "evil.jar"}}, false},
+		{"log4j config", LaunchProfile{JVMArgs: []string{"-Dlog4j.configurationFile=http://evil/x.xml"}}, false},
+		{"library path", LaunchProfile{JVMArgs: []string{"-Djava.library.path=/tmp"}}, false},
+		{"bare word", LaunchProfile{JVMArgs: []string{"nogui"}}, false},
+		{"plugin from path", LaunchProfile{ServerArgs: []string{"--add-plugin", "/tmp/evil.jar"}}, false},
+		{"world outside", LaunchProfile{ServerArgs: []string{"--universe", ".."}}, false},
+		{"port", LaunchProfile{ServerArgs: []string{"--port", "25566"}}, false},
+		{"jar name", LaunchProfile{JarName: "paper-1.20.4.jar"}, true},
+		{"argument file", LaunchProfile{JarName: "@flags.txt"}, false},
+		{"flag as jar", LaunchProfile{JarName: "-XX:OnError=calc.jar"}, false},
+		{"jar outside", LaunchProfile{JarName: "../server.jar"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.profile.validate(); (err == nil) != tt.ok {
+				t.Errorf("validate() = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLaunchProfileJavaCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile LaunchProfile
+		target  string
+		want    []string
+	}{
+		{"default", LaunchProfile{}, "", []string{"-Xms1024M", "-Xmx2048M", "-jar", "server.jar", "nogui"}},
+		{"installed jar", LaunchProfile{}, "fabric-server-launch.jar", []string{"-Xms1024M", "-Xmx2048M", "-jar", "fabric-server-launch.jar", "nogui"}},
+		{"loader args file", LaunchProfile{}, "@libraries/unix_args.txt", []string{"-Xms1024M", "-Xmx2048M", "@libraries/unix_args.txt", "nogui"}},
+		{"own jar wins", LaunchProfile{JarName: "paper.jar"}, "@libraries/unix_args.txt", []string{"-Xms1024M", "-Xmx2048M", "-jar", "paper.jar", "nogui"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.javaCommand(1024, 2048, tt.target); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("javaCommand = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Lease timings. The host refreshes lock.heartbeat_at every leaseHeartbeatInterval
+// while the server process is alive. If no heartbeat arrives for leaseTTL the
+// lock is considered abandoned (crash, power loss) and another member may take it over.
+const (
+	leaseHeartbeatInterval = 30 * time.Second
+	leaseTTL               = 2 * time.Minute
+)
+
+// heartbeats keeps one stop channel per server we are currently hosting
+var (
+	heartbeatsMu sync.Mutex
+	heartbeats   = map[string]chan struct{}{}
+)
+
+// lockExpired reports whether a running lock has missed its heartbeat window.
+// Locks written before heartbeats existed fall back to hosted_at.
+func lockExpired(lock ServerLock) bool {
+	if !lock.IsRunning {
+		return false
+	}
+	last := lock.HeartbeatAt
+	if lock.HostedAt.After(last) {
+		last = lock.HostedAt
+	}
+	return time.Since(last) > leaseTTL
+}
+
+// claimLock acquires the lock for a new hosting session, or takes current
+// over if its host stopped sending heartbeats. takeover reports which.
+func (a *App) claimLock(ctx context.Context, serverID string, current ServerLock, lock ServerLock) (acquired bool, takeover bool, err error) {
+	if !lockExpired(current) {
+		acquired, err = a.store.AcquireLock(ctx, serverID, lock)
+		return acquired, false, err
+	}
+	lock.TakenOverFrom = current.HostedBy
+	lock.TakenOverAt = lock.HostedAt
+	acquired, err = a.store.TakeOverLock(ctx, serverID, current, lock.HostedAt.Add(-leaseTTL), lock)
+	return acquired, true, err
+}
+
+// startHeartbeat refreshes the lease in the background until stopHeartbeat is
+// called, the Minecraft process exits, or another host takes the lock over.
+func (a *App) startHeartbeat(serverID string, username string) {
+	heartbeatsMu.Lock()
+	if old, ok := heartbeats[serverID]; ok {
+		close(old)
+	}
+	stop := make(chan struct{})
+	heartbeats[serverID] = stop
+	heartbeatsMu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(leaseHeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			// Only keep the lease alive while the game is actually running
+			if !a.procs.IsRunning(serverID) {
+				a.Log("💔 Minecraft process is gone. Heartbeat stopped.")
+				a.clearHeartbeat(serverID, stop)
+				return
+			}
+
+			held, err := a.refreshLease(serverID, username)
+			if err != nil {
+				a.Log(fmt.Sprintf("⚠️ Heartbeat failed: %v", err))
+				continue // Transient DB error, try again next tick
+			}
+			if !held {
+				a.Log("❌ Lost the server lock (another host took over). Heartbeat stopped.")
+				a.clearHeartbeat(serverID, stop)
+				return
+			}
+		}
+	}()
+}
+
+// stopHeartbeat ends the heartbeat loop for a server, if any
+func (a *App) stopHeartbeat(serverID string) {
+	heartbeatsMu.Lock()
+	defer heartbeatsMu.Unlock()
+	if stop, ok := heartbeats[serverID]; ok {
+		close(stop)
+		delete(heartbeats, serverID)
+	}
+}
+
+// clearHeartbeat removes the entry only if it still belongs to the given loop
+func (a *App) clearHeartbeat(serverID string, stop chan struct{}) {
+	heartbeatsMu.Lock()
+	defer heartbeatsMu.Unlock()
+	if heartbeats[serverID] == stop {
+		delete(heartbeats, serverID)
+	}
+}
+
+// refreshLease bumps lock.heartbeat_at. It returns false if we no longer hold the lock.
+func (a *App) refreshLease(serverID string, username string) (bool, error) {
+	ctx, cancel := dbContext()
+	defer cancel()
+
+	return a.store.RefreshLock(ctx, serverID, username, time.Now())
+}
+
+// errLeaseLost stops work done under withServerLock once the lock is gone
+var errLeaseLost = errors.New("lost the server lock (another host took over or the database is unreachable)")
+
+// withServerLock holds the server's lock while fn works on its files here
+// without running it (sync, plugin edits), so nobody starts the server or
+// edits it meanwhile. The lease is refreshed until fn returns; if a refresh
+// fails or someone else holds the lock, ctx is cancelled with errLeaseLost
+// and fn must not upload anything.
+func (a *App) withServerLock(serverID string, username string, fn func(ctx context.Context) error) error {
+	now := time.Now()
+	ctx, cancel := dbContext()
+	acquired, err := a.store.AcquireLock(ctx, serverID, ServerLock{
+		IsRunning:   true,
+		HostedBy:    username,
+		HostedAt:    now,
+		HeartbeatAt: now,
+	})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("database connection failed")
+	}
+	if !acquired {
+		return fmt.Errorf("the server is in use by someone else")
+	}
+
+	leaseCtx, lose := context.WithCancelCause(context.Background())
+	defer lose(nil)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(leaseHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			if held, err := a.refreshLease(serverID, username); err != nil || !held {
+				a.Log("❌ Lost the server lock. Stopping before anything is uploaded.")
+				lose(errLeaseLost)
+				return
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-done
+		ctx, cancel := dbContext()
+		a.store.ReleaseLock(ctx, serverID, username)
+		cancel()
+	}()
+
+	err = fn(leaseCtx)
+	if cause := context.Cause(leaseCtx); cause != nil && err == nil {
+		return cause
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockExpired(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		lock ServerLock
+		want bool
+	}{
+		{name: "free", lock: ServerLock{}, want: false},
+		{name: "free with an old heartbeat", lock: ServerLock{HeartbeatAt: now.Add(-time.Hour)}, want: false},
+		{name: "fresh heartbeat", lock: ServerLock{IsRunning: true, HostedAt: now.Add(-time.Hour), HeartbeatAt: now.Add(-leaseHeartbeatInterval)}, want: false},
+		{name: "just inside the window", lock: ServerLock{IsRunning: true, HostedAt: now.Add(-time.Hour), HeartbeatAt: now.Add(-leaseTTL + time.Second)}, want: false},
+		{name: "missed heartbeats", lock: ServerLock{IsRunning: true, HostedAt: now.Add(-time.Hour), HeartbeatAt: now.Add(-leaseTTL - time.Second)}, want: true},
+		{name: "no heartbeat yet falls back to hosted_at", lock: ServerLock{IsRunning: true, HostedAt: now}, want: false},
+		{name: "old lock without heartbeats", lock: ServerLock{IsRunning: true, HostedAt: now.Add(-time.Hour)}, want: true},
+		{name: "newer hosted_at wins", lock: ServerLock{IsRunning: true, HostedAt: now, HeartbeatAt: now.Add(-time.Hour)}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockExpired(tt.lock); got != tt.want {
+				t.Errorf("lockExpired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimLock(t *testing.T) {
+	now := time.Now()
+	stale := now.Add(-leaseTTL - time.Minute)
+	held := func(at time.Time) *ServerLock {
+		return &ServerLock{IsRunning: true, HostedBy: "alice", HostedAt: at, HeartbeatAt: at}
+	}
+
+	tests := []struct {
+		name         string
+		current      *ServerLock // Lock in the store before bob claims it
+		seen         *ServerLock // What bob read, if not current
+		wantAcquired bool
+		wantTakeover bool
+	}{
+		{name: "free lock", wantAcquired: true},
+		{name: "fresh lock is refused", current: held(now)},
+		{name: "expired lock is taken over", current: held(stale), wantAcquired: true, wantTakeover: true},
+		{
+			name:         "pending upload is never taken over",
+			current:      &ServerLock{IsRunning: true, HostedBy: "alice", HostedAt: stale, HeartbeatAt: stale, PendingUpload: true},
+			wantTakeover: true,
+		},
+		{
+			// alice came back between bob's read and his takeover
+			name:         "heartbeat after the read wins",
+			current:      held(now),
+			seen:         held(stale),
+			wantTakeover: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _, _ := newTestApp(t)
+			ctx := context.Background()
+			if tt.current != nil {
+				if ok, err := a.store.AcquireLock(ctx, "srv", *tt.current); err != nil || !ok {
+					t.Fatalf("AcquireLock = %v, %v", ok, err)
+				}
+			}
+			// StartServer claims against the lock it read from the store
+			server, err := a.store.GetServer(ctx, "srv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			seen := server.Lock
+			if tt.seen != nil {
+				seen = *tt.seen
+			}
+
+			lock := ServerLock{IsRunning: true, HostedBy: "bob", HostedAt: now, HeartbeatAt: now}
+			acquired, takeover, err := a.claimLock(ctx, "srv", seen, lock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if acquired != tt.wantAcquired || takeover != tt.wantTakeover {
+				t.Fatalf("claimLock = acquired %v, takeover %v; want %v, %v", acquired, takeover, tt.wantAcquired, tt.wantTakeover)
+			}
+
+			server, err = a.store.GetServer(ctx, "srv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "alice"
+			if tt.wantAcquired {
+				want = "bob"
+			}
+			if tt.current == nil && !tt.wantAcquired {
+				want = ""
+			}
+			if server.Lock.HostedBy != want {
+				t.Errorf("lock held by %q, want %q", server.Lock.HostedBy, want)
+			}
+			if tt.wantAcquired && tt.wantTakeover && server.Lock.TakenOverFrom != "alice" {
+				t.Errorf("TakenOverFrom = %q, want alice", server.Lock.TakenOverFrom)
+			}
+		})
+	}
+}
+
+func TestWithServerLock(t *testing.T) {
+	a, _, _ := newTestApp(t)
+	ctx := context.Background()
+
+	var during ServerLock
+	err := a.withServerLock("srv", "alice", func(ctx context.Context) error {
+		server, err := a.store.GetServer(ctx, "srv")
+		during = server.Lock
+		if err != nil {
+			return err
+		}
+		// Nobody else can start or edit the server meanwhile
+		if err := a.withServerLock("srv", "bob", func(context.Context) error { return nil }); err == nil || !strings.Contains(err.Error(), "in use") {
+			t.Errorf("nested withServerLock = %v, want in use", err)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !during.IsRunning || during.HostedBy != "alice" {
+		t.Errorf("lock while fn ran = %+v", during)
+	}
+	server, _ := a.store.GetServer(ctx, "srv")
+	if server.Lock.IsRunning {
+		t.Errorf("lock not released: %+v", server.Lock)
+	}
+
+	// fn's error comes back and the lock is still released
+	boom := errors.New("boom")
+	if err := a.withServerLock("srv", "alice", func(context.Context) error { return boom }); err != boom {
+		t.Errorf("withServerLock = %v, want %v", err, boom)
+	}
+	server, _ = a.store.GetServer(ctx, "srv")
+	if server.Lock.IsRunning {
+		t.Errorf("lock not released after an error: %+v", server.Lock)
+	}
+}
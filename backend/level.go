@@ -0,0 +1,195 @@
+package backend
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// NBT tag types (the format of level.dat)
+const (
+	nbtEnd byte = iota
+	nbtByte
+	nbtShort
+	nbtInt
+	nbtLong
+	nbtFloat
+	nbtDouble
+	nbtByteArray
+	nbtString
+	nbtList
+	nbtCompound
+	nbtIntArray
+	nbtLongArray
+)
+
+// nbtMaxLength guards against corrupt lengths allocating gigabytes, and
+// nbtMaxDepth against nesting deep enough to exhaust the stack (Minecraft
+// uses the same limit)
+const (
+	nbtMaxLength = 1 << 24
+	nbtMaxDepth  = 512
+)
+
+// readLevelVersion returns the Minecraft version a world was last played
+// on, from level.dat's Data.Version.Name. Worlds from before 1.9 don't
+// record it.
+func readLevelVersion(levelDat string) (string, error) {
+	root, err := readLevelDat(levelDat)
+	if err != nil {
+		return "", err
+	}
+	data, _ := root["Data"].(map[string]interface{})
+	version, _ := data["Version"].(map[string]interface{})
+	name, _ := version["Name"].(string)
+	if name == "" {
+		return "", fmt.Errorf("level.dat doesn't record its Minecraft version (world from before 1.9)")
+	}
+	return name, nil
+}
+
+// readLevelDat decodes a gzipped NBT file into maps, slices and numbers
+func readLevelDat(path string) (map[string]interface{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("level.dat is not gzipped NBT: %v", err)
+	}
+	defer gz.Close()
+
+	r := nbtReader{bufio.NewReader(gz)}
+	tagType, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	if tagType != nbtCompound {
+		return nil, fmt.Errorf("level.dat doesn't start with a compound tag")
+	}
+	if _, err := r.string(); err != nil { // Root name, usually empty
+		return nil, err
+	}
+	value, err := r.payload(nbtCompound, 0)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt level.dat: %v", err)
+	}
+	return value.(map[string]interface{}), nil
+}
+
+type nbtReader struct {
+	r *bufio.Reader
+}
+
+func (n nbtReader) byte() (byte, error) {
+	return n.r.ReadByte()
+}
+
+func (n nbtReader) read(v interface{}) error {
+	return binary.Read(n.r, binary.BigEndian, v)
+}
+
+func (n nbtReader) length() (int, error) {
+	var length int32
+	if err := n.read(&length); err != nil {
+		return 0, err
+	}
+	if length < 0 || length > nbtMaxLength {
+		return 0, fmt.Errorf("bad length %d", length)
+	}
+	return int(length), nil
+}
+
+func (n nbtReader) string() (string, error) {
+	var length uint16
+	if err := n.read(&length); err != nil {
+		return "", err
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(n.r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// payload reads the value of a tag of the given type, depth lists and
+// compounds down
+func (n nbtReader) payload(tagType byte, depth int) (interface{}, error) {
+	if (tagType == nbtList || tagType == nbtCompound) && depth >= nbtMaxDepth {
+		return nil, fmt.Errorf("tags nested deeper than %d", nbtMaxDepth)
+	}
+	switch tagType {
+	case nbtByte:
+		var v int8
+		return v, n.read(&v)
+	case nbtShort:
+		var v int16
+		return v, n.read(&v)
+	case nbtInt:
+		var v int32
+		return v, n.read(&v)
+	case nbtLong:
+		var v int64
+		return v, n.read(&v)
+	case nbtFloat:
+		var v float32
+		return v, n.read(&v)
+	case nbtDouble:
+		var v float64
+		return v, n.read(&v)
+	case nbtString:
+		return n.string()
+	case nbtByteArray, nbtIntArray, nbtLongArray:
+		length, err := n.length()
+		if err != nil {
+			return nil, err
+		}
+		size := map[byte]int{nbtByteArray: 1, nbtIntArray: 4, nbtLongArray: 8}[tagType]
+		_, err = n.r.Discard(length * size) // Not needed for anything we read
+		return nil, err
+	case nbtList:
+		elemType, err := n.byte()
+		if err != nil {
+			return nil, err
+		}
+		length, err := n.length()
+		if err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0, min(length, 1024))
+		for i := 0; i < length; i++ {
+			v, err := n.payload(elemType, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case nbtCompound:
+		compound := map[string]interface{}{}
+		for {
+			childType, err := n.byte()
+			if err != nil {
+				return nil, err
+			}
+			if childType == nbtEnd {
+				return compound, nil
+			}
+			name, err := n.string()
+			if err != nil {
+				return nil, err
+			}
+			if compound[name], err = n.payload(childType, depth+1); err != nil {
+				return nil, err
+			}
+		}
+	case nbtEnd:
+		return nil, nil // Empty lists have element type End
+	}
+	return nil, fmt.Errorf("unknown tag type %d", tagType)
+}
@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// nbtWriter builds NBT for tests
+type nbtWriter struct {
+	bytes.Buffer
+}
+
+func (w *nbtWriter) tag(tagType byte, name string) {
+	w.WriteByte(tagType)
+	binary.Write(w, binary.BigEndian, uint16(len(name)))
+	w.WriteString(name)
+}
+
+func (w *nbtWriter) str(name string, value string) {
+	w.tag(nbtString, name)
+	binary.Write(w, binary.BigEndian, uint16(len(value)))
+	w.WriteString(value)
+}
+
+func writeLevelDat(t *testing.T, nbt []byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(nbt)
+	gz.Close()
+	path := filepath.Join(t.TempDir(), "level.dat")
+	os.WriteFile(path, buf.Bytes(), 0644)
+	return path
+}
+
+func TestReadLevelVersion(t *testing.T) {
+	var w nbtWriter
+	w.tag(nbtCompound, "")
+	w.tag(nbtCompound, "Data")
+	w.tag(nbtList, "ServerBrands")
+	w.WriteByte(nbtString)
+	binary.Write(&w, binary.BigEndian, int32(1))
+	binary.Write(&w, binary.BigEndian, uint16(5))
+	w.WriteString("paper")
+	w.tag(nbtCompound, "Version")
+	w.str("Name", "1.20.4")
+	w.WriteByte(nbtEnd)
+	w.WriteByte(nbtEnd)
+	w.WriteByte(nbtEnd)
+
+	version, err := readLevelVersion(writeLevelDat(t, w.Bytes()))
+	if err != nil || version != "1.20.4" {
+		t.Errorf("readLevelVersion = %q, %v", version, err)
+	}
+}
+
+func TestReadLevelDatDepthLimit(t *testing.T) {
+	nested := func(depth int) []byte {
+		var w nbtWriter
+		w.tag(nbtCompound, "")
+		for i := 0; i < depth; i++ {
+			w.tag(nbtCompound, "x")
+		}
+		for i := 0; i <= depth; i++ {
+			w.WriteByte(nbtEnd)
+		}
+		return w.Bytes()
+	}
+	if _, err := readLevelDat(writeLevelDat(t, nested(nbtMaxDepth-1))); err != nil {
+		t.Errorf("nesting within the limit: %v", err)
+	}
+	if _, err := readLevelDat(writeLevelDat(t, nested(nbtMaxDepth))); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+		t.Errorf("nesting past the limit: error = %v", err)
+	}
+
+	// Lists of lists count too
+	var w nbtWriter
+	w.tag(nbtCompound, "")
+	w.tag(nbtList, "deep")
+	for i := 0; i < 100000; i++ {
+		w.WriteByte(nbtList)
+		binary.Write(&w, binary.BigEndian, int32(1))
+	}
+	if _, err := readLevelDat(writeLevelDat(t, w.Bytes())); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+		t.Errorf("nested lists: error = %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// chatHistoryLimit is how many chat messages we keep per running server
+const chatHistoryLimit = 200
+
+// serverReadyTimeout is how long we wait for "Done (Xs)!" before giving up
+const serverReadyTimeout = 10 * time.Minute
+
+// OnlinePlayer is a player currently connected to a server hosted on this PC
+type OnlinePlayer struct {
+	Name     string    `json:"name"`
+	UUID     string    `json:"uuid,omitempty"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ChatMessage is one line of in-game chat
+type ChatMessage struct {
+	Player string    `json:"player"`
+	Text   string    `json:"text"`
+	At     time.Time `json:"at"`
+}
+
+// liveServer is what we know about a running server from its console
+type liveServer struct {
+	mu      sync.Mutex
+	parser  *LogParser
+	players map[string]*OnlinePlayer
+	uuids   map[string]string // Seen before the join line
+	chat    []ChatMessage
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+var (
+	liveMu      sync.Mutex
+	liveServers = map[string]*liveServer{}
+)
+
+// newLiveServer resets the live state of a server that is about to start
+func newLiveServer(serverID string) *liveServer {
+	live := &liveServer{
+		parser:  NewLogParser(),
+		players: map[string]*OnlinePlayer{},
+		uuids:   map[string]string{},
+		ready:   make(chan struct{}),
+	}
+	liveMu.Lock()
+	liveServers[serverID] = live
+	liveMu.Unlock()
+	return live
+}
+
+func getLiveServer(serverID string) *liveServer {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	return liveServers[serverID]
+}
+
+// watchLog returns an output listener that parses the console into events
+func (a *App) watchLog(live *liveServer) OutputListener {
+	return func(serverID string, line string, isErr bool) {
+		// stdout and stderr are pumped concurrently
+		live.mu.Lock()
+		events := live.parser.Feed(line)
+		for _, e := range events {
+			live.apply(e)
+		}
+		live.mu.Unlock()
+
+		for _, e := range events {
+			a.publishLogEvent(serverID, e)
+		}
+	}
+}
+
+// apply updates players, chat and readiness. Caller holds live.mu.
+func (live *liveServer) apply(e LogEvent) {
+	switch e.Kind {
+	case LogPlayerUUID:
+		live.uuids[e.Player] = e.UUID
+		if p, ok := live.players[e.Player]; ok {
+			p.UUID = e.UUID
+		}
+	case LogPlayerJoin:
+		live.players[e.Player] = &OnlinePlayer{Name: e.Player, UUID: live.uuids[e.Player], JoinedAt: time.Now()}
+	case LogPlayerLeave:
+		delete(live.players, e.Player)
+	case LogChat:
+		live.chat = append(live.chat, ChatMessage{Player: e.Player, Text: e.Message, At: time.Now()})
+		if len(live.chat) > chatHistoryLimit {
+			live.chat = live.chat[len(live.chat)-chatHistoryLimit:]
+		}
+	case LogServerReady:
+		live.readyOnce.Do(func() { close(live.ready) })
+	}
+}
+
+// finishLog publishes a crash trace the server printed just before exiting
+// and clears the online list
+func (a *App) finishLog(serverID string, live *liveServer) {
+	live.mu.Lock()
+	events := live.parser.Flush()
+	live.players = map[string]*OnlinePlayer{}
+	live.mu.Unlock()
+
+	for _, e := range events {
+		a.publishLogEvent(serverID, e)
+	}
+}
+
+// publishLogEvent turns a parsed log event into bus events
+func (a *App) publishLogEvent(serverID string, e LogEvent) {
+	event := Event{
+		Topic:    TopicGame,
+		Source:   SourceMinecraft,
+		ServerID: serverID,
+		Payload:  e,
+	}
+	switch e.Kind {
+	case LogServerReady:
+		a.Log(fmt.Sprintf("✅ Server is ready (started in %.1fs)", e.Startup))
+		a.setState(serverID, StateRunning)
+		go a.applyWorldSettings(serverID)
+	case LogPlayerJoin:
+		a.Log("👋 " + e.Player + " joined")
+	case LogPlayerLeave:
+		a.Log("🚪 " + e.Player + " left")
+	case LogCrash:
+		event.Severity = SeverityError
+		a.Log("❌ Server error: " + e.Message)
+	}
+	a.publish(event)
+}
+
+// waitReady blocks until the server printed "Done", exited, or timeout passed
+func (a *App) waitReady(serverID string, timeout time.Duration) bool {
+	live := getLiveServer(serverID)
+	proc := a.procs.Get(serverID)
+	if live == nil || proc == nil {
+		return false
+	}
+	select {
+	case <-live.ready:
+		return true
+	case <-proc.Done():
+		return false
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// GetOnlinePlayers returns who is connected right now (server must be hosted on this PC)
+func (a *App) GetOnlinePlayers(serverID string, token string) []OnlinePlayer {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return []OnlinePlayer{}
+	}
+	live := getLiveServer(serverID)
+	if live == nil || !a.procs.IsRunning(serverID) {
+		return []OnlinePlayer{}
+	}
+
+	live.mu.Lock()
+	defer live.mu.Unlock()
+	players := make([]OnlinePlayer, 0, len(live.players))
+	for _, p := range live.players {
+		players = append(players, *p)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].JoinedAt.Before(players[j].JoinedAt) })
+	return players
+}
+
+// GetChatHistory returns recent chat of the current session, oldest first
+func (a *App) GetChatHistory(serverID string, token string) []ChatMessage {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return []ChatMessage{}
+	}
+	live := getLiveServer(serverID)
+	if live == nil {
+		return []ChatMessage{}
+	}
+
+	live.mu.Lock()
+	defer live.mu.Unlock()
+	return append([]ChatMessage{}, live.chat...)
+}
@@ -0,0 +1,252 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// loaderFile records how the server in a folder was installed. It is synced
+// with the world, while libraries/ and versions/ are not: they are large
+// and the recorded build lets any host rebuild them exactly.
+const loaderFile = ".mcroam-loader.json"
+
+// installerTimeout bounds a Forge/NeoForge installer run (it downloads libraries)
+const installerTimeout = 20 * time.Minute
+
+// LoaderInstall is the content of loaderFile
+type LoaderInstall struct {
+	Type     string `json:"type"`
+	Version  string `json:"version"` // Minecraft version
+	Build    string `json:"build,omitempty"`
+	Url      string `json:"url"` // What was installed: server jar or installer
+	Checksum string `json:"checksum,omitempty"`
+
+	Jar     string `json:"jar,omitempty"`      // Runnable jar, relative to the folder
+	ArgsDir string `json:"args_dir,omitempty"` // Folder with unix_args.txt / win_args.txt (Forge 1.17+)
+}
+
+// ServerLoader installs one kind of server into an instance folder
+type ServerLoader interface {
+	// Install sets up the server for v in env.Dir (keeping worlds and
+	// configs) and reports how to launch it
+	Install(ctx context.Context, env LoaderEnv, v ServerVersion) (LoaderInstall, error)
+}
+
+// LoaderEnv is what an install can use
+type LoaderEnv struct {
+	Dir      string
+	Java     string // For installers
+	Download func(Download) error
+	Log      func(string)
+}
+
+// serverLoaders maps ServerGroup.Type to its loader. Types not listed run a
+// single server jar.
+var serverLoaders = map[string]ServerLoader{
+	"Forge": InstallerLoader{
+		Name:    "Forge",
+		ArgsDir: func(v ServerVersion) string { return "libraries/net/minecraftforge/forge/" + v.Version + "-" + v.Build },
+	},
+	"NeoForge": InstallerLoader{
+		Name:    "NeoForge",
+		ArgsDir: func(v ServerVersion) string { return "libraries/net/neoforged/neoforge/" + v.Build },
+	},
+}
+
+func loaderFor(serverType string) ServerLoader {
+	if l, ok := serverLoaders[serverType]; ok {
+		return l
+	}
+	return JarLoader{}
+}
+
+// --- Single jar (Vanilla, Paper, Purpur, Fabric's server launcher) ---
+
+// JarLoader downloads a runnable server.jar. Paper and Fabric fetch their
+// libraries on first start by themselves.
+type JarLoader struct{}
+
+func (JarLoader) Install(ctx context.Context, env LoaderEnv, v ServerVersion) (LoaderInstall, error) {
+	if v.Installer {
+		return LoaderInstall{}, fmt.Errorf("%s ships an installer, not a server jar", v.Type)
+	}
+	env.Log(fmt.Sprintf("⬇️ Downloading %s %s Server Jar...", v.Type, v.Version))
+	err := env.Download(Download{
+		URL:      v.Url,
+		Dest:     filepath.Join(env.Dir, "server.jar"),
+		Checksum: v.Checksum,
+		Name:     fmt.Sprintf("%s %s (build %s)", v.Type, v.Version, v.Build),
+	})
+	if err != nil {
+		return LoaderInstall{}, err
+	}
+	return newLoaderInstall(v, "server.jar", ""), nil
+}
+
+// --- Forge / NeoForge ---
+
+// InstallerLoader runs a Forge-style installer with --installServer. Since
+// 1.17 it produces an argument file under libraries/; older Forge produces
+// a forge-*.jar that needs libraries/ next to it.
+type InstallerLoader struct {
+	Name    string
+	ArgsDir func(v ServerVersion) string // Where the argument files land
+}
+
+func (l InstallerLoader) Install(ctx context.Context, env LoaderEnv, v ServerVersion) (LoaderInstall, error) {
+	installer := filepath.Join(env.Dir, fmt.Sprintf("%s-%s-installer.jar", strings.ToLower(l.Name), v.Build))
+	defer os.Remove(installer)
+
+	env.Log(fmt.Sprintf("⬇️ Downloading %s %s installer (build %s)...", l.Name, v.Version, v.Build))
+	err := env.Download(Download{
+		URL:      v.Url,
+		Dest:     installer,
+		Checksum: v.Checksum,
+		Name:     fmt.Sprintf("%s %s installer", l.Name, v.Build),
+	})
+	if err != nil {
+		return LoaderInstall{}, err
+	}
+
+	env.Log(fmt.Sprintf("🧩 Running the %s installer (this downloads libraries and can take a few minutes)...", l.Name))
+	ctx, cancel := context.WithTimeout(ctx, installerTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, env.Java, "-jar", filepath.Base(installer), "--installServer")
+	cmd.Dir = env.Dir
+	prepareCommand(cmd)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return LoaderInstall{}, fmt.Errorf("%s installer failed: %v%s", l.Name, err, lastLines(output.String(), 5))
+	}
+
+	// Forge 1.17+ / NeoForge: java @libraries/.../unix_args.txt
+	argsDir := l.ArgsDir(v)
+	if _, err := os.Stat(filepath.Join(env.Dir, filepath.FromSlash(argsDir), "unix_args.txt")); err == nil {
+		return newLoaderInstall(v, "", argsDir), nil
+	}
+	// Older Forge: java -jar forge-<version>.jar (or ...-universal.jar)
+	jars, _ := filepath.Glob(filepath.Join(env.Dir, "forge-*.jar"))
+	for _, jar := range jars {
+		if !strings.Contains(filepath.Base(jar), "installer") {
+			return newLoaderInstall(v, filepath.Base(jar), ""), nil
+		}
+	}
+	return LoaderInstall{}, fmt.Errorf("%s installer finished but produced no server to launch", l.Name)
+}
+
+// lastLines returns the end of a command's output for an error message
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		return ""
+	}
+	return ":\n" + strings.Join(lines, "\n")
+}
+
+func newLoaderInstall(v ServerVersion, jar string, argsDir string) LoaderInstall {
+	return LoaderInstall{
+		Type: v.Type, Version: v.Version, Build: v.Build, Url: v.Url, Checksum: v.Checksum,
+		Jar: jar, ArgsDir: argsDir,
+	}
+}
+
+// serverVersion is the exact build that was installed
+func (l LoaderInstall) serverVersion() ServerVersion {
+	return ServerVersion{
+		Type: l.Type, Version: l.Version, Build: l.Build, Url: l.Url, Checksum: l.Checksum,
+		Installer: l.Type == "Forge" || l.Type == "NeoForge",
+	}
+}
+
+// launchTarget is what java loads: "@<args file>" for this OS, or a jar
+func (l LoaderInstall) launchTarget() string {
+	if l.ArgsDir != "" {
+		name := "unix_args.txt"
+		if runtime.GOOS == "windows" {
+			name = "win_args.txt"
+		}
+		return "@" + path.Join(l.ArgsDir, name)
+	}
+	return l.Jar
+}
+
+// complete reports whether everything the launch needs is in dir. The
+// installer's output under libraries/ isn't synced, so a new host lacks it.
+func (l LoaderInstall) complete(dir string) bool {
+	target := strings.TrimPrefix(l.launchTarget(), "@")
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(target))); err != nil {
+		return false
+	}
+	if l.serverVersion().Installer && l.ArgsDir == "" {
+		// Old Forge loads its libraries from libraries/ at runtime
+		if _, err := os.Stat(filepath.Join(dir, "libraries")); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func readLoaderInstall(dir string) (LoaderInstall, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, loaderFile))
+	if err != nil {
+		return LoaderInstall{}, false
+	}
+	var l LoaderInstall
+	if json.Unmarshal(data, &l) != nil || (l.Jar == "" && l.ArgsDir == "") {
+		return LoaderInstall{}, false
+	}
+	return l, true
+}
+
+func writeLoaderInstall(dir string, l LoaderInstall) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, loaderFile), data, 0644)
+}
+
+// serverInstalled reports whether a folder has a server to run (servers
+// installed before loaderFile existed just have server.jar)
+func serverInstalled(dir string) bool {
+	if _, ok := readLoaderInstall(dir); ok {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, "server.jar"))
+	return err == nil
+}
+
+// installLoader installs v into dir with its type's loader and records it
+func (a *App) installLoader(dir string, v ServerVersion, javaPath string) error {
+	env := LoaderEnv{Dir: dir, Java: javaPath, Download: a.download, Log: a.Log}
+	install, err := loaderFor(v.Type).Install(context.Background(), env, v)
+	if err != nil {
+		return err
+	}
+	return writeLoaderInstall(dir, install)
+}
+
+// ensureLoader rebuilds what a recorded install needs but the sync left
+// out, using the same build so every host runs identical files
+func (a *App) ensureLoader(dir string, javaPath string) error {
+	install, ok := readLoaderInstall(dir)
+	if !ok || install.complete(dir) {
+		return nil
+	}
+	a.Log(fmt.Sprintf("🧩 Rebuilding %s %s (build %s) files on this PC...", install.Type, install.Version, install.Build))
+	return a.installLoader(dir, install.serverVersion(), javaPath)
+}
@@ -0,0 +1,182 @@
+package backend
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LogEventKind is what a parsed server log line means
+type LogEventKind string
+
+const (
+	LogPlayerJoin  LogEventKind = "player-join"
+	LogPlayerLeave LogEventKind = "player-leave"
+	LogPlayerUUID  LogEventKind = "player-uuid"
+	LogChat        LogEventKind = "chat"
+	LogDeath       LogEventKind = "death"
+	LogAdvancement LogEventKind = "advancement"
+	LogServerReady LogEventKind = "server-ready"
+	LogCrash       LogEventKind = "crash"
+)
+
+// LogEvent is one thing that happened in a Minecraft server, read from its console
+type LogEvent struct {
+	Kind    LogEventKind `json:"kind"`
+	Time    string       `json:"time"`              // As printed by the server, e.g. "12:34:56"
+	Player  string       `json:"player,omitempty"`  // Join, leave, uuid, chat, death, advancement
+	UUID    string       `json:"uuid,omitempty"`    // player-uuid
+	Message string       `json:"message,omitempty"` // Chat text, death message, advancement title
+	Startup float64      `json:"startup,omitempty"` // server-ready: seconds it took to start
+	Trace   []string     `json:"trace,omitempty"`   // crash: the exception and stack
+}
+
+var (
+	// [12:34:56] [Server thread/INFO]: msg        (Vanilla, Fabric)
+	// [12:34:56] [Server thread/INFO] [minecraft/DedicatedServer]: msg   (Forge)
+	vanillaLineRe = regexp.MustCompile(`^\[([^\]]+)\] \[([^\]]+)/([A-Z]+)\](?: \[[^\]]*\])?: (.*)$`)
+	// [12:34:56 INFO]: msg                        (Paper, Spigot, Purpur)
+	paperLineRe = regexp.MustCompile(`^\[(\d{2}:\d{2}:\d{2}) ([A-Z]+)\]: (.*)$`)
+
+	joinRe        = regexp.MustCompile(`^([.\w]{1,17}) joined the game$`)
+	leaveRe       = regexp.MustCompile(`^([.\w]{1,17}) left the game$`)
+	uuidRe        = regexp.MustCompile(`^UUID of player ([.\w]{1,17}) is ([0-9a-fA-F-]{32,36})$`)
+	chatRe        = regexp.MustCompile(`^(?:\[Not Secure\] )?<([.\w]{1,17})> (.*)$`)
+	readyRe       = regexp.MustCompile(`^Done \(([\d.]+)s\)! For help`)
+	advancementRe = regexp.MustCompile(`^([.\w]{1,17}) has (?:made the advancement|completed the challenge|reached the goal) \[(.+)\]$`)
+
+	// Death messages start with the player's name, then one of these.
+	// "was " covers slain/shot/blown up/killed/... variants.
+	deathRe = regexp.MustCompile(`^(was |fell |walked into |drowned|died|blew up|burned to death|went up in flames|went off with a bang|tried to swim in lava|hit the ground too hard|starved to death|suffocated in a wall|experienced kinetic energy|froze to death|withered away|discovered the floor was lava|didn't want to live|left the confines of this world|was squashed)`)
+
+	// Colour codes some servers print even when not on a terminal
+	ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+	// Lines that begin or continue a Java stack trace
+	exceptionRe = regexp.MustCompile(`^([\w$]+\.)+[\w$]*(Exception|Error|Throwable)\b`)
+)
+
+// LogParser turns console lines into LogEvents. It remembers who is online,
+// which it needs to tell death messages apart from other lines, and
+// collects multi-line stack traces. Not safe for concurrent use.
+type LogParser struct {
+	online map[string]bool
+	trace  []string
+	time   string // Timestamp of the line that started the trace
+}
+
+// NewLogParser creates a parser with nobody online
+func NewLogParser() *LogParser {
+	return &LogParser{online: map[string]bool{}}
+}
+
+// Feed parses one line. A line can complete a pending crash trace, so it
+// may return more than one event.
+func (p *LogParser) Feed(line string) []LogEvent {
+	line = ansiRe.ReplaceAllString(strings.TrimRight(line, "\r\n"), "")
+	ts, level, msg, ok := splitLogLine(line)
+
+	// Raw lines (no header) are stack frames, exception text or crash reports
+	if !ok {
+		if p.trace != nil {
+			p.trace = append(p.trace, line)
+		} else if exceptionRe.MatchString(line) || strings.HasPrefix(line, "---- Minecraft Crash Report") {
+			p.trace = []string{line}
+		}
+		return nil
+	}
+
+	var events []LogEvent
+	if crash, ok := p.flushTrace(); ok {
+		events = append(events, crash)
+	}
+
+	if level == "ERROR" || level == "FATAL" {
+		// The stack (if any) follows on raw lines
+		p.trace = []string{msg}
+		p.time = ts
+		return events
+	}
+
+	if e, ok := p.parseMessage(ts, msg); ok {
+		events = append(events, e)
+	}
+	return events
+}
+
+// Flush returns a crash trace still being collected (call when the stream ends)
+func (p *LogParser) Flush() []LogEvent {
+	if crash, ok := p.flushTrace(); ok {
+		return []LogEvent{crash}
+	}
+	return nil
+}
+
+// Online lists the players the parser thinks are connected
+func (p *LogParser) Online() []string {
+	names := make([]string, 0, len(p.online))
+	for name := range p.online {
+		names = append(names, name)
+	}
+	return names
+}
+
+// flushTrace ends the current trace. Only traces with an actual stack
+// (or a crash report) count as crashes; lone ERROR lines are dropped.
+func (p *LogParser) flushTrace() (LogEvent, bool) {
+	trace, ts := p.trace, p.time
+	p.trace, p.time = nil, ""
+	if len(trace) == 0 {
+		return LogEvent{}, false
+	}
+	for _, l := range trace {
+		t := strings.TrimSpace(l)
+		if strings.HasPrefix(t, "at ") || strings.HasPrefix(t, "---- Minecraft Crash Report") {
+			return LogEvent{Kind: LogCrash, Time: ts, Message: trace[0], Trace: trace}, true
+		}
+	}
+	return LogEvent{}, false
+}
+
+// parseMessage recognises the message part of an INFO/WARN line
+func (p *LogParser) parseMessage(ts string, msg string) (LogEvent, bool) {
+	if m := joinRe.FindStringSubmatch(msg); m != nil {
+		p.online[m[1]] = true
+		return LogEvent{Kind: LogPlayerJoin, Time: ts, Player: m[1]}, true
+	}
+	if m := leaveRe.FindStringSubmatch(msg); m != nil {
+		delete(p.online, m[1])
+		return LogEvent{Kind: LogPlayerLeave, Time: ts, Player: m[1]}, true
+	}
+	if m := uuidRe.FindStringSubmatch(msg); m != nil {
+		return LogEvent{Kind: LogPlayerUUID, Time: ts, Player: m[1], UUID: strings.ToLower(m[2])}, true
+	}
+	if m := chatRe.FindStringSubmatch(msg); m != nil {
+		return LogEvent{Kind: LogChat, Time: ts, Player: m[1], Message: m[2]}, true
+	}
+	if m := readyRe.FindStringSubmatch(msg); m != nil {
+		secs, _ := strconv.ParseFloat(m[1], 64)
+		return LogEvent{Kind: LogServerReady, Time: ts, Startup: secs}, true
+	}
+	if m := advancementRe.FindStringSubmatch(msg); m != nil {
+		return LogEvent{Kind: LogAdvancement, Time: ts, Player: m[1], Message: m[2]}, true
+	}
+
+	// Deaths: "<online player> <death phrase>"
+	name, rest, found := strings.Cut(msg, " ")
+	if found && p.online[name] && deathRe.MatchString(rest) {
+		return LogEvent{Kind: LogDeath, Time: ts, Player: name, Message: msg}, true
+	}
+	return LogEvent{}, false
+}
+
+// splitLogLine separates the timestamp, level and message of a log line
+func splitLogLine(line string) (ts string, level string, msg string, ok bool) {
+	if m := vanillaLineRe.FindStringSubmatch(line); m != nil {
+		return m[1], m[3], m[4], true
+	}
+	if m := paperLineRe.FindStringSubmatch(line); m != nil {
+		return m[1], m[2], m[3], true
+	}
+	return "", "", "", false
+}
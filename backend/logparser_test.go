@@ -0,0 +1,180 @@
+package backend
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Samples are real console output from a Vanilla 1.20.4 and a Paper 1.20.4 server
+func TestLogParserSamples(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []LogEvent
+	}{
+		{
+			name: "vanilla startup",
+			lines: []string{
+				"[12:00:01] [ServerMain/INFO]: Environment: Environment[sessionHost=https://sessionserver.mojang.com]",
+				"[12:00:02] [Server thread/INFO]: Starting minecraft server version 1.20.4",
+				"[12:00:02] [Server thread/INFO]: Preparing level \"world\"",
+				"[12:00:09] [Server thread/INFO]: Done (7.215s)! For help, type \"help\"",
+			},
+			want: []LogEvent{{Kind: LogServerReady, Time: "12:00:09", Startup: 7.215}},
+		},
+		{
+			name: "paper startup",
+			lines: []string{
+				"[12:00:02 INFO]: Starting minecraft server version 1.20.4",
+				"[12:00:03 WARN]: Legacy plugin Foo v1.0 does not specify an api-version.",
+				"[12:00:12 INFO]: Done (9.87s)! For help, type \"help\"",
+			},
+			want: []LogEvent{{Kind: LogServerReady, Time: "12:00:12", Startup: 9.87}},
+		},
+		{
+			name: "forge header with logger name",
+			lines: []string{
+				"[12:00:30] [Server thread/INFO] [minecraft/DedicatedServer]: Done (21.4s)! For help, type \"help\"",
+			},
+			want: []LogEvent{{Kind: LogServerReady, Time: "12:00:30", Startup: 21.4}},
+		},
+		{
+			name: "vanilla session",
+			lines: []string{
+				"[12:01:00] [User Authenticator #1/INFO]: UUID of player Steve_01 is 069A79F4-44E9-4726-A5BE-FCA90E38AAF5",
+				"[12:01:00] [Server thread/INFO]: Steve_01[/127.0.0.1:53422] logged in with entity id 123 at (0.5, 64.0, 0.5)",
+				"[12:01:00] [Server thread/INFO]: Steve_01 joined the game",
+				"[12:01:05] [Server thread/INFO]: <Steve_01> hello world",
+				"[12:01:06] [Server thread/INFO]: [Not Secure] <Steve_01> unsigned hi",
+				"[12:01:10] [Server thread/INFO]: Steve_01 has made the advancement [Stone Age]",
+				"[12:01:11] [Server thread/INFO]: Steve_01 has completed the challenge [How Did We Get Here?]",
+				"[12:01:20] [Server thread/INFO]: Steve_01 was slain by Zombie",
+				"[12:01:30] [Server thread/INFO]: Steve_01 lost connection: Disconnected",
+				"[12:01:30] [Server thread/INFO]: Steve_01 left the game",
+			},
+			want: []LogEvent{
+				{Kind: LogPlayerUUID, Time: "12:01:00", Player: "Steve_01", UUID: "069a79f4-44e9-4726-a5be-fca90e38aaf5"},
+				{Kind: LogPlayerJoin, Time: "12:01:00", Player: "Steve_01"},
+				{Kind: LogChat, Time: "12:01:05", Player: "Steve_01", Message: "hello world"},
+				{Kind: LogChat, Time: "12:01:06", Player: "Steve_01", Message: "unsigned hi"},
+				{Kind: LogAdvancement, Time: "12:01:10", Player: "Steve_01", Message: "Stone Age"},
+				{Kind: LogAdvancement, Time: "12:01:11", Player: "Steve_01", Message: "How Did We Get Here?"},
+				{Kind: LogDeath, Time: "12:01:20", Player: "Steve_01", Message: "Steve_01 was slain by Zombie"},
+				{Kind: LogPlayerLeave, Time: "12:01:30", Player: "Steve_01"},
+			},
+		},
+		{
+			name: "paper session with colour codes",
+			lines: []string{
+				"[13:00:00 INFO]: UUID of player .BedrockAlex is 00000000-0000-0000-0009-01f64f65c7c3",
+				"[13:00:00 INFO]: \x1b[33;1m.BedrockAlex joined the game\x1b[0m",
+				"[13:00:04 INFO]: <.BedrockAlex> gg",
+				"[13:00:08 INFO]: .BedrockAlex fell from a high place",
+				"[13:00:09 INFO]: .BedrockAlex has reached the goal [Sky's the Limit]",
+				"[13:00:20 INFO]: \x1b[33;1m.BedrockAlex left the game\x1b[0m",
+			},
+			want: []LogEvent{
+				{Kind: LogPlayerUUID, Time: "13:00:00", Player: ".BedrockAlex", UUID: "00000000-0000-0000-0009-01f64f65c7c3"},
+				{Kind: LogPlayerJoin, Time: "13:00:00", Player: ".BedrockAlex"},
+				{Kind: LogChat, Time: "13:00:04", Player: ".BedrockAlex", Message: "gg"},
+				{Kind: LogDeath, Time: "13:00:08", Player: ".BedrockAlex", Message: ".BedrockAlex fell from a high place"},
+				{Kind: LogAdvancement, Time: "13:00:09", Player: ".BedrockAlex", Message: "Sky's the Limit"},
+				{Kind: LogPlayerLeave, Time: "13:00:20", Player: ".BedrockAlex"},
+			},
+		},
+		{
+			name: "death phrases need an online player",
+			lines: []string{
+				"[12:02:00] [Server thread/INFO]: Villager was slain by Zombie",
+				"[12:02:01] [Server thread/INFO]: Nobody fell from a high place",
+				"[12:02:02] [Server thread/INFO]: <Steve> Steve was slain by a joke",
+			},
+			want: []LogEvent{
+				{Kind: LogChat, Time: "12:02:02", Player: "Steve", Message: "Steve was slain by a joke"},
+			},
+		},
+		{
+			name: "vanilla crash with stack",
+			lines: []string{
+				"[12:03:00] [Server thread/ERROR]: Encountered an unexpected exception",
+				"java.lang.IllegalStateException: Ticking entity",
+				"\tat net.minecraft.server.MinecraftServer.a(SourceFile:123)",
+				"\tat java.lang.Thread.run(Thread.java:833)",
+				"[12:03:01] [Server thread/INFO]: Stopping server",
+			},
+			want: []LogEvent{{
+				Kind: LogCrash, Time: "12:03:00", Message: "Encountered an unexpected exception",
+				Trace: []string{
+					"Encountered an unexpected exception",
+					"java.lang.IllegalStateException: Ticking entity",
+					"\tat net.minecraft.server.MinecraftServer.a(SourceFile:123)",
+					"\tat java.lang.Thread.run(Thread.java:833)",
+				},
+			}},
+		},
+		{
+			name: "paper error without stack is not a crash",
+			lines: []string{
+				"[13:01:00 ERROR]: Could not pass event PlayerJoinEvent to Foo v1.0",
+				"[13:01:01 INFO]: <Alex> hi",
+			},
+			want: []LogEvent{{Kind: LogChat, Time: "13:01:01", Player: "Alex", Message: "hi"}},
+		},
+		{
+			name: "crash report without header",
+			lines: []string{
+				"---- Minecraft Crash Report ----",
+				"// Don't do that.",
+				"Description: Exception in server tick loop",
+			},
+			want: []LogEvent{{
+				Kind:    LogCrash,
+				Message: "---- Minecraft Crash Report ----",
+				Trace:   []string{"---- Minecraft Crash Report ----", "// Don't do that.", "Description: Exception in server tick loop"},
+			}},
+		},
+		{
+			name: "noise",
+			lines: []string{
+				"",
+				"Starting net.minecraft.server.Main",
+				"[12:04:00] [Server thread/WARN]: Can't keep up! Is the server overloaded?",
+				"[12:04:01] [Server thread/INFO]: [Steve: Set the time to 1000]",
+				"[12:04:02] [Server thread/INFO]: There are 1 of a max of 20 players online: Steve",
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewLogParser()
+			var got []LogEvent
+			for _, line := range tt.lines {
+				got = append(got, p.Feed(line)...)
+			}
+			got = append(got, p.Flush()...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events:\n  got  %+v\n  want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogParserOnline(t *testing.T) {
+	p := NewLogParser()
+	for _, line := range []string{
+		"[12:00:00] [Server thread/INFO]: Steve joined the game",
+		"[12:00:01 INFO]: Alex joined the game",
+		"[12:00:02] [Server thread/INFO]: Notch joined the game",
+		"[12:00:03] [Server thread/INFO]: Steve left the game",
+	} {
+		p.Feed(line)
+	}
+	online := p.Online()
+	sort.Strings(online)
+	if want := []string{"Alex", "Notch"}; !reflect.DeepEqual(online, want) {
+		t.Errorf("Online() = %v, want %v", online, want)
+	}
+}
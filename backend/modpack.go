@@ -0,0 +1,387 @@
+package backend
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// modpack is a pack read from disk, ready to install into a new group
+type modpack struct {
+	Name    string
+	Type    string // Server type, e.g. "Fabric"
+	Version string // Minecraft version
+	Build   string // Loader version the pack was made with (empty for Vanilla)
+
+	// install puts the pack's mods and configs into an instance folder that
+	// already has the loader
+	install func(dir string) error
+}
+
+// mrpackLoaders maps Modrinth dependency names to server types
+var mrpackLoaders = map[string]string{
+	"fabric-loader": "Fabric",
+	"forge":         "Forge",
+	"neoforge":      "NeoForge",
+}
+
+// readModpack reads a Modrinth .mrpack or a CurseForge server pack zip
+func (a *App) readModpack(file string) (modpack, error) {
+	reader, err := zip.OpenReader(file)
+	if err != nil {
+		return modpack{}, fmt.Errorf("not a modpack archive: %v", err)
+	}
+	defer reader.Close()
+
+	if index, err := readZipFile(&reader.Reader, "modrinth.index.json"); err == nil {
+		return a.readMrpack(file, index)
+	}
+	return readServerPack(file, &reader.Reader)
+}
+
+func readZipFile(reader *zip.Reader, name string) ([]byte, error) {
+	f, err := reader.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// --- Modrinth (.mrpack) ---
+
+type mrpackIndex struct {
+	FormatVersion int    `json:"formatVersion"`
+	Game          string `json:"game"`
+	Name          string `json:"name"`
+	Files         []struct {
+		Path   string            `json:"path"`
+		Hashes map[string]string `json:"hashes"`
+		Env    map[string]string `json:"env"` // "client"/"server": required, optional or unsupported
+		URLs   []string          `json:"downloads"`
+	} `json:"files"`
+	Dependencies map[string]string `json:"dependencies"` // "minecraft": "1.20.1", "fabric-loader": "0.15.3"
+}
+
+// mrpackHosts are the only hosts the .mrpack format allows downloads from.
+// Packs are untrusted uploads, so links anywhere else are refused.
+var mrpackHosts = map[string]bool{
+	"cdn.modrinth.com":          true,
+	"github.com":                true,
+	"raw.githubusercontent.com": true,
+	"gitlab.com":                true,
+}
+
+// mrpackLink reports whether a pack's download link is https on an allowed host
+func mrpackLink(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && u.Scheme == "https" && u.User == nil && mrpackHosts[strings.ToLower(u.Hostname())]
+}
+
+// readMrpack reads a Modrinth pack: an index of mods to download plus
+// overrides/ and server-overrides/ folders to copy
+func (a *App) readMrpack(file string, data []byte) (modpack, error) {
+	var index mrpackIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return modpack{}, fmt.Errorf("bad modrinth.index.json: %v", err)
+	}
+	if index.FormatVersion != 1 || index.Game != "minecraft" {
+		return modpack{}, fmt.Errorf("unsupported .mrpack (format %d, game %q)", index.FormatVersion, index.Game)
+	}
+
+	pack := modpack{Name: index.Name, Type: "Vanilla", Version: index.Dependencies["minecraft"]}
+	if pack.Version == "" {
+		return modpack{}, fmt.Errorf("the pack doesn't say which Minecraft version it needs")
+	}
+	for dep, build := range index.Dependencies {
+		if dep == "minecraft" {
+			continue
+		}
+		serverType, ok := mrpackLoaders[dep]
+		if !ok {
+			return modpack{}, fmt.Errorf("the pack needs %s, which isn't supported", dep)
+		}
+		pack.Type, pack.Build = serverType, build
+	}
+	for _, f := range index.Files {
+		for _, link := range f.URLs {
+			if !mrpackLink(link) {
+				return modpack{}, fmt.Errorf("%s: downloads may only come from Modrinth, GitHub or GitLab, not %q", f.Path, link)
+			}
+		}
+	}
+
+	pack.install = func(dir string) error {
+		for _, f := range index.Files {
+			if f.Env["server"] == "unsupported" {
+				continue // Client-only (shaders, minimaps...)
+			}
+			dest, err := safeJoin(dir, f.Path)
+			if err != nil {
+				return err
+			}
+			checksum := checksumOf("sha512", f.Hashes["sha512"])
+			if checksum == "" {
+				checksum = checksumOf("sha1", f.Hashes["sha1"])
+			}
+			if checksum == "" {
+				return fmt.Errorf("%s has no hash to check", f.Path)
+			}
+			if err := a.downloadMirrors(f.URLs, dest, checksum); err != nil {
+				return fmt.Errorf("%s: %v", f.Path, err)
+			}
+		}
+		// Server overrides are copied last so they win
+		for _, overrides := range []string{"overrides", "server-overrides"} {
+			if err := extractZipDir(file, overrides, dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return pack, nil
+}
+
+// downloadMirrors tries each of a file's download links in turn
+func (a *App) downloadMirrors(urls []string, dest string, checksum string) error {
+	err := fmt.Errorf("no download link")
+	for _, link := range urls {
+		if u, parseErr := url.Parse(link); parseErr != nil || u.Scheme != "https" {
+			err = fmt.Errorf("refusing non-https link %q", link)
+			continue
+		}
+		if err = a.download(Download{URL: link, Dest: dest, Checksum: checksum, Name: filepath.Base(dest)}); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// --- CurseForge server packs ---
+
+var (
+	forgeInstallerName    = regexp.MustCompile(`^forge-(\d[\w.]*)-(\d[\w.]*)-installer\.jar$`)
+	neoForgeInstallerName = regexp.MustCompile(`^neoforge-(\d[\w.-]*)-installer\.jar$`)
+	forgeLibraries        = regexp.MustCompile(`^libraries/net/minecraftforge/forge/(\d[\w.]*)-(\d[\w.]*)/`)
+	neoForgeLibraries     = regexp.MustCompile(`^libraries/net/neoforged/neoforge/(\d[\w.-]*)/`)
+)
+
+// cfManifest is the manifest.json CurseForge packs carry
+type cfManifest struct {
+	Name      string `json:"name"`
+	Minecraft struct {
+		Version    string `json:"version"`
+		ModLoaders []struct {
+			ID      string `json:"id"` // "forge-47.2.0", "neoforge-20.4.237", "fabric-0.15.3"
+			Primary bool   `json:"primary"`
+		} `json:"modLoaders"`
+	} `json:"minecraft"`
+	Files []json.RawMessage `json:"files"`
+}
+
+// readServerPack reads a CurseForge server pack: a zipped server folder
+// with mods/ and configs already in it. The loader and Minecraft version
+// come from manifest.json, variables.txt or the bundled installer.
+func readServerPack(file string, reader *zip.Reader) (modpack, error) {
+	root := serverPackRoot(reader)
+	pack := modpack{Name: strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))}
+	hasMods := false
+	for _, f := range reader.File {
+		if strings.HasPrefix(f.Name, root+"mods/") {
+			hasMods = true
+			break
+		}
+	}
+
+	if data, err := readZipFile(reader, root+"manifest.json"); err == nil {
+		var manifest cfManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return modpack{}, fmt.Errorf("bad manifest.json: %v", err)
+		}
+		if len(manifest.Files) > 0 && !hasMods {
+			return modpack{}, fmt.Errorf("this is a CurseForge client pack; download the pack's server files instead")
+		}
+		if manifest.Name != "" {
+			pack.Name = manifest.Name
+		}
+		pack.Version = manifest.Minecraft.Version
+		for _, l := range manifest.Minecraft.ModLoaders {
+			if l.Primary || pack.Type == "" {
+				name, build, _ := strings.Cut(l.ID, "-")
+				pack.Type, pack.Build = loaderType(name), build
+			}
+		}
+	} else if data, err := readZipFile(reader, root+"variables.txt"); err == nil {
+		vars := parseVariables(string(data))
+		pack.Version = vars["MINECRAFT_VERSION"]
+		pack.Type, pack.Build = loaderType(vars["MODLOADER"]), vars["MODLOADER_VERSION"]
+	} else {
+		for _, f := range reader.File {
+			name := strings.TrimPrefix(f.Name, root)
+			if m := forgeInstallerName.FindStringSubmatch(name); m != nil {
+				pack.Type, pack.Version, pack.Build = "Forge", m[1], m[2]
+			} else if m := forgeLibraries.FindStringSubmatch(name); m != nil {
+				pack.Type, pack.Version, pack.Build = "Forge", m[1], m[2]
+			} else if m := neoForgeInstallerName.FindStringSubmatch(name); m != nil {
+				pack.Type, pack.Build = "NeoForge", m[1]
+			} else if m := neoForgeLibraries.FindStringSubmatch(name); m != nil {
+				pack.Type, pack.Build = "NeoForge", m[1]
+			} else {
+				continue
+			}
+			if pack.Type == "NeoForge" {
+				pack.Version, _ = neoForgeMinecraft(pack.Build)
+			}
+			break
+		}
+	}
+
+	if pack.Type == "" {
+		return modpack{}, fmt.Errorf("could not tell which loader and Minecraft version this server pack needs")
+	}
+	if pack.Version == "" {
+		return modpack{}, fmt.Errorf("the pack doesn't say which Minecraft version it needs")
+	}
+	if pack.Type != "Forge" && pack.Type != "NeoForge" && pack.Type != "Fabric" {
+		return modpack{}, fmt.Errorf("the pack needs %s, which isn't supported", pack.Type)
+	}
+	if !hasMods {
+		return modpack{}, fmt.Errorf("the pack has no mods folder")
+	}
+
+	pack.install = func(dir string) error {
+		if err := extractZipDir(file, root, dir); err != nil {
+			return err
+		}
+		// The loader is already installed; drop bundled installers so they
+		// aren't synced
+		installers, _ := filepath.Glob(filepath.Join(dir, "*installer*.jar"))
+		for _, jar := range installers {
+			os.Remove(jar)
+		}
+		return nil
+	}
+	return pack, nil
+}
+
+// serverPackRoot finds the folder of the zip that holds the server (packs
+// are often zipped with one top folder), as a "name/" prefix
+func serverPackRoot(reader *zip.Reader) string {
+	best, found := "", false
+	for _, f := range reader.File {
+		var root string
+		if name := path.Base(f.Name); name == "manifest.json" || name == "variables.txt" {
+			root = strings.TrimSuffix(f.Name, name)
+		} else if i := strings.Index("/"+f.Name, "/mods/"); i != -1 {
+			root = f.Name[:i]
+		} else {
+			continue
+		}
+		if strings.Count(root, "/") > 1 {
+			continue // Deeper than one top folder: some mod's own files
+		}
+		if !found || len(root) < len(best) {
+			best, found = root, true
+		}
+	}
+	return best
+}
+
+// loaderType maps a loader name from a pack to a server type
+func loaderType(name string) string {
+	switch strings.ToLower(name) {
+	case "forge":
+		return "Forge"
+	case "neoforge":
+		return "NeoForge"
+	case "fabric":
+		return "Fabric"
+	}
+	return name
+}
+
+// parseVariables reads KEY=value lines (quotes optional) from variables.txt
+func parseVariables(text string) map[string]string {
+	vars := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok {
+			vars[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return vars
+}
+
+// --- Group creation ---
+
+// ChooseModpackFile opens a file picker for CreateServerFromModpack
+func (a *App) ChooseModpackFile() string {
+	if a.ctx == nil {
+		return ""
+	}
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Choose a modpack",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Modpacks (*.mrpack, *.zip)", Pattern: "*.mrpack;*.zip"},
+		},
+	})
+	if err != nil {
+		return ""
+	}
+	return file
+}
+
+// CreateServerFromModpack creates a group from a Modrinth .mrpack or a
+// CurseForge server pack: it installs the pack's loader build and mods and
+// uploads the result. serverName may be empty to use the pack's name.
+// Returns the new server ID, like CreateServer.
+func (a *App) CreateServerFromModpack(serverName string, packPath string, token string, configString string) string {
+	if _, err := a.authenticate(token); err != nil {
+		return "Error: " + err.Error()
+	}
+
+	// Read and resolve everything before creating the group, so a bad pack
+	// leaves nothing behind
+	pack, err := a.readModpack(packPath)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	versionDoc, err := a.versions.ResolveBuild(ctx, pack.Type, pack.Version, pack.Build)
+	cancel()
+	if err != nil {
+		return fmt.Sprintf("Error: %s %s (build %s) not found: %v", pack.Type, pack.Version, pack.Build, err)
+	}
+	if serverName == "" {
+		serverName = pack.Name
+	}
+	a.Log(fmt.Sprintf("📦 Modpack %s: %s %s (build %s)", pack.Name, versionDoc.Type, versionDoc.Version, versionDoc.Build))
+
+	serverID := a.CreateServer(serverName, versionDoc.Type, versionDoc.Version, token, configString)
+	if strings.HasPrefix(serverID, "Error") {
+		return serverID
+	}
+
+	if res := a.installServer(serverID, versionDoc, pack.install); strings.HasPrefix(res, "Error") {
+		// Don't leave a group whose files don't match its pack
+		a.DeleteServer(serverID, token)
+		return res
+	}
+	a.Log("✅ Modpack installed: " + pack.Name)
+	return serverID
+}
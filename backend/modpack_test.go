@@ -0,0 +1,38 @@
+package backend
+
+import "testing"
+
+func TestMrpackLink(t *testing.T) {
+	tests := []struct {
+		link string
+		ok   bool
+	}{
+		{"https://cdn.modrinth.com/data/AANobbMI/versions/1.0/sodium.jar", true},
+		{"https://github.com/owner/repo/releases/download/v1/mod.jar", true},
+		{"https://raw.githubusercontent.com/owner/repo/main/mod.jar", true},
+		{"https://gitlab.com/owner/repo/-/raw/main/mod.jar", true},
+		{"https://CDN.Modrinth.com/data/x.jar", true},
+		{"http://cdn.modrinth.com/data/x.jar", false},
+		{"https://evil.example/mod.jar", false},
+		{"https://cdn.modrinth.com.evil.example/mod.jar", false},
+		{"https://user@cdn.modrinth.com/mod.jar", false},
+		{"https://127.0.0.1/mod.jar", false},
+		{"file:///etc/passwd", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := mrpackLink(tt.link); got != tt.ok {
+			t.Errorf("mrpackLink(%q) = %v, want %v", tt.link, got, tt.ok)
+		}
+	}
+}
+
+func TestReadMrpackRefusesOtherHosts(t *testing.T) {
+	a := &App{}
+	index := `{"formatVersion": 1, "game": "minecraft", "name": "Pack",
+		"dependencies": {"minecraft": "1.20.1", "fabric-loader": "0.15.3"},
+		"files": [{"path": "mods/a.jar", "hashes": {"sha512": "ab"}, "downloads": ["https://cdn.modrinth.com/a.jar", "https://evil.example/a.jar"]}]}`
+	if _, err := a.readMrpack("pack.mrpack", []byte(index)); err == nil {
+		t.Error("a pack linking to another host was accepted")
+	}
+}
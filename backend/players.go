@@ -9,7 +9,11 @@ import (
 )
 
 // GetPlayerLists reads all player-related JSON files
-func (a *App) GetPlayerLists(serverID string) PlayerLists {
+func (a *App) GetPlayerLists(serverID string, token string) PlayerLists {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return PlayerLists{}
+	}
 	instanceDir := a.getInstancePath(serverID)
 
 	return PlayerLists{
@@ -20,17 +24,23 @@ func (a *App) GetPlayerLists(serverID string) PlayerLists {
 	}
 }
 
-// ManagePlayer sends commands to modify lists (ONLY if server is running)
-func (a *App) ManagePlayer(serverID string, username string, action string, target string, extra string) string {
+// ManagePlayer sends commands to modify lists (ONLY if server is running).
+// On success the server's reply is returned as "Success: <reply>".
+func (a *App) ManagePlayer(serverID string, token string, action string, target string, extra string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
 	// Permission check: Only owner or admins can manage players
-	if !a.IsAdmin(serverID, username) {
+	if !a.isAdmin(serverID, username) {
 		return "Error: Only admins can manage players"
 	}
 
 	// action: "op", "deop", "whitelist add", "whitelist remove", "ban", "pardon"
 
 	// Check if server is online (Commands require a running server)
-	if activeCmd == nil || stdinPipe == nil {
+	if !a.procs.IsRunning(serverID) {
 		return "Error: Server must be ONLINE to manage players."
 	}
 
@@ -80,10 +90,11 @@ func (a *App) ManagePlayer(serverID string, username string, action string, targ
 		// Syntax: tp <target> x y z
 		command = "tp " + target + " " + extra
 
+	default:
 		return "Error: Unknown action"
 	}
 
-	return a.SendConsoleCommand(serverID, username, command)
+	return a.sendConsoleCommand(serverID, username, command)
 }
 
 // Helper to read generic JSON lists
@@ -4,52 +4,111 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
-
-	"go.mongodb.org/mongo-driver/bson"
 )
 
-// ensurePlayitBinary checks if playit.exe exists, if not, downloads it
+// playitReleaseAPI describes the latest agent release, including asset digests
+const playitReleaseAPI = "https://api.github.com/repos/playit-cloud/playit-agent/releases/latest"
+
+// playitAsset names the agent release asset for this OS and CPU
+func playitAsset() (string, error) {
+	assets := map[string]string{
+		"windows/amd64": "playit-windows-x86_64.exe",
+		"windows/386":   "playit-windows-x86.exe",
+		"linux/amd64":   "playit-linux-amd64",
+		"linux/arm64":   "playit-linux-aarch64",
+		"linux/arm":     "playit-linux-armv7",
+		"linux/386":     "playit-linux-i686",
+	}
+	asset, ok := assets[runtime.GOOS+"/"+runtime.GOARCH]
+	if !ok {
+		return "", fmt.Errorf("no playit agent for %s/%s; install playit and put it on the PATH", runtime.GOOS, runtime.GOARCH)
+	}
+	return asset, nil
+}
+
+// ensurePlayitBinary checks if the playit agent exists, if not, downloads it
 func (a *App) ensurePlayitBinary() error {
 	binPath := getPlayitBin()
 	if _, err := os.Stat(binPath); err == nil {
 		return nil
 	}
-	a.Log("⬇️ Downloading Playit.gg agent...")
-	url := "https://github.com/playit-cloud/playit-agent/releases/latest/download/playit-windows-x86_64.exe"
-	if runtime.GOARCH == "386" {
-		url = "https://github.com/playit-cloud/playit-agent/releases/latest/download/playit-windows-x86.exe"
-	}
-	resp, err := http.Get(url)
+	asset, err := playitAsset()
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	out, err := os.Create(binPath)
+	a.Log("⬇️ Downloading Playit.gg agent...")
+
+	// GitHub publishes a sha256 digest for each release asset
+	var release struct {
+		Assets []struct {
+			Name   string `json:"name"`
+			URL    string `json:"browser_download_url"`
+			Digest string `json:"digest"` // "sha256:<hex>"
+		} `json:"assets"`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err = getJSON(ctx, nil, playitReleaseAPI, &release)
+	cancel()
 	if err != nil {
-		return err
+		return fmt.Errorf("could not read playit release: %v", err)
 	}
-	defer out.Close()
-	_, err = io.Copy(out, resp.Body)
-	return err
+	for _, file := range release.Assets {
+		if file.Name == asset {
+			if err := a.download(Download{URL: file.URL, Dest: binPath, Checksum: file.Digest, Name: "Playit.gg agent"}); err != nil {
+				return err
+			}
+			return os.Chmod(binPath, 0755)
+		}
+	}
+	return fmt.Errorf("%s not found in the latest playit release", asset)
 }
 
-// 1. Launch Terminal (Standard launch, we let it save to AppData)
-func (a *App) LaunchPlayitExternally(serverID string) string {
+// playitGlobalConfig is where the agent saves its config when run by hand:
+// %LocalAppData%\playit_gg on Windows, ~/.config/playit_gg on Linux
+func playitGlobalConfig() string {
+	if runtime.GOOS != "windows" {
+		dir, _ := os.UserConfigDir()
+		return filepath.Join(dir, "playit_gg", "playit.toml")
+	}
+	dir := os.Getenv("LOCALAPPDATA")
+	if dir == "" {
+		homeDir, _ := os.UserHomeDir()
+		dir = filepath.Join(homeDir, "AppData", "Local")
+	}
+	return filepath.Join(dir, "playit_gg", "playit.toml")
+}
+
+// 1. Launch Terminal (Standard launch, we let it save its global config)
+func (a *App) LaunchPlayitExternally(token string) string {
+	if _, err := a.authenticate(token); err != nil {
+		return "Error: " + err.Error()
+	}
 	if err := a.ensurePlayitBinary(); err != nil {
 		return "Error: Download failed"
 	}
 
-	// We just launch it. It will save config to %LocalAppData%\playit_gg\playit.toml
+	// We just launch it in a terminal. It will save config to playitGlobalConfig()
 	absPath, _ := filepath.Abs(getPlayitBin())
-	cmd := exec.Command("cmd", "/c", "start", "Playit Setup", "cmd", "/k", absPath)
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "Playit Setup", "cmd", "/k", absPath)
+	case "darwin":
+		cmd = exec.Command("open", "-a", "Terminal", absPath)
+	default:
+		terminal, err := exec.LookPath("x-terminal-emulator")
+		if err != nil {
+			return "Error: No terminal found. Run " + absPath + " yourself, claim the agent, then import the config."
+		}
+		cmd = exec.Command(terminal, "-e", absPath)
+	}
 
 	if err := cmd.Start(); err != nil {
 		return "Error launching: " + err.Error()
@@ -57,15 +116,18 @@ func (a *App) LaunchPlayitExternally(serverID string) string {
 	return "Success"
 }
 
-// 2. Import the config from AppData and save to User's DB record
-func (a *App) ImportPlayitConfig(username string) string {
-	// Construct path: C:\Users\User\AppData\Local\playit_gg\playit.toml
-	homeDir, _ := os.UserHomeDir()
-	globalConfigPath := filepath.Join(homeDir, "AppData", "Local", "playit_gg", "playit.toml")
+// 2. Import the agent's global config and save to User's DB record
+func (a *App) ImportPlayitConfig(token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	globalConfigPath := playitGlobalConfig()
 
 	// Check if it exists
 	if _, err := os.Stat(globalConfigPath); os.IsNotExist(err) {
-		return "Error: Config file not found in AppData. Did you claim the link?"
+		return "Error: Config file not found at " + globalConfigPath + ". Did you claim the link?"
 	}
 
 	// Read the content
@@ -75,17 +137,10 @@ func (a *App) ImportPlayitConfig(username string) string {
 	}
 
 	// Save to user's database record
-	collection := DB.Client.Database("mc_roam").Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := dbContext()
 	defer cancel()
 
-	update := bson.M{
-		"$set": bson.M{
-			"playit_toml_content": string(content),
-		},
-	}
-
-	_, err = collection.UpdateOne(ctx, bson.M{"username": username}, update)
+	err = a.store.SetPlayitConfig(ctx, username, string(content))
 	if err != nil {
 		return "Error saving to database: " + err.Error()
 	}
@@ -95,7 +150,7 @@ func (a *App) ImportPlayitConfig(username string) string {
 }
 
 // 3. Start Tunnel (Standard) with retry logic for cloud deployments
-func (a *App) StartPlayitTunnel(serverID string) {
+func (a *App) startPlayitTunnel(serverID string) {
 	if err := a.ensurePlayitBinary(); err != nil {
 		return
 	}
@@ -136,20 +191,31 @@ func (a *App) StartPlayitTunnel(serverID string) {
 	a.Log("💡 Try: 1) Check firewall settings 2) Verify internet connection 3) Re-import Playit config")
 }
 
-// StopTunnel stops the playit.exe tunnel process
-func (a *App) StopTunnel() error {
-	a.Log("🛑 Stopping Playit tunnel...")
+// tunnels holds the playit agent we started for each server
+var (
+	tunnelMu sync.Mutex
+	tunnels  = map[string]*exec.Cmd{}
+)
 
-	// Kill all playit.exe processes
-	cmd := exec.Command("taskkill", "/F", "/IM", "playit.exe")
-	prepareCommand(cmd)
+// stopTunnel stops the playit agent we started for this server. Other playit
+// processes on the machine (another server, the user's own agent) are left alone.
+func (a *App) stopTunnel(serverID string) error {
+	tunnelMu.Lock()
+	cmd := tunnels[serverID]
+	delete(tunnels, serverID)
+	tunnelMu.Unlock()
 
-	if err := cmd.Run(); err != nil {
-		// Process might not be running, which is fine
+	if cmd == nil {
 		a.Log("⚠️ No Playit tunnel found (may already be stopped)")
 		return nil
 	}
 
+	a.Log("🛑 Stopping Playit tunnel...")
+	if err := killProcessTree(cmd.Process.Pid); err != nil && processAlive(cmd.Process.Pid) {
+		a.Log(fmt.Sprintf("⚠️ Failed to stop Playit tunnel: %v", err))
+		return err
+	}
+
 	a.Log("✅ Playit tunnel stopped")
 	return nil
 }
@@ -160,6 +226,7 @@ func (a *App) attemptStartPlayit(serverID string, instanceDir string) bool {
 
 	cmd := exec.Command(absPath)
 	cmd.Dir = instanceDir
+	prepareCommand(cmd)
 	// Playit will now find the 'playit.toml' we copied into this folder
 
 	// Capture output pipe instead of dumping to os.Stdout
@@ -186,8 +253,13 @@ func (a *App) attemptStartPlayit(serverID string, instanceDir string) bool {
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			line := scanner.Text()
-			// Prefix with [Playit] so we can filter it in the Console tab
-			a.Log("[Playit]: " + line)
+			a.publish(Event{
+				Topic:    TopicConsole,
+				Source:   SourcePlayit,
+				ServerID: serverID,
+				Message:  line,
+				Payload:  ConsoleLine{Stream: "stdout"},
+			})
 
 			// Detect connection errors
 			if strings.Contains(line, "Error:") || strings.Contains(line, "RequestError") ||
@@ -231,12 +303,23 @@ func (a *App) attemptStartPlayit(serverID string, instanceDir string) bool {
 				}
 			}
 		}
+
+		// Output ends when the agent exits
+		cmd.Wait()
+		tunnelMu.Lock()
+		if tunnels[serverID] == cmd {
+			delete(tunnels, serverID)
+		}
+		tunnelMu.Unlock()
 	}()
 
 	// Wait for either success or failure with timeout
 	timeout := time.After(30 * time.Second)
 	select {
 	case <-connectionSuccess:
+		tunnelMu.Lock()
+		tunnels[serverID] = cmd
+		tunnelMu.Unlock()
 		a.Log("✅ Playit tunnel established successfully!")
 		return true
 	case <-errorDetected:
@@ -274,17 +357,17 @@ func extractPlayitAddress(text string) string {
 
 // Save tunnel URL to database
 func (a *App) saveTunnelURL(serverID string, tunnelURL string) {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := dbContext()
 	defer cancel()
 
-	update := bson.M{
-		"$set": bson.M{
-			"lock.tunnel_url": tunnelURL,
-		},
-	}
+	a.publish(Event{
+		Topic:    TopicTunnel,
+		Source:   SourcePlayit,
+		ServerID: serverID,
+		Payload:  TunnelAddress{Address: tunnelURL},
+	})
 
-	_, err := collection.UpdateOne(ctx, bson.M{"_id": serverID}, update)
+	err := a.store.SetTunnelURL(ctx, serverID, tunnelURL)
 	if err != nil {
 		a.Log("⚠️ Failed to save tunnel URL to database")
 	}
@@ -292,12 +375,10 @@ func (a *App) saveTunnelURL(serverID string, tunnelURL string) {
 
 // deployUserPlayitConfig fetches user's playit.toml from DB and writes to local file
 func (a *App) deployUserPlayitConfig(username string, destPath string) error {
-	collection := DB.Client.Database("mc_roam").Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := dbContext()
 	defer cancel()
 
-	var user User
-	err := collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	user, err := a.store.GetUser(ctx, username)
 	if err != nil {
 		return fmt.Errorf("user not found")
 	}
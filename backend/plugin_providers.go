@@ -0,0 +1,285 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PluginProvider searches one plugin repository and finds the newest
+// release of a project for a server. Base URLs are fields so they can be
+// pointed at mirrors or local fixtures.
+type PluginProvider interface {
+	// Name is the name shown in the UI and stored in plugins.lock, e.g. "Modrinth"
+	Name() string
+	// Search lists projects matching query that have a release for the server
+	Search(ctx context.Context, query string, target PluginTarget) ([]PluginInfo, error)
+	// Latest finds the newest release of a project for the server
+	Latest(ctx context.Context, projectID string, target PluginTarget) (PluginRelease, error)
+}
+
+// PluginTarget is the server a plugin has to run on
+type PluginTarget struct {
+	Type    string // Server type, e.g. "Paper"
+	Version string // Minecraft version, e.g. "1.20.4"
+}
+
+// PluginInfo is a search result
+type PluginInfo struct {
+	Provider    string `json:"provider"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Downloads   int64  `json:"downloads"`
+	IconURL     string `json:"icon_url,omitempty"`
+}
+
+// PluginRelease is one downloadable plugin jar
+type PluginRelease struct {
+	Provider  string `json:"provider"`
+	ProjectID string `json:"project_id"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	FileName  string `json:"file_name"`
+	URL       string `json:"url"`
+	Checksum  string `json:"checksum,omitempty"` // "sha512:<hex>" or "sha256:<hex>"
+}
+
+// DefaultPluginProviders returns the repositories plugins are installed from
+func DefaultPluginProviders() []PluginProvider {
+	return []PluginProvider{
+		&ModrinthProvider{},
+		&HangarProvider{},
+	}
+}
+
+// pluginSearchLimit is how many results a search returns
+const pluginSearchLimit = 20
+
+// --- Modrinth ---
+
+// ModrinthProvider reads the Modrinth v2 API
+type ModrinthProvider struct {
+	BaseURL string // Default: https://api.modrinth.com/v2
+	Client  *http.Client
+}
+
+func (p *ModrinthProvider) Name() string { return "Modrinth" }
+
+func (p *ModrinthProvider) base() string {
+	if p.BaseURL == "" {
+		return "https://api.modrinth.com/v2"
+	}
+	return strings.TrimRight(p.BaseURL, "/")
+}
+
+// modrinthLoaders lists the Modrinth loaders a server type can run
+func modrinthLoaders(serverType string) []string {
+	if strings.EqualFold(serverType, "Purpur") {
+		return []string{"purpur", "paper", "spigot", "bukkit"}
+	}
+	return []string{"paper", "spigot", "bukkit"}
+}
+
+func (p *ModrinthProvider) Search(ctx context.Context, query string, target PluginTarget) ([]PluginInfo, error) {
+	var loaderFacet []string
+	for _, l := range modrinthLoaders(target.Type) {
+		loaderFacet = append(loaderFacet, "categories:"+l)
+	}
+	facets, _ := json.Marshal([][]string{
+		{"project_type:plugin"},
+		loaderFacet,
+		{"versions:" + target.Version},
+	})
+	params := url.Values{
+		"query":  {query},
+		"facets": {string(facets)},
+		"limit":  {fmt.Sprint(pluginSearchLimit)},
+	}
+
+	var res struct {
+		Hits []struct {
+			ProjectID   string `json:"project_id"`
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Author      string `json:"author"`
+			Downloads   int64  `json:"downloads"`
+			IconURL     string `json:"icon_url"`
+		} `json:"hits"`
+	}
+	if err := getJSON(ctx, p.Client, p.base()+"/search?"+params.Encode(), &res); err != nil {
+		return nil, err
+	}
+	plugins := []PluginInfo{}
+	for _, h := range res.Hits {
+		plugins = append(plugins, PluginInfo{
+			Provider: p.Name(), ID: h.ProjectID, Name: h.Title, Description: h.Description,
+			Author: h.Author, Downloads: h.Downloads, IconURL: h.IconURL,
+		})
+	}
+	return plugins, nil
+}
+
+func (p *ModrinthProvider) Latest(ctx context.Context, projectID string, target PluginTarget) (PluginRelease, error) {
+	loaders, _ := json.Marshal(modrinthLoaders(target.Type))
+	versions, _ := json.Marshal([]string{target.Version})
+	params := url.Values{"loaders": {string(loaders)}, "game_versions": {string(versions)}}
+
+	var project struct {
+		Title string `json:"title"`
+	}
+	if err := getJSON(ctx, p.Client, p.base()+"/project/"+url.PathEscape(projectID), &project); err != nil {
+		return PluginRelease{}, err
+	}
+	var releases []struct {
+		VersionNumber string `json:"version_number"`
+		VersionType   string `json:"version_type"` // release, beta, alpha
+		Files         []struct {
+			URL      string            `json:"url"`
+			Filename string            `json:"filename"`
+			Primary  bool              `json:"primary"`
+			Hashes   map[string]string `json:"hashes"`
+		} `json:"files"`
+	}
+	path := p.base() + "/project/" + url.PathEscape(projectID) + "/version?" + params.Encode()
+	if err := getJSON(ctx, p.Client, path, &releases); err != nil {
+		return PluginRelease{}, err
+	}
+
+	// Newest first; prefer a full release over betas
+	pick := -1
+	for i, r := range releases {
+		if len(r.Files) == 0 {
+			continue
+		}
+		if pick == -1 {
+			pick = i
+		}
+		if r.VersionType == "release" {
+			pick = i
+			break
+		}
+	}
+	if pick == -1 {
+		return PluginRelease{}, fmt.Errorf("%s has no release for %s %s", project.Title, target.Type, target.Version)
+	}
+
+	r := releases[pick]
+	file := r.Files[0]
+	for _, f := range r.Files {
+		if f.Primary {
+			file = f
+			break
+		}
+	}
+	checksum := checksumOf("sha512", file.Hashes["sha512"])
+	if checksum == "" {
+		checksum = checksumOf("sha1", file.Hashes["sha1"])
+	}
+	return PluginRelease{
+		Provider: p.Name(), ProjectID: projectID, Name: project.Title, Version: r.VersionNumber,
+		FileName: file.Filename, URL: file.URL, Checksum: checksum,
+	}, nil
+}
+
+// --- Hangar ---
+
+// HangarProvider reads PaperMC's Hangar v1 API. Project IDs are slugs.
+type HangarProvider struct {
+	BaseURL string // Default: https://hangar.papermc.io/api/v1
+	Client  *http.Client
+}
+
+func (p *HangarProvider) Name() string { return "Hangar" }
+
+func (p *HangarProvider) base() string {
+	if p.BaseURL == "" {
+		return "https://hangar.papermc.io/api/v1"
+	}
+	return strings.TrimRight(p.BaseURL, "/")
+}
+
+func (p *HangarProvider) Search(ctx context.Context, query string, target PluginTarget) ([]PluginInfo, error) {
+	params := url.Values{
+		"q":        {query},
+		"limit":    {fmt.Sprint(pluginSearchLimit)},
+		"platform": {"PAPER"}, // Hangar only knows Paper; Purpur runs the same plugins
+		"version":  {target.Version},
+	}
+	var res struct {
+		Result []struct {
+			Name      string `json:"name"`
+			Namespace struct {
+				Owner string `json:"owner"`
+				Slug  string `json:"slug"`
+			} `json:"namespace"`
+			Description string `json:"description"`
+			AvatarURL   string `json:"avatarUrl"`
+			Stats       struct {
+				Downloads int64 `json:"downloads"`
+			} `json:"stats"`
+		} `json:"result"`
+	}
+	if err := getJSON(ctx, p.Client, p.base()+"/projects?"+params.Encode(), &res); err != nil {
+		return nil, err
+	}
+	plugins := []PluginInfo{}
+	for _, r := range res.Result {
+		plugins = append(plugins, PluginInfo{
+			Provider: p.Name(), ID: r.Namespace.Slug, Name: r.Name, Description: r.Description,
+			Author: r.Namespace.Owner, Downloads: r.Stats.Downloads, IconURL: r.AvatarURL,
+		})
+	}
+	return plugins, nil
+}
+
+func (p *HangarProvider) Latest(ctx context.Context, projectID string, target PluginTarget) (PluginRelease, error) {
+	params := url.Values{
+		"platform":        {"PAPER"},
+		"platformVersion": {target.Version},
+		"limit":           {"10"},
+	}
+	var res struct {
+		Result []struct {
+			Name    string `json:"name"`
+			Channel struct {
+				Name string `json:"name"`
+			} `json:"channel"`
+			Downloads map[string]struct {
+				FileInfo *struct {
+					Name       string `json:"name"`
+					SHA256Hash string `json:"sha256Hash"`
+				} `json:"fileInfo"`
+				ExternalURL string `json:"externalUrl"`
+				DownloadURL string `json:"downloadUrl"`
+			} `json:"downloads"`
+		} `json:"result"`
+	}
+	path := p.base() + "/projects/" + url.PathEscape(projectID) + "/versions?" + params.Encode()
+	if err := getJSON(ctx, p.Client, path, &res); err != nil {
+		return PluginRelease{}, err
+	}
+
+	// Newest first; prefer the Release channel, and only files Hangar hosts
+	// (external links have no hash to check)
+	for _, releaseOnly := range []bool{true, false} {
+		for _, v := range res.Result {
+			if releaseOnly && !strings.EqualFold(v.Channel.Name, "Release") {
+				continue
+			}
+			dl, ok := v.Downloads["PAPER"]
+			if !ok || dl.FileInfo == nil || dl.DownloadURL == "" {
+				continue
+			}
+			return PluginRelease{
+				Provider: p.Name(), ProjectID: projectID, Name: projectID, Version: v.Name,
+				FileName: dl.FileInfo.Name, URL: dl.DownloadURL, Checksum: checksumOf("sha256", dl.FileInfo.SHA256Hash),
+			}, nil
+		}
+	}
+	return PluginRelease{}, fmt.Errorf("%s has no downloadable release for %s %s", projectID, target.Type, target.Version)
+}
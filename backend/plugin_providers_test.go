@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var pluginRoutes = map[string]string{
+	// Modrinth v2
+	"/modrinth/search": `{"hits": [
+		{"project_id": "AAAA", "title": "LuckPerms", "description": "Permissions", "author": "Luck", "downloads": 1200, "icon_url": "{base}/icons/lp.png"},
+		{"project_id": "BBBB", "title": "Chunky", "description": "Pregenerate", "author": "pop4959", "downloads": 900}]}`,
+	"/modrinth/project/AAAA": `{"title": "LuckPerms"}`,
+	"/modrinth/project/AAAA/version": `[
+		{"version_number": "5.5.0-beta", "version_type": "beta", "files": [{"url": "{base}/jars/lp-beta.jar", "filename": "LuckPerms-5.5.0.jar", "primary": true, "hashes": {"sha512": "beef"}}]},
+		{"version_number": "5.4.1", "version_type": "release", "files": [
+			{"url": "{base}/jars/lp-sources.jar", "filename": "LuckPerms-5.4.1-sources.jar", "hashes": {"sha512": "0000"}},
+			{"url": "{base}/jars/lp.jar", "filename": "LuckPerms-5.4.1.jar", "primary": true, "hashes": {"sha512": "ABCD", "sha1": "ffff"}}]}]`,
+	"/modrinth/project/BETA":         `{"title": "Only Betas"}`,
+	"/modrinth/project/BETA/version": `[{"version_number": "0.2", "version_type": "alpha", "files": [{"url": "{base}/jars/b.jar", "filename": "b.jar", "hashes": {"sha1": "a1b2"}}]}]`,
+	"/modrinth/project/NONE":         `{"title": "Nothing Yet"}`,
+	"/modrinth/project/NONE/version": `[{"version_number": "1.0", "version_type": "release", "files": []}]`,
+
+	// Hangar v1
+	"/hangar/projects": `{"result": [
+		{"name": "Maintenance", "namespace": {"owner": "kennytv", "slug": "Maintenance"}, "description": "Maintenance mode", "avatarUrl": "{base}/icons/m.png", "stats": {"downloads": 300}}]}`,
+	"/hangar/projects/Maintenance/versions": `{"result": [
+		{"name": "4.3.0-SNAPSHOT", "channel": {"name": "Snapshot"}, "downloads": {"PAPER": {"fileInfo": {"name": "Maintenance-4.3.0.jar", "sha256Hash": "aaaa"}, "downloadUrl": "{base}/jars/m-snap.jar"}}},
+		{"name": "4.2.1", "channel": {"name": "Release"}, "downloads": {"PAPER": {"externalUrl": "https://example.com/m.jar"}}},
+		{"name": "4.2.0", "channel": {"name": "Release"}, "downloads": {"PAPER": {"fileInfo": {"name": "Maintenance-4.2.0.jar", "sha256Hash": "BBBB"}, "downloadUrl": "{base}/jars/m.jar"}}}]}`,
+	"/hangar/projects/External/versions": `{"result": [
+		{"name": "1.0", "channel": {"name": "Release"}, "downloads": {"PAPER": {"externalUrl": "https://example.com/e.jar"}}}]}`,
+}
+
+// fixturePluginProviders points every plugin provider at the fixture
+func fixturePluginProviders(base string) []PluginProvider {
+	return []PluginProvider{
+		&ModrinthProvider{BaseURL: base + "/modrinth"},
+		&HangarProvider{BaseURL: base + "/hangar/"},
+	}
+}
+
+var paperTarget = PluginTarget{Type: "Paper", Version: "1.20.4"}
+
+func TestPluginProvidersLatest(t *testing.T) {
+	srv := upstreamFixture(t, pluginRoutes)
+	providers := fixturePluginProviders(srv.URL)
+	modrinth, hangar := providers[0], providers[1]
+
+	tests := []struct {
+		name     string
+		provider PluginProvider
+		project  string
+		want     PluginRelease
+		wantErr  string
+	}{
+		{
+			name: "modrinth prefers the release and its primary file", provider: modrinth, project: "AAAA",
+			want: PluginRelease{Provider: "Modrinth", ProjectID: "AAAA", Name: "LuckPerms", Version: "5.4.1",
+				FileName: "LuckPerms-5.4.1.jar", URL: srv.URL + "/jars/lp.jar", Checksum: "sha512:abcd"},
+		},
+		{
+			name: "modrinth falls back to the newest beta", provider: modrinth, project: "BETA",
+			want: PluginRelease{Provider: "Modrinth", ProjectID: "BETA", Name: "Only Betas", Version: "0.2",
+				FileName: "b.jar", URL: srv.URL + "/jars/b.jar", Checksum: "sha1:a1b2"},
+		},
+		{name: "modrinth release without files", provider: modrinth, project: "NONE", wantErr: "Nothing Yet has no release for Paper 1.20.4"},
+		{name: "modrinth unknown project", provider: modrinth, project: "GONE", wantErr: "404"},
+		{
+			name: "hangar skips snapshots and external links", provider: hangar, project: "Maintenance",
+			want: PluginRelease{Provider: "Hangar", ProjectID: "Maintenance", Name: "Maintenance", Version: "4.2.0",
+				FileName: "Maintenance-4.2.0.jar", URL: srv.URL + "/jars/m.jar", Checksum: "sha256:bbbb"},
+		},
+		{name: "hangar external only", provider: hangar, project: "External", wantErr: "External has no downloadable release"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.provider.Latest(context.Background(), tt.project, paperTarget)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Latest error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Latest =\n  %+v\nwant\n  %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPluginProvidersSearch(t *testing.T) {
+	srv := upstreamFixture(t, pluginRoutes)
+	providers := fixturePluginProviders(srv.URL)
+
+	got, err := providers[0].Search(context.Background(), "perms", paperTarget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PluginInfo{
+		{Provider: "Modrinth", ID: "AAAA", Name: "LuckPerms", Description: "Permissions", Author: "Luck", Downloads: 1200, IconURL: srv.URL + "/icons/lp.png"},
+		{Provider: "Modrinth", ID: "BBBB", Name: "Chunky", Description: "Pregenerate", Author: "pop4959", Downloads: 900},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Modrinth search = %+v", got)
+	}
+
+	got, err = providers[1].Search(context.Background(), "maint", paperTarget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []PluginInfo{
+		{Provider: "Hangar", ID: "Maintenance", Name: "Maintenance", Description: "Maintenance mode", Author: "kennytv", Downloads: 300, IconURL: srv.URL + "/icons/m.png"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Hangar search = %+v", got)
+	}
+}
+
+// The providers filter by loader and game version on the server side, so
+// the query is what decides compatibility
+func TestPluginProviderQueries(t *testing.T) {
+	queries := map[string]url.Values{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries[r.URL.Path] = r.URL.Query()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/version"):
+			w.Write([]byte(`[]`))
+		case strings.HasPrefix(r.URL.Path, "/modrinth/project/"):
+			w.Write([]byte(`{"title": "X"}`))
+		default:
+			w.Write([]byte(`{"hits": [], "result": []}`))
+		}
+	}))
+	defer srv.Close()
+	providers := fixturePluginProviders(srv.URL)
+	purpur := PluginTarget{Type: "Purpur", Version: "1.21"}
+
+	providers[0].Search(context.Background(), "chunk", purpur)
+	providers[0].Latest(context.Background(), "X", purpur)
+	providers[1].Search(context.Background(), "chunk", purpur)
+	providers[1].Latest(context.Background(), "Y", purpur)
+
+	tests := []struct {
+		path, key, want string
+	}{
+		{"/modrinth/search", "facets", `[["project_type:plugin"],["categories:purpur","categories:paper","categories:spigot","categories:bukkit"],["versions:1.21"]]`},
+		{"/modrinth/search", "query", "chunk"},
+		{"/modrinth/project/X/version", "loaders", `["purpur","paper","spigot","bukkit"]`},
+		{"/modrinth/project/X/version", "game_versions", `["1.21"]`},
+		{"/hangar/projects", "platform", "PAPER"},
+		{"/hangar/projects", "version", "1.21"},
+		{"/hangar/projects/Y/versions", "platformVersion", "1.21"},
+	}
+	for _, tt := range tests {
+		if got := queries[tt.path].Get(tt.key); got != tt.want {
+			t.Errorf("%s ?%s = %q, want %q", tt.path, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pluginLockFile lists the plugins installed through the app. It lives in
+// the synced folder, so every host sees the same list and versions.
+const pluginLockFile = "plugins.lock"
+
+// pluginTimeout bounds provider API calls
+const pluginTimeout = 30 * time.Second
+
+// InstalledPlugin is one entry of plugins.lock
+type InstalledPlugin struct {
+	PluginRelease
+	InstalledBy string    `json:"installed_by"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// PluginLock is the content of plugins.lock
+type PluginLock struct {
+	Plugins []InstalledPlugin `json:"plugins"`
+}
+
+func readPluginLock(data []byte) PluginLock {
+	var lock PluginLock
+	json.Unmarshal(data, &lock)
+	if lock.Plugins == nil {
+		lock.Plugins = []InstalledPlugin{}
+	}
+	return lock
+}
+
+func loadPluginLock(dir string) PluginLock {
+	data, _ := os.ReadFile(filepath.Join(dir, pluginLockFile))
+	return readPluginLock(data)
+}
+
+func savePluginLock(dir string, lock PluginLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, pluginLockFile), data, 0644)
+}
+
+// find returns the index of a project in the lock, or -1
+func (l PluginLock) find(provider string, projectID string) int {
+	for i, p := range l.Plugins {
+		if p.Provider == provider && p.ProjectID == projectID {
+			return i
+		}
+	}
+	return -1
+}
+
+// pluginProvider finds a provider by name (case-insensitive)
+func (a *App) pluginProvider(name string) (PluginProvider, error) {
+	for _, p := range a.plugins {
+		if strings.EqualFold(p.Name(), name) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown plugin source %q", name)
+}
+
+// pluginTarget is the server plugins must fit. Only Bukkit-style servers
+// load plugins.
+func pluginTarget(server ServerGroup) (PluginTarget, error) {
+	switch strings.ToLower(server.Type) {
+	case "paper", "purpur":
+		return PluginTarget{Type: server.Type, Version: server.Version}, nil
+	}
+	return PluginTarget{}, fmt.Errorf("plugins need a Paper or Purpur server, this one is %s", server.Type)
+}
+
+// GetPluginProviders lists the plugin sources for the search dropdown
+func (a *App) GetPluginProviders() []string {
+	names := []string{}
+	for _, p := range a.plugins {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// SearchPlugins searches a plugin source for plugins that run on the
+// group's server type and Minecraft version
+func (a *App) SearchPlugins(serverID string, token string, provider string, query string) []PluginInfo {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return []PluginInfo{}
+	}
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return []PluginInfo{}
+	}
+	target, err := pluginTarget(server)
+	if err != nil {
+		a.Log("⚠️ " + err.Error())
+		return []PluginInfo{}
+	}
+	p, err := a.pluginProvider(provider)
+	if err != nil {
+		return []PluginInfo{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+	results, err := p.Search(ctx, query, target)
+	if err != nil {
+		a.Log("⚠️ Plugin search failed: " + err.Error())
+		return []PluginInfo{}
+	}
+	return results
+}
+
+// ListPlugins returns the group's plugins.lock. The cloud copy is the
+// group's truth; the local copy is used when it can't be read.
+func (a *App) ListPlugins(serverID string, token string) []InstalledPlugin {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return []InstalledPlugin{}
+	}
+	if !a.procs.IsRunning(serverID) {
+		if cmd, err := a.rcloneCommand(serverID, "cat", "mc-remote:server-"+serverID+"/"+pluginLockFile); err == nil {
+			if out, err := cmd.Output(); err == nil {
+				return readPluginLock(out).Plugins
+			}
+		}
+	}
+	return loadPluginLock(a.getInstancePath(serverID)).Plugins
+}
+
+// InstallPlugin installs (or updates) the newest compatible release of a
+// project into plugins/ and records it in plugins.lock (admins only)
+func (a *App) InstallPlugin(serverID string, token string, provider string, projectID string) string {
+	var installed PluginRelease
+	err := a.editPlugins(serverID, token, func(leaseCtx context.Context, dir string, target PluginTarget, lock *PluginLock, username string) error {
+		p, err := a.pluginProvider(provider)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(leaseCtx, pluginTimeout)
+		defer cancel()
+		release, err := p.Latest(ctx, projectID, target)
+		if err != nil {
+			return err
+		}
+		installed = release
+		return a.installPlugin(dir, lock, release, username)
+	})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return fmt.Sprintf("Success: Installed %s %s", installed.Name, installed.Version)
+}
+
+// UpdatePlugins moves every plugin in plugins.lock to its newest
+// compatible release (admins only)
+func (a *App) UpdatePlugins(serverID string, token string) string {
+	updated := 0
+	err := a.editPlugins(serverID, token, func(leaseCtx context.Context, dir string, target PluginTarget, lock *PluginLock, username string) error {
+		for _, installed := range append([]InstalledPlugin(nil), lock.Plugins...) {
+			p, err := a.pluginProvider(installed.Provider)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(leaseCtx, pluginTimeout)
+			release, err := p.Latest(ctx, installed.ProjectID, target)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("%s: %v", installed.Name, err)
+			}
+			if release.Version == installed.Version && release.Checksum == installed.Checksum {
+				continue
+			}
+			if err := a.installPlugin(dir, lock, release, username); err != nil {
+				return err
+			}
+			a.Log(fmt.Sprintf("🔌 Updated %s %s → %s", installed.Name, installed.Version, release.Version))
+			updated++
+		}
+		if updated == 0 {
+			return errNothingToDo
+		}
+		return nil
+	})
+	if errors.Is(err, errNothingToDo) {
+		return "Success: All plugins are up to date"
+	}
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return fmt.Sprintf("Success: Updated %d plugins", updated)
+}
+
+// UninstallPlugin removes a plugin's jar and its plugins.lock entry. Its
+// config folder is kept. Admins only.
+func (a *App) UninstallPlugin(serverID string, token string, provider string, projectID string) string {
+	var name string
+	err := a.editPlugins(serverID, token, func(_ context.Context, dir string, _ PluginTarget, lock *PluginLock, _ string) error {
+		i := lock.find(provider, projectID)
+		if i == -1 {
+			return fmt.Errorf("plugin is not installed")
+		}
+		name = lock.Plugins[i].Name
+		if file := lock.Plugins[i].FileName; plainName(file) {
+			if err := os.Remove(filepath.Join(dir, "plugins", file)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		lock.Plugins = append(lock.Plugins[:i], lock.Plugins[i+1:]...)
+		return nil
+	})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Success: Removed " + name
+}
+
+// errNothingToDo ends an edit without uploading
+var errNothingToDo = errors.New("nothing to do")
+
+// installPlugin downloads a release into plugins/ (hash-checked), removes
+// the jar of the release it replaces and updates the lock
+func (a *App) installPlugin(dir string, lock *PluginLock, release PluginRelease, username string) error {
+	name := release.FileName
+	if !plainName(name) || !strings.HasSuffix(strings.ToLower(name), ".jar") {
+		return fmt.Errorf("%s: unexpected file name %q", release.Name, release.FileName)
+	}
+	if release.Checksum == "" {
+		return fmt.Errorf("%s: the source gave no checksum for %s", release.Name, name)
+	}
+	pluginsDir := filepath.Join(dir, "plugins")
+	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
+		return err
+	}
+
+	err := a.download(Download{
+		URL:      release.URL,
+		Dest:     filepath.Join(pluginsDir, name),
+		Checksum: release.Checksum,
+		Name:     release.Name + " " + release.Version,
+	})
+	if err != nil {
+		return err
+	}
+
+	entry := InstalledPlugin{PluginRelease: release, InstalledBy: username, InstalledAt: time.Now()}
+	if i := lock.find(release.Provider, release.ProjectID); i != -1 {
+		if old := lock.Plugins[i].FileName; old != name && plainName(old) {
+			os.Remove(filepath.Join(pluginsDir, old))
+		}
+		lock.Plugins[i] = entry
+	} else {
+		lock.Plugins = append(lock.Plugins, entry)
+	}
+	return nil
+}
+
+// editPlugins brings the group's files to this PC, lets edit change
+// plugins/ and the lock, and uploads the result. Like SyncNow it needs the
+// server to be stopped everywhere, since a running host would upload over
+// the change (and holds the jars open), and it holds the server lock so
+// nobody starts it until the upload is done.
+func (a *App) editPlugins(serverID string, token string, edit func(ctx context.Context, dir string, target PluginTarget, lock *PluginLock, username string) error) error {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return err
+	}
+	if !a.isAdmin(serverID, username) {
+		return fmt.Errorf("only admins can manage plugins")
+	}
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return fmt.Errorf("server not found")
+	}
+	target, err := pluginTarget(server)
+	if err != nil {
+		return err
+	}
+	if server.Lock.IsRunning || a.procs.IsRunning(serverID) {
+		return fmt.Errorf("stop the server first")
+	}
+
+	return a.withServerLock(serverID, username, func(ctx context.Context) error {
+		dir := a.getInstancePath(serverID)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := a.syncDown(serverID, dir); err != nil {
+			return fmt.Errorf("sync failed: %v", err)
+		}
+
+		lock := loadPluginLock(dir)
+		if err := edit(ctx, dir, target, &lock, username); err != nil {
+			return err
+		}
+		if err := savePluginLock(dir, lock); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+
+		err := a.syncUp(serverID, dir, username, true)
+		status := "ok"
+		if err != nil {
+			status = "error"
+			var conflict *SyncConflictError
+			if errors.As(err, &conflict) {
+				status = "conflict"
+			}
+		}
+		ctx, cancel := dbContext()
+		_ = a.store.SetSyncStatus(ctx, serverID, status, username, time.Now())
+		cancel()
+		if err != nil {
+			return fmt.Errorf("upload failed: %v", err)
+		}
+		return nil
+	})
+}
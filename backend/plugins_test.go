@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakePluginProvider serves fixed releases, for tests that don't need HTTP
+type fakePluginProvider struct {
+	name     string
+	releases map[string]PluginRelease
+}
+
+func (p *fakePluginProvider) Name() string { return p.name }
+
+func (p *fakePluginProvider) Search(ctx context.Context, query string, target PluginTarget) ([]PluginInfo, error) {
+	return []PluginInfo{}, nil
+}
+
+func (p *fakePluginProvider) Latest(ctx context.Context, projectID string, target PluginTarget) (PluginRelease, error) {
+	return p.releases[projectID], nil
+}
+
+func sha512Of(data string) string {
+	sum := sha512.Sum512([]byte(data))
+	return "sha512:" + hex.EncodeToString(sum[:])
+}
+
+func TestPluginProviderLookup(t *testing.T) {
+	a := &App{plugins: []PluginProvider{&fakePluginProvider{name: "Modrinth"}, &fakePluginProvider{name: "Hangar"}}}
+	if p, err := a.pluginProvider("hangar"); err != nil || p.Name() != "Hangar" {
+		t.Errorf("pluginProvider(hangar) = %v, %v", p, err)
+	}
+	if _, err := a.pluginProvider("CurseForge"); err == nil {
+		t.Error("an unknown provider was found")
+	}
+}
+
+func TestInstallPluginFiles(t *testing.T) {
+	srv := upstreamFixture(t, map[string]string{
+		"/jars/lp-5.4.0.jar": "luckperms 5.4.0",
+		"/jars/lp-5.4.1.jar": "luckperms 5.4.1",
+	})
+	a := &App{events: NewEventBus()}
+	dir := t.TempDir()
+	lock := PluginLock{Plugins: []InstalledPlugin{}}
+
+	release := func(version string) PluginRelease {
+		return PluginRelease{
+			Provider: "Modrinth", ProjectID: "AAAA", Name: "LuckPerms", Version: version,
+			FileName: "LuckPerms-" + version + ".jar", URL: srv.URL + "/jars/lp-" + version + ".jar",
+			Checksum: sha512Of("luckperms " + version),
+		}
+	}
+
+	if err := a.installPlugin(dir, &lock, release("5.4.0"), "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "plugins", "LuckPerms-5.4.0.jar")); string(got) != "luckperms 5.4.0" {
+		t.Fatalf("installed jar = %q", got)
+	}
+
+	// An update replaces the old jar and the lock entry
+	if err := a.installPlugin(dir, &lock, release("5.4.1"), "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "plugins", "LuckPerms-5.4.0.jar")); !os.IsNotExist(err) {
+		t.Error("the replaced jar was kept")
+	}
+	if len(lock.Plugins) != 1 || lock.Plugins[0].Version != "5.4.1" || lock.Plugins[0].InstalledBy != "bob" {
+		t.Errorf("lock = %+v", lock.Plugins)
+	}
+
+	// A tampered plugins.lock must not make an update delete files outside plugins/
+	outside := filepath.Join(dir, "server.jar")
+	os.WriteFile(outside, []byte("server"), 0644)
+	lock.Plugins[0].FileName = "../server.jar"
+	if err := a.installPlugin(dir, &lock, release("5.4.0"), "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Error("an update deleted a file outside plugins/")
+	}
+}
+
+func TestInstallPluginRejectsUnsafeNames(t *testing.T) {
+	srv, hits := jarFixture(t, 0, 0)
+	a := &App{events: NewEventBus()}
+	dir := t.TempDir()
+
+	for _, name := range []string{"../evil.jar", "sub/evil.jar", `..\evil.jar`, "/tmp/evil.jar", "..", "evil.txt", ""} {
+		lock := PluginLock{Plugins: []InstalledPlugin{}}
+		release := PluginRelease{Name: "Evil", FileName: name, URL: srv.URL, Checksum: jarChecksum()}
+		err := a.installPlugin(dir, &lock, release, "mallory")
+		if err == nil || !strings.Contains(err.Error(), "unexpected file name") {
+			t.Errorf("installPlugin(%q) error = %v", name, err)
+		}
+		if len(lock.Plugins) != 0 {
+			t.Errorf("installPlugin(%q) changed the lock", name)
+		}
+	}
+	if hits.Load() != 0 {
+		t.Errorf("%d downloads for rejected names", hits.Load())
+	}
+}
+
+func TestPlainName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"LuckPerms-5.4.1.jar", true},
+		{"world", true},
+		{"world_nether", true},
+		{"..world", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../world", false},
+		{"world/../../x", false},
+		{`..\world`, false},
+		{"/etc/passwd", false},
+		{`C:\Windows`, false},
+	}
+	for _, tt := range tests {
+		if got := plainName(tt.name); got != tt.ok {
+			t.Errorf("plainName(%q) = %v, want %v", tt.name, got, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The platform layer (process_windows.go, process_unix.go) provides:
+//
+//	processTable() (map[int]int, error)            pid -> parent pid
+//	processInfo(pid) (name string, started uint64, err error)
+//	killPID(pid) error                             hard kill
+//	terminatePID(pid) error                        polite stop, if the OS has one
+//	listeningPIDs(port) ([]int, error)             owners of a listening TCP port
+//	fileLockers(path) ([]int, error)               processes holding a file open
+//	totalMemoryMB() int                            physical RAM, 0 if unknown
+//
+// "started" is an opaque process start time. Together with the PID it
+// identifies one process instance, so a recycled PID is never mistaken for ours.
+
+// processIdentity is what we write to server.pid
+type processIdentity struct {
+	PID     int
+	Started uint64 // 0 if unknown (old pid files)
+}
+
+// identify captures the identity of a running process
+func identify(pid int) processIdentity {
+	_, started, _ := processInfo(pid)
+	return processIdentity{PID: pid, Started: started}
+}
+
+// writePIDFile saves "<pid> <start time>"
+func writePIDFile(path string, id processIdentity) error {
+	return os.WriteFile(path, []byte(fmt.Sprintf("%d %d", id.PID, id.Started)), 0644)
+}
+
+// readPIDFile loads a pid file. Files with only a PID are still accepted.
+func readPIDFile(path string) (processIdentity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return processIdentity{}, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return processIdentity{}, fmt.Errorf("empty pid file")
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil || pid <= 0 {
+		return processIdentity{}, fmt.Errorf("bad pid file")
+	}
+	id := processIdentity{PID: pid}
+	if len(fields) > 1 {
+		id.Started, _ = strconv.ParseUint(fields[1], 10, 64)
+	}
+	return id, nil
+}
+
+// WriteHostPIDFile records the current process in a pid file, for
+// HostPIDAlive in another process
+func WriteHostPIDFile(path string) error {
+	return writePIDFile(path, identify(os.Getpid()))
+}
+
+// HostPIDAlive reads a pid file written by WriteHostPIDFile and reports
+// whether that process still runs, not another that reused its PID
+func HostPIDAlive(path string) (int, bool) {
+	id, err := readPIDFile(path)
+	if err != nil {
+		return 0, false
+	}
+	_, started, err := processInfo(id.PID)
+	if err != nil {
+		return id.PID, false
+	}
+	return id.PID, id.Started == 0 || started == 0 || started == id.Started
+}
+
+// processAlive reports whether pid exists
+func processAlive(pid int) bool {
+	_, _, err := processInfo(pid)
+	return err == nil
+}
+
+// isOurJava checks that the process is still the Java we started,
+// not some other program that reused its PID
+func isOurJava(id processIdentity) bool {
+	name, started, err := processInfo(id.PID)
+	if err != nil || !isJavaName(name) {
+		return false
+	}
+	return id.Started == 0 || started == 0 || started == id.Started
+}
+
+func isJavaName(name string) bool {
+	base := strings.ToLower(filepath.Base(name))
+	base = strings.TrimSuffix(base, ".exe")
+	return base == "java" || base == "javaw"
+}
+
+// killProcessTree kills pid and everything it spawned, children first
+func killProcessTree(pid int) error {
+	victims := []int{pid}
+	if table, err := processTable(); err == nil {
+		children := map[int][]int{}
+		for child, parent := range table {
+			children[parent] = append(children[parent], child)
+		}
+		for i := 0; i < len(victims); i++ {
+			victims = append(victims, children[victims[i]]...)
+		}
+	}
+
+	var firstErr error
+	for i := len(victims) - 1; i >= 0; i-- {
+		if err := killPID(victims[i]); err != nil && victims[i] == pid {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// stopProcessTree asks a process to exit, then kills the tree after grace
+func stopProcessTree(pid int, grace time.Duration) error {
+	if terminatePID(pid) == nil {
+		deadline := time.Now().Add(grace)
+		for time.Now().Before(deadline) {
+			if !processAlive(pid) {
+				return nil
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+	}
+	return killProcessTree(pid)
+}
@@ -0,0 +1,34 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHostPIDAlive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "srv.pid")
+
+	if err := WriteHostPIDFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if pid, ok := HostPIDAlive(path); !ok || pid != os.Getpid() {
+		t.Errorf("HostPIDAlive = %d, %v for this process", pid, ok)
+	}
+
+	// Same PID, different start time: the PID was reused by another process
+	id := identify(os.Getpid())
+	if id.Started != 0 {
+		os.WriteFile(path, []byte(fmt.Sprintf("%d %d", id.PID, id.Started+1)), 0644)
+		if _, ok := HostPIDAlive(path); ok {
+			t.Error("a reused PID counted as the host")
+		}
+	}
+
+	os.Remove(path)
+	if _, ok := HostPIDAlive(path); ok {
+		t.Error("a missing pid file counted as a live host")
+	}
+}
@@ -0,0 +1,230 @@
+//go:build !windows
+
+package backend
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Linux exposes everything under /proc. Other Unixes (macOS) don't have it,
+// so there we ask ps and lsof instead.
+func hasProc() bool {
+	_, err := os.Stat("/proc/self/stat")
+	return err == nil
+}
+
+func processTable() (map[int]int, error) {
+	table := map[int]int{}
+	if !hasProc() {
+		out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=").Output()
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			pid, err1 := strconv.Atoi(fields[0])
+			ppid, err2 := strconv.Atoi(fields[1])
+			if err1 == nil && err2 == nil {
+				table[pid] = ppid
+			}
+		}
+		return table, nil
+	}
+
+	for _, pid := range procPIDs() {
+		if stat, err := readProcStat(pid); err == nil {
+			table[pid] = stat.ppid
+		}
+	}
+	return table, nil
+}
+
+func processInfo(pid int) (string, uint64, error) {
+	if !hasProc() {
+		if err := syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+			return "", 0, err
+		}
+		out, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+		if err != nil {
+			return "", 0, err
+		}
+		return strings.TrimSpace(string(out)), 0, nil
+	}
+
+	stat, err := readProcStat(pid)
+	if err != nil {
+		return "", 0, err
+	}
+	if stat.state == "Z" {
+		return "", 0, fmt.Errorf("process %d has exited", pid)
+	}
+	name := stat.comm
+	if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid)); err == nil {
+		name = exe
+	}
+	return name, stat.started, nil
+}
+
+func killPID(pid int) error {
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
+
+// terminatePID sends SIGTERM; the JVM runs shutdown hooks, so Minecraft saves
+func terminatePID(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+func listeningPIDs(port int) ([]int, error) {
+	if !hasProc() {
+		return lsofPIDs("-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN")
+	}
+
+	// /proc/net/tcp lists sockets with their inode; the inode leads to the
+	// process through its /proc/<pid>/fd links
+	inodes := map[string]bool{}
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		file, err := os.Open(table)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Scan() // Header
+		for scanner.Scan() {
+			// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != "0A" { // 0A = LISTEN
+				continue
+			}
+			_, hexPort, ok := strings.Cut(fields[1], ":")
+			if p, err := strconv.ParseInt(hexPort, 16, 32); ok && err == nil && int(p) == port {
+				inodes["socket:["+fields[9]+"]"] = true
+			}
+		}
+		file.Close()
+	}
+	if len(inodes) == 0 {
+		return nil, nil
+	}
+	return procPIDsWithFD(func(target string) bool { return inodes[target] }), nil
+}
+
+func fileLockers(path string) ([]int, error) {
+	if !hasProc() {
+		return lsofPIDs(path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return procPIDsWithFD(func(target string) bool { return target == abs }), nil
+}
+
+func totalMemoryMB() int {
+	if !hasProc() {
+		out, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+		if err != nil {
+			return 0
+		}
+		bytes, _ := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+		return int(bytes / (1024 * 1024))
+	}
+
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// MemTotal:       16318412 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, _ := strconv.Atoi(fields[1])
+			return kb / 1024
+		}
+	}
+	return 0
+}
+
+// procStat holds the /proc/<pid>/stat fields we use
+type procStat struct {
+	comm    string
+	state   string
+	ppid    int
+	started uint64 // Clock ticks since boot
+}
+
+func readProcStat(pid int) (procStat, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return procStat{}, err
+	}
+	// "pid (comm) state ppid ..." - comm may contain spaces and parentheses
+	text := string(data)
+	open, end := strings.IndexByte(text, '('), strings.LastIndexByte(text, ')')
+	if open < 0 || end < open {
+		return procStat{}, fmt.Errorf("bad stat for %d", pid)
+	}
+	fields := strings.Fields(text[end+1:])
+	if len(fields) < 20 {
+		return procStat{}, fmt.Errorf("bad stat for %d", pid)
+	}
+	stat := procStat{comm: text[open+1 : end], state: fields[0]}
+	stat.ppid, _ = strconv.Atoi(fields[1])
+	stat.started, _ = strconv.ParseUint(fields[19], 10, 64) // Field 22
+	return stat, nil
+}
+
+func procPIDs() []int {
+	entries, _ := os.ReadDir("/proc")
+	var pids []int
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// procPIDsWithFD lists processes with an open descriptor whose link target matches
+func procPIDsWithFD(match func(target string) bool) []int {
+	var pids []int
+	for _, pid := range procPIDs() {
+		dir := fmt.Sprintf("/proc/%d/fd", pid)
+		fds, err := os.ReadDir(dir) // Fails for other users' processes, which can't be ours anyway
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			if target, err := os.Readlink(filepath.Join(dir, fd.Name())); err == nil && match(target) {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	return pids
+}
+
+func lsofPIDs(args ...string) ([]int, error) {
+	out, err := exec.Command("lsof", append([]string{"-t"}, args...)...).Output()
+	if err != nil && len(out) == 0 {
+		return nil, nil // lsof exits 1 when nothing matches
+	}
+	var pids []int
+	for _, line := range strings.Split(string(out), "\n") {
+		if pid, err := strconv.Atoi(strings.TrimSpace(line)); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
@@ -0,0 +1,231 @@
+//go:build windows
+
+package backend
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	iphlpapi                = windows.NewLazySystemDLL("iphlpapi.dll")
+	procGetExtendedTcpTable = iphlpapi.NewProc("GetExtendedTcpTable")
+
+	kernel32                 = windows.NewLazySystemDLL("kernel32.dll")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+
+	rstrtmgr               = windows.NewLazySystemDLL("rstrtmgr.dll")
+	procRmStartSession     = rstrtmgr.NewProc("RmStartSession")
+	procRmRegisterResource = rstrtmgr.NewProc("RmRegisterResources")
+	procRmGetList          = rstrtmgr.NewProc("RmGetList")
+	procRmEndSession       = rstrtmgr.NewProc("RmEndSession")
+)
+
+const (
+	tcpTableOwnerPIDListener = 3
+	mibTCPStateListen        = 2
+	stillActive              = 259
+)
+
+func processTable() (map[int]int, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	table := map[int]int{}
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		table[int(entry.ProcessID)] = int(entry.ParentProcessID)
+	}
+	return table, nil
+}
+
+func processInfo(pid int) (string, uint64, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return "", 0, err
+	}
+	defer windows.CloseHandle(h)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return "", 0, err
+	}
+	if code != stillActive {
+		return "", 0, fmt.Errorf("process %d has exited", pid)
+	}
+
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	name := ""
+	if err := windows.QueryFullProcessImageName(h, 0, &buf[0], &size); err == nil {
+		name = windows.UTF16ToString(buf[:size])
+	}
+
+	var created, exited, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return name, 0, nil
+	}
+	return name, uint64(created.Nanoseconds()), nil
+}
+
+func killPID(pid int) error {
+	h, err := windows.OpenProcess(windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+	return windows.TerminateProcess(h, 1)
+}
+
+// terminatePID can't ask a console process to exit politely on Windows
+func terminatePID(pid int) error {
+	return fmt.Errorf("not supported on windows")
+}
+
+func listeningPIDs(port int) ([]int, error) {
+	var pids []int
+	seen := map[int]bool{}
+	// Java listens on IPv6 "::" as well as IPv4, so check both tables
+	for _, family := range []uint32{windows.AF_INET, windows.AF_INET6} {
+		rows, err := tcpListeners(family)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if row.port == port && !seen[row.pid] {
+				seen[row.pid] = true
+				pids = append(pids, row.pid)
+			}
+		}
+	}
+	return pids, nil
+}
+
+type tcpListener struct {
+	port int
+	pid  int
+}
+
+// tcpListeners reads MIB_TCPTABLE_OWNER_PID / MIB_TCP6TABLE_OWNER_PID
+func tcpListeners(family uint32) ([]tcpListener, error) {
+	var size uint32
+	procGetExtendedTcpTable.Call(0, uintptr(unsafe.Pointer(&size)), 0, uintptr(family), tcpTableOwnerPIDListener, 0)
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	ret, _, _ := procGetExtendedTcpTable.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)), 0, uintptr(family), tcpTableOwnerPIDListener, 0)
+	if ret != 0 {
+		return nil, fmt.Errorf("GetExtendedTcpTable failed: %d", ret)
+	}
+
+	// Row layouts (all DWORDs, ports in network byte order in the low 16 bits):
+	//   IPv4: state, localAddr, localPort, remoteAddr, remotePort, pid            (24 bytes)
+	//   IPv6: localAddr[16], scope, localPort, remoteAddr[16], scope, remotePort, state, pid (56 bytes)
+	rowSize, portOff, stateOff, pidOff := 24, 8, 0, 20
+	if family == windows.AF_INET6 {
+		rowSize, portOff, stateOff, pidOff = 56, 20, 48, 52
+	}
+
+	count := int(binary.LittleEndian.Uint32(buf))
+	var rows []tcpListener
+	for i := 0; i < count; i++ {
+		row := buf[4+i*rowSize:]
+		if len(row) < rowSize {
+			break
+		}
+		if binary.LittleEndian.Uint32(row[stateOff:]) != mibTCPStateListen {
+			continue
+		}
+		port := binary.BigEndian.Uint16(row[portOff:])
+		pid := binary.LittleEndian.Uint32(row[pidOff:])
+		rows = append(rows, tcpListener{port: int(port), pid: int(pid)})
+	}
+	return rows, nil
+}
+
+// memoryStatusEx is MEMORYSTATUSEX
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+func totalMemoryMB() int {
+	var status memoryStatusEx
+	status.Length = uint32(unsafe.Sizeof(status))
+	if ret, _, _ := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status))); ret == 0 {
+		return 0
+	}
+	return int(status.TotalPhys / (1024 * 1024))
+}
+
+// rmProcessInfo is RM_PROCESS_INFO from the Restart Manager API
+type rmProcessInfo struct {
+	PID              uint32
+	ProcessStartTime windows.Filetime
+	AppName          [256]uint16
+	ServiceShortName [64]uint16
+	ApplicationType  uint32
+	AppStatus        uint32
+	TSSessionID      uint32
+	Restartable      int32
+}
+
+// fileLockers asks the Restart Manager which processes hold a file open
+func fileLockers(path string) ([]int, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	name, err := windows.UTF16PtrFromString(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	var session uint32
+	key := make([]uint16, 33) // CCH_RM_SESSION_KEY + 1
+	if ret, _, _ := procRmStartSession.Call(uintptr(unsafe.Pointer(&session)), 0, uintptr(unsafe.Pointer(&key[0]))); ret != 0 {
+		return nil, fmt.Errorf("RmStartSession failed: %d", ret)
+	}
+	defer procRmEndSession.Call(uintptr(session))
+
+	if ret, _, _ := procRmRegisterResource.Call(uintptr(session), 1, uintptr(unsafe.Pointer(&name)), 0, 0, 0, 0); ret != 0 {
+		return nil, fmt.Errorf("RmRegisterResources failed: %d", ret)
+	}
+
+	var needed, count, reasons uint32
+	infos := make([]rmProcessInfo, 8)
+	for {
+		count = uint32(len(infos))
+		ret, _, _ := procRmGetList.Call(uintptr(session), uintptr(unsafe.Pointer(&needed)), uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&infos[0])), uintptr(unsafe.Pointer(&reasons)))
+		if ret == uintptr(windows.ERROR_MORE_DATA) {
+			infos = make([]rmProcessInfo, needed)
+			continue
+		}
+		if ret != 0 {
+			return nil, fmt.Errorf("RmGetList failed: %d", ret)
+		}
+		break
+	}
+
+	pids := make([]int, 0, count)
+	for _, info := range infos[:count] {
+		pids = append(pids, int(info.PID))
+	}
+	return pids, nil
+}
@@ -1,11 +1,13 @@
 package backend
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+
+	"mc-roam/backend/properties"
 )
 
 // ServerProps holds the editable settings
@@ -26,9 +28,48 @@ type ServerProps struct {
 	SpawnProtection string `json:"spawn-protection"`
 }
 
+// PropertyEntry is one server.properties key with its schema (if vanilla)
+type PropertyEntry struct {
+	properties.Property
+	Value   string `json:"value"`
+	Present bool   `json:"present"` // In the file (otherwise Value is the default)
+	Known   bool   `json:"known"`   // In this version's schema
+}
+
+// Older versions store gamemode and difficulty as numbers
+var legacyNumbers = map[string][]string{
+	"gamemode":   {"survival", "creative", "adventure", "spectator"},
+	"difficulty": {"peaceful", "easy", "normal", "hard"},
+}
+
+// managedProperty reports whether mc-roam sets a key on every start (ports,
+// RCON). Members don't see them and admins can't change them.
+func managedProperty(key string) bool {
+	switch key {
+	case "enable-rcon", "broadcast-rcon-to-ops", "server-port", "query.port":
+		return true
+	}
+	return strings.HasPrefix(key, "rcon.")
+}
+
+// serverProperties loads a server's server.properties and its game version
+func (a *App) serverProperties(serverID string) (*properties.File, string, error) {
+	version := ""
+	if server, err := a.getServer(serverID); err == nil {
+		version = server.Version
+	}
+	file, err := properties.Load(filepath.Join(a.getInstancePath(serverID), "server.properties"))
+	return file, version, err
+}
+
 // GetServerOptions reads the server.properties file and returns a struct
-func (a *App) GetServerOptions(serverID string) ServerProps {
-	// Default values
+func (a *App) GetServerOptions(serverID string, token string) ServerProps {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return ServerProps{}
+	}
+
+	// Default values (servers are created cracked, see installer.go)
 	props := ServerProps{
 		MaxPlayers: "20", Gamemode: "survival", Difficulty: "easy",
 		WhiteList: false, OnlineMode: false, Pvp: true,
@@ -37,77 +78,48 @@ func (a *App) GetServerOptions(serverID string) ServerProps {
 		ForceGamemode: false, SpawnProtection: "16",
 	}
 
-	path := filepath.Join(a.getInstancePath(serverID), "server.properties")
-	file, err := os.Open(path)
+	file, _, err := a.serverProperties(serverID)
 	if err != nil {
-		return props // Return defaults if file missing
+		return props // Return defaults if the file can't be read
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
+	text := func(key string, dst *string) {
+		if val, ok := file.Get(key); ok {
+			*dst = val
 		}
-		key := strings.TrimSpace(parts[0])
-		val := strings.TrimSpace(parts[1])
-
-		switch key {
-		case "max-players":
-			props.MaxPlayers = val
-		case "gamemode":
-			props.Gamemode = val
-		case "difficulty":
-			props.Difficulty = val
-		case "white-list":
-			props.WhiteList = (val == "true")
-		case "online-mode":
-			props.OnlineMode = (val == "true")
-		case "pvp":
-			props.Pvp = (val == "true")
-		case "enable-command-block":
-			props.EnableCmdBlock = (val == "true")
-		case "allow-flight":
-			props.AllowFlight = (val == "true")
-		case "spawn-animals":
-			props.SpawnAnimals = (val == "true")
-		case "spawn-monsters":
-			props.SpawnMonsters = (val == "true")
-		case "spawn-npcs":
-			props.SpawnNpcs = (val == "true")
-		case "allow-nether":
-			props.AllowNether = (val == "true")
-		case "force-gamemode":
-			props.ForceGamemode = (val == "true")
-		case "spawn-protection":
-			props.SpawnProtection = val
+	}
+	flag := func(key string, dst *bool) {
+		if val, ok := file.Get(key); ok {
+			*dst = val == "true"
 		}
 	}
+	name := func(key string, dst *string) {
+		text(key, dst)
+		if n, err := strconv.Atoi(*dst); err == nil && n >= 0 && n < len(legacyNumbers[key]) {
+			*dst = legacyNumbers[key][n]
+		}
+	}
+
+	text("max-players", &props.MaxPlayers)
+	name("gamemode", &props.Gamemode)
+	name("difficulty", &props.Difficulty)
+	flag("white-list", &props.WhiteList)
+	flag("online-mode", &props.OnlineMode)
+	flag("pvp", &props.Pvp)
+	flag("enable-command-block", &props.EnableCmdBlock)
+	flag("allow-flight", &props.AllowFlight)
+	flag("spawn-animals", &props.SpawnAnimals)
+	flag("spawn-monsters", &props.SpawnMonsters)
+	flag("spawn-npcs", &props.SpawnNpcs)
+	flag("allow-nether", &props.AllowNether)
+	flag("force-gamemode", &props.ForceGamemode)
+	text("spawn-protection", &props.SpawnProtection)
 	return props
 }
 
 // SaveServerOptions writes the struct back to the file
-func (a *App) SaveServerOptions(serverID string, username string, props ServerProps) string {
-	// Permission check: Only owner or admins can modify server options
-	if !a.IsAdmin(serverID, username) {
-		return "Error: Only admins can modify server options"
-	}
-
-	path := filepath.Join(a.getInstancePath(serverID), "server.properties")
-
-	// We read the whole file to preserve comments and formatting for other keys
-	input, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Sprintf("Error reading file: %v", err)
-	}
-
-	lines := strings.Split(string(input), "\n")
-	output := []string{}
-
-	// Create a map of updates
-	updates := map[string]string{
+func (a *App) SaveServerOptions(serverID string, token string, props ServerProps) string {
+	return a.SetServerProperties(serverID, token, map[string]string{
 		"max-players":          props.MaxPlayers,
 		"gamemode":             props.Gamemode,
 		"difficulty":           props.Difficulty,
@@ -122,34 +134,98 @@ func (a *App) SaveServerOptions(serverID string, username string, props ServerPr
 		"allow-nether":         fmt.Sprintf("%t", props.AllowNether),
 		"force-gamemode":       fmt.Sprintf("%t", props.ForceGamemode),
 		"spawn-protection":     props.SpawnProtection,
+	})
+}
+
+// GetServerProperties lists every key in server.properties plus the ones this
+// version knows but the file doesn't have yet, with types, ranges and defaults
+func (a *App) GetServerProperties(serverID string, token string) []PropertyEntry {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return []PropertyEntry{}
+	}
+	file, version, err := a.serverProperties(serverID)
+	if err != nil {
+		return []PropertyEntry{}
 	}
 
-	// Track which keys we updated so we can add missing ones later
-	updatedKeys := make(map[string]bool)
+	entries := []PropertyEntry{}
+	seen := map[string]bool{}
+	for _, key := range file.Keys() {
+		if managedProperty(key) {
+			continue
+		}
+		value, _ := file.Get(key)
+		prop, known := properties.Lookup(version, key)
+		if !known {
+			prop = properties.Property{Key: key, Type: properties.String}
+		}
+		entries = append(entries, PropertyEntry{Property: prop, Value: value, Present: true, Known: known})
+		seen[key] = true
+	}
+	for _, prop := range properties.Schema(version) {
+		if !seen[prop.Key] && !managedProperty(prop.Key) {
+			entries = append(entries, PropertyEntry{Property: prop, Value: prop.Default, Known: true})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
 
-	for _, line := range lines {
-		if strings.Contains(line, "=") && !strings.HasPrefix(line, "#") {
-			parts := strings.SplitN(line, "=", 2)
-			key := strings.TrimSpace(parts[0])
+// SetServerProperties changes server.properties keys (admins only), except
+// the managed ones. Every value is checked against the version's schema
+// first; nothing is written if one is invalid. Comments and the order of
+// other keys are kept.
+func (a *App) SetServerProperties(serverID string, token string, values map[string]string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
 
-			if newVal, exists := updates[key]; exists {
-				output = append(output, fmt.Sprintf("%s=%s", key, newVal))
-				updatedKeys[key] = true
-				continue
-			}
-		}
-		output = append(output, line)
+	// Permission check: Only owner or admins can modify server options
+	if !a.isAdmin(serverID, username) {
+		return "Error: Only admins can modify server options"
+	}
+
+	file, version, err := a.serverProperties(serverID)
+	if err != nil {
+		return fmt.Sprintf("Error reading file: %v", err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys) // Missing keys are appended in a stable order
 
-	// Append any keys that were missing from the original file
-	for key, val := range updates {
-		if !updatedKeys[key] {
-			output = append(output, fmt.Sprintf("%s=%s", key, val))
+	normalized := make(map[string]string, len(values))
+	for _, key := range keys {
+		if managedProperty(key) {
+			return fmt.Sprintf("Error: %s is set by mc-roam on every start", key)
+		}
+		value := values[key]
+		if prop, ok := properties.Lookup(version, key); ok && prop.Type == properties.Int {
+			for i, name := range legacyNumbers[key] {
+				if value == name {
+					value = strconv.Itoa(i)
+				}
+			}
+		}
+		if err := properties.Validate(version, key, value); err != nil {
+			return "Error: " + err.Error()
 		}
+		// The world folder must stay inside the server folder (export and
+		// import refuse anything else)
+		if key == "level-name" && !plainName(value) {
+			return fmt.Sprintf("Error: level-name must be a single folder name, not %q", value)
+		}
+		normalized[key] = value
+	}
+	for _, key := range keys {
+		file.Set(key, normalized[key])
 	}
 
-	err = os.WriteFile(path, []byte(strings.Join(output, "\n")), 0644)
-	if err != nil {
+	if err := file.Save(filepath.Join(a.getInstancePath(serverID), "server.properties")); err != nil {
 		return fmt.Sprintf("Error saving: %v", err)
 	}
 	return "Success: Settings Saved!"
@@ -0,0 +1,303 @@
+// Package properties reads and edits Java .properties files (server.properties)
+// without disturbing the parts it doesn't touch: comments, blank lines, key
+// order and the original spelling of unchanged entries all survive a round trip.
+package properties
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// line is one logical line of the file. Entries may span several physical
+// lines (a trailing backslash continues the value).
+type line struct {
+	raw   string // Original text, written back as-is unless the entry changed
+	entry bool
+	key   string
+	value string // Decoded value
+	dirty bool
+}
+
+// File is a parsed properties file
+type File struct {
+	lines []*line
+	index map[string]*line // Last occurrence wins, like java.util.Properties
+	crlf  bool
+}
+
+// New returns an empty file
+func New() *File {
+	return &File{index: map[string]*line{}}
+}
+
+// Load reads path. A missing file gives an empty File.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data), nil
+}
+
+// Save writes the file to path
+func (f *File) Save(path string) error {
+	return os.WriteFile(path, f.Bytes(), 0644)
+}
+
+// Parse reads properties text. Minecraft writes UTF-8 (recent versions) or
+// ISO-8859-1 with \uXXXX escapes (older ones); both are accepted.
+func Parse(data []byte) *File {
+	text := string(data)
+	if !utf8.Valid(data) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b) // ISO-8859-1 maps bytes straight to code points
+		}
+		text = string(runes)
+	}
+
+	f := New()
+	f.crlf = strings.Contains(text, "\r\n")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return f
+	}
+
+	physical := strings.Split(text, "\n")
+	for i := 0; i < len(physical); i++ {
+		raw := physical[i]
+		trimmed := strings.TrimLeft(raw, " \t\f")
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			f.lines = append(f.lines, &line{raw: raw})
+			continue
+		}
+
+		// Join continuation lines; leading whitespace of each continuation is dropped
+		logical := trimmed
+		rawParts := []string{raw}
+		for continues(logical) && i+1 < len(physical) {
+			i++
+			rawParts = append(rawParts, physical[i])
+			logical = logical[:len(logical)-1] + strings.TrimLeft(physical[i], " \t\f")
+		}
+		if continues(logical) {
+			logical = logical[:len(logical)-1]
+		}
+
+		key, value := splitEntry(logical)
+		l := &line{raw: strings.Join(rawParts, "\n"), entry: true, key: key, value: value}
+		f.lines = append(f.lines, l)
+		f.index[key] = l
+	}
+	return f
+}
+
+// continues reports whether a line ends in an odd number of backslashes
+func continues(s string) bool {
+	n := 0
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitEntry separates a logical line into its decoded key and value
+func splitEntry(s string) (string, string) {
+	end := len(s)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' {
+			i++ // Escaped character is part of the key
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			end = i
+			break
+		}
+	}
+	key := s[:end]
+	rest := strings.TrimLeft(s[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return unescape(key), unescape(rest)
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 <= len(s) {
+				if code, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+					r := rune(code)
+					i += 4
+					// Characters above U+FFFF are written as two escaped surrogates
+					if utf16.IsSurrogate(r) && i+7 <= len(s) && s[i+1:i+3] == `\u` {
+						if low, err := strconv.ParseUint(s[i+3:i+7], 16, 32); err == nil {
+							if pair := utf16.DecodeRune(r, rune(low)); pair != utf8.RuneError {
+								r = pair
+								i += 6
+							}
+						}
+					}
+					b.WriteRune(r)
+					continue
+				}
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i]) // \\, \=, \:, \# ... stand for themselves
+		}
+	}
+	return b.String()
+}
+
+// escape encodes a key or value the way java.util.Properties.store does.
+// Non-ASCII is written as \uXXXX so every Minecraft version reads it.
+func escape(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			if isKey || (i == 0 && (r == '#' || r == '!')) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		case ' ':
+			if isKey || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, unit := range utf16Units(r) {
+					fmt.Fprintf(&b, `\u%04X`, unit)
+				}
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// utf16Units splits a rune into UTF-16 code units (surrogate pairs above U+FFFF)
+func utf16Units(r rune) []rune {
+	if r < 0x10000 {
+		return []rune{r}
+	}
+	r -= 0x10000
+	return []rune{0xD800 + (r>>10)&0x3FF, 0xDC00 + r&0x3FF}
+}
+
+// Get returns a key's decoded value
+func (f *File) Get(key string) (string, bool) {
+	l, ok := f.index[key]
+	if !ok {
+		return "", false
+	}
+	return l.value, true
+}
+
+// Set changes a key in place, or appends it if the file doesn't have it
+func (f *File) Set(key string, value string) {
+	if l, ok := f.index[key]; ok {
+		if l.value != value {
+			l.value = value
+			l.dirty = true
+		}
+		return
+	}
+	l := &line{entry: true, key: key, value: value, dirty: true}
+	f.lines = append(f.lines, l)
+	f.index[key] = l
+}
+
+// Delete removes every occurrence of a key
+func (f *File) Delete(key string) {
+	if _, ok := f.index[key]; !ok {
+		return
+	}
+	kept := f.lines[:0]
+	for _, l := range f.lines {
+		if !l.entry || l.key != key {
+			kept = append(kept, l)
+		}
+	}
+	f.lines = kept
+	delete(f.index, key)
+}
+
+// Keys lists the keys in file order
+func (f *File) Keys() []string {
+	keys := make([]string, 0, len(f.index))
+	for _, l := range f.lines {
+		if l.entry && f.index[l.key] == l {
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Map returns every key and value
+func (f *File) Map() map[string]string {
+	values := make(map[string]string, len(f.index))
+	for key, l := range f.index {
+		values[key] = l.value
+	}
+	return values
+}
+
+// Bytes renders the file. Untouched lines come out exactly as they were read.
+func (f *File) Bytes() []byte {
+	newline := "\n"
+	if f.crlf {
+		newline = "\r\n"
+	}
+	var b strings.Builder
+	for _, l := range f.lines {
+		text := l.raw
+		if l.dirty {
+			text = escape(l.key, true) + "=" + escape(l.value, false)
+		}
+		b.WriteString(strings.ReplaceAll(text, "\n", newline))
+		b.WriteString(newline)
+	}
+	return []byte(b.String())
+}
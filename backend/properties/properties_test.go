@@ -0,0 +1,130 @@
+package properties
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseValues(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		key  string
+		want string
+	}{
+		{name: "equals", text: "motd=A Minecraft Server\n", key: "motd", want: "A Minecraft Server"},
+		{name: "colon and spaces", text: "motd : hello\n", key: "motd", want: "hello"},
+		{name: "whitespace separator", text: "motd hello world\n", key: "motd", want: "hello world"},
+		{name: "empty value", text: "level-seed=\n", key: "level-seed", want: ""},
+		{name: "escaped colon and equals", text: `motd=a\:b\=c` + "\n", key: "motd", want: "a:b=c"},
+		{name: "escaped separator in key", text: `a\=b\:c=d` + "\n", key: "a=b:c", want: "d"},
+		{name: "escaped space in key", text: `a\ b=c` + "\n", key: "a b", want: "c"},
+		{name: "unicode escape", text: `motd=caf\u00e9` + "\n", key: "motd", want: "café"},
+		{name: "surrogate pair", text: `motd=\uD83D\uDE00` + "\n", key: "motd", want: "😀"},
+		{name: "bad unicode escape", text: `motd=\uZZ` + "\n", key: "motd", want: "uZZ"},
+		{name: "control escapes", text: `motd=a\tb\nc` + "\n", key: "motd", want: "a\tb\nc"},
+		{name: "escaped backslash", text: `motd=a\\b` + "\n", key: "motd", want: `a\b`},
+		{name: "continuation", text: "motd=one \\\n    two\n", key: "motd", want: "one two"},
+		{name: "continuation at end of file", text: "motd=one\\", key: "motd", want: "one"},
+		{name: "escaped backslash doesn't continue", text: "motd=a\\\\\nb=c\n", key: "motd", want: `a\`},
+		{name: "last occurrence wins", text: "pvp=true\npvp=false\n", key: "pvp", want: "false"},
+		{name: "utf-8", text: "motd=café\n", key: "motd", want: "café"},
+		{name: "latin-1", text: "motd=caf\xe9\n", key: "motd", want: "café"},
+		{name: "crlf", text: "motd=hi\r\npvp=true\r\n", key: "motd", want: "hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Parse([]byte(tt.text)).Get(tt.key)
+			if !ok || got != tt.want {
+				t.Errorf("Get(%q) = %q, %v; want %q", tt.key, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	texts := []string{
+		"",
+		"#Minecraft server properties\n#Sat Oct 17 12:00:00 UTC 2026\nmotd=hi\n",
+		"! bang comment\n\n  \nmotd = spaced  \n\tpvp:true\n",
+		"motd=one \\\n    two\nlevel-name=world\n",
+		"motd=caf\\u00e9 \\:\\=\n",
+		"#comment\r\n\r\nmotd=hi\r\n",
+		"custom.plugin-key=1\nmotd=hi\nanother-unknown=x\n",
+	}
+	for _, text := range texts {
+		if got := string(Parse([]byte(text)).Bytes()); got != text {
+			t.Errorf("round trip changed the file:\n%q\ngot\n%q", text, got)
+		}
+	}
+}
+
+func TestKeysKeepFileOrder(t *testing.T) {
+	f := Parse([]byte("zeta=1\n# note\ncustom.plugin=2\nalpha=3\nzeta=4\n"))
+	if got, want := f.Keys(), []string{"custom.plugin", "alpha", "zeta"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys = %v, want %v", got, want)
+	}
+	if got, want := f.Map(), map[string]string{"zeta": "4", "custom.plugin": "2", "alpha": "3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Map = %v, want %v", got, want)
+	}
+}
+
+func TestSetAndDelete(t *testing.T) {
+	text := "#Minecraft server properties\n" +
+		"motd=one \\\n    two\n" +
+		"\n" +
+		"custom.plugin=caf\\u00e9\n" +
+		"pvp=true\n" +
+		"gamemode=survival\n"
+	f := Parse([]byte(text))
+
+	f.Set("pvp", "true") // Unchanged: written as it was
+	f.Set("motd", "a:b c")
+	f.Set("gamemode", "creative")
+	f.Delete("custom.plugin")
+	f.Delete("missing")
+	f.Set("level-name", "wörld")
+	f.Set("key with=sep", "#lead")
+
+	want := "#Minecraft server properties\n" +
+		"motd=a:b c\n" +
+		"\n" +
+		"pvp=true\n" +
+		"gamemode=creative\n" +
+		"level-name=w\\u00F6rld\n" +
+		"key\\ with\\=sep=\\#lead\n"
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("Bytes =\n%s\nwant\n%s", got, want)
+	}
+
+	// What was written reads back the same
+	back := Parse(f.Bytes())
+	for key, value := range f.Map() {
+		if got, _ := back.Get(key); got != value {
+			t.Errorf("%q read back as %q, want %q", key, got, value)
+		}
+	}
+	if _, ok := back.Get("custom.plugin"); ok {
+		t.Error("deleted key is still there")
+	}
+}
+
+func TestSetKeepsCRLF(t *testing.T) {
+	f := Parse([]byte("#c\r\nmotd=hi\r\n"))
+	f.Set("motd", "bye")
+	f.Set("pvp", "false")
+	if got, want := string(f.Bytes()), "#c\r\nmotd=bye\r\npvp=false\r\n"; got != want {
+		t.Errorf("Bytes = %q, want %q", got, want)
+	}
+}
+
+func TestEscapeSurrogates(t *testing.T) {
+	f := New()
+	f.Set("motd", "😀")
+	if got, want := string(f.Bytes()), "motd=\\uD83D\\uDE00\n"; got != want {
+		t.Errorf("Bytes = %q, want %q", got, want)
+	}
+	if got, _ := Parse(f.Bytes()).Get("motd"); got != "😀" {
+		t.Errorf("read back as %q", got)
+	}
+}
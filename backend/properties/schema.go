@@ -0,0 +1,243 @@
+package properties
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Type of a property value
+type Type string
+
+const (
+	Bool   Type = "bool"
+	Int    Type = "int"
+	String Type = "string"
+	Enum   Type = "enum"
+)
+
+// Property describes one vanilla server.properties key
+type Property struct {
+	Key         string   `json:"key"`
+	Type        Type     `json:"type"`
+	Default     string   `json:"default"`
+	Min         *int     `json:"min,omitempty"`
+	Max         *int     `json:"max,omitempty"`
+	Values      []string `json:"values,omitempty"` // Allowed values of an Enum
+	Description string   `json:"description"`
+
+	since string // First Minecraft version with the key ("" = always)
+	until string // First version without it ("" = still there)
+}
+
+func intRange(min, max int) (*int, *int) {
+	return &min, &max
+}
+
+func boolProp(key, def, desc string) Property {
+	return Property{Key: key, Type: Bool, Default: def, Description: desc}
+}
+
+func intProp(key, def string, min, max int, desc string) Property {
+	lo, hi := intRange(min, max)
+	return Property{Key: key, Type: Int, Default: def, Min: lo, Max: hi, Description: desc}
+}
+
+func stringProp(key, def, desc string) Property {
+	return Property{Key: key, Type: String, Default: def, Description: desc}
+}
+
+func enumProp(key, def string, values []string, desc string) Property {
+	return Property{Key: key, Type: Enum, Default: def, Values: values, Description: desc}
+}
+
+func (p Property) since_(v string) Property { p.since = v; return p }
+func (p Property) until_(v string) Property { p.until = v; return p }
+
+const port = 65535
+
+// catalog lists every vanilla key with the versions it exists in
+var catalog = []Property{
+	boolProp("accepts-transfers", "false", "Accept players transferred from another server").since_("1.20.5"),
+	boolProp("allow-flight", "false", "Don't kick players that fly in survival"),
+	boolProp("allow-nether", "true", "Allow travelling to the Nether"),
+	boolProp("announce-player-achievements", "true", "Announce achievements in chat").until_("1.12"),
+	boolProp("broadcast-console-to-ops", "true", "Send console command output to online operators"),
+	boolProp("broadcast-rcon-to-ops", "true", "Send RCON command output to online operators"),
+	stringProp("bug-report-link", "", "Link shown on the disconnect screen").since_("1.21"),
+	enumProp("difficulty", "easy", []string{"peaceful", "easy", "normal", "hard"}, "World difficulty").since_("1.14"),
+	intProp("difficulty", "1", 0, 3, "World difficulty (0 peaceful - 3 hard)").until_("1.14"),
+	boolProp("enable-command-block", "false", "Allow command blocks"),
+	boolProp("enable-jmx-monitoring", "false", "Expose tick timings over JMX").since_("1.16"),
+	boolProp("enable-query", "false", "Answer GameSpy4 status queries"),
+	boolProp("enable-rcon", "false", "Allow remote console access"),
+	boolProp("enable-status", "true", "Show the server as online in the server list").since_("1.16"),
+	boolProp("enforce-secure-profile", "true", "Require signed chat from players").since_("1.19"),
+	boolProp("enforce-whitelist", "false", "Kick players not on the whitelist when it is reloaded"),
+	intProp("entity-broadcast-range-percentage", "100", 10, 1000, "How far away entities are sent to players").since_("1.16"),
+	boolProp("force-gamemode", "false", "Put players in the default game mode every time they join"),
+	intProp("function-permission-level", "2", 1, 4, "Permission level of data pack functions").since_("1.14.4"),
+	enumProp("gamemode", "survival", []string{"survival", "creative", "adventure", "spectator"}, "Default game mode").since_("1.14"),
+	intProp("gamemode", "0", 0, 3, "Default game mode (0 survival, 1 creative, 2 adventure, 3 spectator)").until_("1.14"),
+	boolProp("generate-structures", "true", "Generate villages, temples and other structures"),
+	stringProp("generator-settings", "{}", "Settings for custom world types"),
+	boolProp("hardcore", "false", "Players are banned when they die"),
+	boolProp("hide-online-players", "false", "Hide the player list in server status").since_("1.18"),
+	stringProp("initial-disabled-packs", "", "Data packs not enabled when the world is created").since_("1.19.3"),
+	stringProp("initial-enabled-packs", "vanilla", "Data packs enabled when the world is created").since_("1.19.3"),
+	stringProp("level-name", "world", "World folder name"),
+	stringProp("level-seed", "", "Seed for new worlds (empty = random)"),
+	stringProp("level-type", "minecraft:normal", "World preset, e.g. minecraft:flat").since_("1.19"),
+	stringProp("level-type", "default", "World type, e.g. flat or amplified").until_("1.19"),
+	boolProp("log-ips", "true", "Write player IP addresses to the log").since_("1.20.2"),
+	intProp("max-chained-neighbor-updates", "1000000", math.MinInt32, math.MaxInt32, "Limit on consecutive block updates (negative = no limit)").since_("1.19"),
+	intProp("max-players", "20", 0, math.MaxInt32, "Maximum number of players online"),
+	intProp("max-tick-time", "60000", -1, math.MaxInt32, "Milliseconds a tick may take before the watchdog stops the server (-1 = off)"),
+	intProp("max-world-size", "29999984", 1, 29999984, "World border radius in blocks"),
+	stringProp("motd", "A Minecraft Server", "Message shown in the server list"),
+	intProp("network-compression-threshold", "256", -1, math.MaxInt32, "Compress packets larger than this (-1 = never)"),
+	boolProp("online-mode", "true", "Check players against Minecraft accounts"),
+	intProp("op-permission-level", "4", 0, 4, "Permission level of operators"),
+	intProp("pause-when-empty-seconds", "60", math.MinInt32, math.MaxInt32, "Pause the server after it is empty this long (0 or less = never)").since_("1.21.2"),
+	intProp("player-idle-timeout", "0", 0, math.MaxInt32, "Kick players idle this many minutes (0 = never)"),
+	boolProp("prevent-proxy-connections", "false", "Kick players whose connection doesn't match their account's ISP"),
+	boolProp("pvp", "true", "Players can hurt each other"),
+	intProp("query.port", "25565", 1, port, "Port for status queries"),
+	intProp("rate-limit", "0", 0, math.MaxInt32, "Kick players sending more packets per second (0 = no limit)"),
+	stringProp("rcon.password", "", "Remote console password"),
+	intProp("rcon.port", "25575", 1, port, "Remote console port"),
+	enumProp("region-file-compression", "deflate", []string{"deflate", "lz4", "none"}, "Compression of region files").since_("1.20.5"),
+	boolProp("require-resource-pack", "false", "Kick players that decline the resource pack"),
+	stringProp("resource-pack", "", "URL of the server resource pack"),
+	stringProp("resource-pack-id", "", "UUID of the resource pack").since_("1.20.3"),
+	stringProp("resource-pack-prompt", "", "Message shown when asking for the resource pack").since_("1.17"),
+	stringProp("resource-pack-sha1", "", "SHA-1 of the resource pack"),
+	stringProp("server-ip", "", "Address to bind to (empty = all)"),
+	intProp("server-port", "25565", 1, port, "Port players connect to"),
+	intProp("simulation-distance", "10", 3, 32, "Chunks around players that are ticked").since_("1.18"),
+	boolProp("snooper-enabled", "true", "Send usage statistics to Mojang").until_("1.18"),
+	boolProp("spawn-animals", "true", "Spawn animals").until_("1.21.2"),
+	boolProp("spawn-monsters", "true", "Spawn monsters"),
+	boolProp("spawn-npcs", "true", "Spawn villagers").until_("1.21.2"),
+	intProp("spawn-protection", "16", 0, math.MaxInt32, "Radius around spawn only operators can build in (0 = off)"),
+	boolProp("sync-chunk-writes", "true", "Write chunks synchronously").since_("1.16"),
+	stringProp("text-filtering-config", "", "Chat filtering configuration").since_("1.17"),
+	boolProp("use-native-transport", "true", "Use Linux's faster networking"),
+	intProp("view-distance", "10", 3, 32, "Chunks sent to players"),
+	boolProp("white-list", "false", "Only whitelisted players may join"),
+}
+
+// Schema returns the keys a Minecraft version knows, sorted by key.
+// An unparseable version (snapshots, "latest") gets the newest schema.
+func Schema(version string) []Property {
+	var props []Property
+	for _, p := range catalog {
+		if p.in(version) {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// Lookup finds one key in a version's schema
+func Lookup(version string, key string) (Property, bool) {
+	for _, p := range catalog {
+		if p.Key == key && p.in(version) {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+func (p Property) in(version string) bool {
+	if p.since != "" && compareVersions(version, p.since) < 0 {
+		return false
+	}
+	if p.until != "" && compareVersions(version, p.until) >= 0 {
+		return false
+	}
+	return true
+}
+
+// Validate checks a value against the key's type. Keys the schema doesn't
+// know (mods, plugins, future versions) accept any single-line value.
+func Validate(version string, key string, value string) error {
+	if key == "" || strings.ContainsAny(key, "\r\n") {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%s: value can't contain line breaks", key)
+	}
+	p, ok := Lookup(version, key)
+	if !ok {
+		return nil
+	}
+	return p.Check(value)
+}
+
+// Check validates a value for this property
+func (p Property) Check(value string) error {
+	switch p.Type {
+	case Bool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s must be true or false, not %q", p.Key, value)
+		}
+	case Int:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number, not %q", p.Key, value)
+		}
+		if (p.Min != nil && int(n) < *p.Min) || (p.Max != nil && int(n) > *p.Max) {
+			return fmt.Errorf("%s must be between %d and %d", p.Key, *p.Min, *p.Max)
+		}
+	case Enum:
+		for _, v := range p.Values {
+			if v == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %s", p.Key, strings.Join(p.Values, ", "))
+	}
+	return nil
+}
+
+// compareVersions orders release versions like 1.20.4; anything that isn't
+// one sorts after every release
+func compareVersions(a, b string) int {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return 1
+	case !okB:
+		return -1
+	}
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	parts := strings.Split(strings.TrimSpace(v), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return out, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestApp returns an App on newTestStore with a session for alice (admin)
+// and bob (member). The server's instance folder is removed afterwards.
+func newTestApp(t *testing.T) (a *App, alice string, bob string) {
+	t.Helper()
+	t.Setenv("MC_ROAM_SESSION_SECRET", "test-secret") // Keep session.key out of the data dir
+	store := newTestStore(t)
+	if err := store.AddMember(context.Background(), "srv", "bob"); err != nil {
+		t.Fatal(err)
+	}
+	a = NewHeadlessApp(store, NewEventBus())
+	t.Cleanup(func() { os.RemoveAll(a.getInstancePath("srv")) })
+
+	var err error
+	if alice, err = a.createSession("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if bob, err = a.createSession("bob"); err != nil {
+		t.Fatal(err)
+	}
+	return a, alice, bob
+}
+
+func writeServerProperties(t *testing.T, a *App, text string) string {
+	t.Helper()
+	dir := a.getInstancePath("srv")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "server.properties")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGetServerPropertiesHidesManagedKeys(t *testing.T) {
+	a, _, bob := newTestApp(t)
+	writeServerProperties(t, a, "#comment\n"+
+		"server-port=25565\nquery.port=25565\nenable-rcon=true\nrcon.port=25575\nrcon.password=secret\n"+
+		"broadcast-rcon-to-ops=false\nmotd=hi\ncustom.plugin-key=1\n")
+
+	entries := a.GetServerProperties("srv", bob)
+	values := map[string]PropertyEntry{}
+	for _, entry := range entries {
+		if managedProperty(entry.Key) {
+			t.Errorf("managed key %s listed", entry.Key)
+		}
+		values[entry.Key] = entry
+	}
+	if e := values["motd"]; e.Value != "hi" || !e.Present || !e.Known {
+		t.Errorf("motd = %+v", e)
+	}
+	if e := values["custom.plugin-key"]; e.Value != "1" || !e.Present || e.Known {
+		t.Errorf("custom.plugin-key = %+v", e)
+	}
+	if e, ok := values["pvp"]; !ok || e.Present {
+		t.Errorf("pvp should be listed with its default, got %+v", e)
+	}
+
+	if got := a.GetServerProperties("srv", "not-a-token"); len(got) != 0 {
+		t.Errorf("listed %d keys without a session", len(got))
+	}
+}
+
+func TestSetServerProperties(t *testing.T) {
+	const original = "#Minecraft server properties\n\nrcon.password=secret\nmotd=hi\ncustom.plugin-key=1\npvp=true\n"
+
+	tests := []struct {
+		name    string
+		member  bool
+		values  map[string]string
+		wantErr string
+		want    string
+	}{
+		{
+			name:   "keeps comments and unknown keys",
+			values: map[string]string{"motd": "bye", "gamemode": "creative"},
+			want:   "#Minecraft server properties\n\nrcon.password=secret\nmotd=bye\ncustom.plugin-key=1\npvp=true\ngamemode=creative\n",
+		},
+		{name: "members can't", member: true, values: map[string]string{"motd": "bye"}, wantErr: "Only admins"},
+		{name: "rcon password", values: map[string]string{"rcon.password": "x"}, wantErr: "set by mc-roam"},
+		{name: "rcon port", values: map[string]string{"motd": "bye", "rcon.port": "1"}, wantErr: "set by mc-roam"},
+		{name: "enable-rcon", values: map[string]string{"enable-rcon": "false"}, wantErr: "set by mc-roam"},
+		{name: "server port", values: map[string]string{"server-port": "1"}, wantErr: "set by mc-roam"},
+		{name: "query port", values: map[string]string{"query.port": "1"}, wantErr: "set by mc-roam"},
+		{name: "level-name outside the server", values: map[string]string{"level-name": "../other"}, wantErr: "single folder name"},
+		{name: "level-name with a slash", values: map[string]string{"level-name": "worlds/a"}, wantErr: "single folder name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, alice, bob := newTestApp(t)
+			path := writeServerProperties(t, a, original)
+			token := alice
+			if tt.member {
+				token = bob
+			}
+
+			result := a.SetServerProperties("srv", token, tt.values)
+			want := tt.want
+			if tt.wantErr != "" {
+				if !strings.HasPrefix(result, "Error") || !strings.Contains(result, tt.wantErr) {
+					t.Fatalf("got %q, want an error about %q", result, tt.wantErr)
+				}
+				want = original // Nothing is written
+			} else if !strings.HasPrefix(result, "Success") {
+				t.Fatalf("got %q", result)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != want {
+				t.Errorf("server.properties =\n%s\nwant\n%s", data, want)
+			}
+		})
+	}
+}
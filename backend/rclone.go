@@ -1,14 +1,12 @@
 package backend
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
-
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 // SyncDirection defines if we are Pulling (Down) or Pushing (Up)
@@ -19,9 +17,8 @@ const (
 	SyncUp   SyncDirection = "up"   // Local -> Cloud
 )
 
-// RunSync executes the Rclone command and streams output to UI
-func (a *App) RunSync(direction SyncDirection, remotePath string, localPath string) error {
-	rcloneBin := getToolPath("rclone.exe")
+// runSync executes the Rclone command and streams output to UI
+func (a *App) runSync(direction SyncDirection, remotePath string, localPath string) error {
 	var source, dest string
 	remoteName := "mc-remote:" + remotePath
 
@@ -33,12 +30,30 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 		source = remoteName
 		dest = localPath
 		logMsg = "⬇️ STARTING DOWNLOAD: Cloud ➔ Local"
-		statusMsg = "[Sync]: STATUS: ⬇️ Downloading Server Data... DO NOT CLOSE!"
+		statusMsg = "STATUS: ⬇️ Downloading Server Data... DO NOT CLOSE!"
 	} else {
 		source = localPath
 		dest = remoteName
 		logMsg = "☁️ STARTING UPLOAD: Local ➔ Cloud"
-		statusMsg = "[Sync]: STATUS: ☁️ Uploading Server Data... DO NOT CLOSE!"
+		statusMsg = "STATUS: ☁️ Uploading Server Data... DO NOT CLOSE!"
+	}
+
+	// Sync events carry the direction & phase so the UI doesn't parse text
+	serverID := serverIDFromRemote(remotePath)
+	syncEvent := func(phase string, severity Severity, message string) {
+		a.publish(Event{
+			Topic:    TopicSync,
+			Source:   SourceRclone,
+			ServerID: serverID,
+			Severity: severity,
+			Message:  message,
+			Payload:  SyncProgress{Direction: direction, Phase: phase, Percent: syncPercent(message)},
+		})
+	}
+	emitLine := func(line string) {
+		if !shouldSuppressSyncLog(line) {
+			syncEvent("progress", SeverityInfo, line)
+		}
 	}
 
 	// 1. Log to Main Terminal (Permanent History)
@@ -46,7 +61,7 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 	a.Log("⚠️ DO NOT CLOSE THE APP OR TURN OFF PC")
 
 	// 2. Trigger Sticky Footer Immediately (Transient Status)
-	a.Log(statusMsg)
+	syncEvent("start", SeverityInfo, statusMsg)
 
 	// --- FIX 1: Ensure folder exists with proper delay ---
 	EnsureLocalFolder(localPath)
@@ -63,14 +78,6 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 		"--stats", "2s", // Increased from 1s to reduce overhead
 		"--stats-one-line",
 		"--transfers", "4", // Reduced from 8 to prevent Windows handle exhaustion
-		"--config", getRcloneConfig(),
-		"--exclude", "session.lock",
-		"--exclude", "logs/**",
-		"--exclude", "cache/**",
-		"--exclude", "libraries/**",
-		"--exclude", "versions/**",
-		"--exclude", "crash-reports/**",
-		"--exclude", "playit.toml", // NEVER sync - stored in user's DB instead
 		// --- FIX 2: Windows-specific flags to prevent hangs ---
 		"--no-traverse",        // Don't traverse the entire tree first
 		"--fast-list",          // Use recursive list if available
@@ -79,9 +86,12 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 		"--contimeout", "60s", // Connection timeout
 		// ------------------------------------------------------
 	}
+	args = append(args, syncExcludes()...)
 
-	cmd := exec.Command(rcloneBin, args...)
-	prepareCommand(cmd)
+	cmd, err := a.rcloneCommand(serverIDFromRemote(remotePath), args...)
+	if err != nil {
+		return err
+	}
 
 	// --- FIX 3: Use non-blocking pipes ---
 	stdout, err := cmd.StdoutPipe()
@@ -110,18 +120,9 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 			if n > 0 {
 				for i := 0; i < n; i++ {
 					char := buf[i]
-					if char == '\r' {
-						if line != "" {
-							if !shouldSuppressSyncLog(line) {
-								a.Log("[Sync]: " + line)
-							}
-							line = ""
-						}
-					} else if char == '\n' {
+					if char == '\r' || char == '\n' {
 						if line != "" {
-							if !shouldSuppressSyncLog(line) {
-								a.Log("[Sync]: " + line)
-							}
+							emitLine(line)
 							line = ""
 						}
 					} else {
@@ -131,9 +132,7 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 			}
 			if err != nil {
 				if line != "" {
-					if !shouldSuppressSyncLog(line) {
-						a.Log("[Sync]: " + line)
-					}
+					emitLine(line)
 				}
 				break
 			}
@@ -151,7 +150,7 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 					char := buf[i]
 					if char == '\n' {
 						if line != "" {
-							a.Log("[Sync Error]: " + line)
+							syncEvent("progress", SeverityError, line)
 							line = ""
 						}
 					} else {
@@ -161,7 +160,7 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 			}
 			if err != nil {
 				if line != "" {
-					a.Log("[Sync Error]: " + line)
+					syncEvent("progress", SeverityError, line)
 				}
 				break
 			}
@@ -179,10 +178,12 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 	// ----------------------------------------------------
 
 	if err := cmd.Wait(); err != nil {
+		syncEvent("error", SeverityError, "")
 		return fmt.Errorf("sync failed: %w", err)
 	}
 
 	close(done)
+	syncEvent("done", SeverityInfo, "")
 
 	// 6. Success Message
 	if direction == SyncDown {
@@ -194,6 +195,27 @@ func (a *App) RunSync(direction SyncDirection, remotePath string, localPath stri
 	return nil
 }
 
+// rcloneCommand builds an rclone command for one server group.
+// The group's decrypted credentials are passed as environment variables and
+// "--config" points at the null device, so rclone never reads or writes a config file.
+func (a *App) rcloneCommand(serverID string, args ...string) (*exec.Cmd, error) {
+	env, err := a.remoteEnv(serverID)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "--config", os.DevNull)
+	cmd := exec.Command(getToolPath("rclone"), args...)
+	cmd.Env = append(os.Environ(), env...)
+	prepareCommand(cmd)
+	return cmd, nil
+}
+
+// serverIDFromRemote extracts "srv_123" from a "server-srv_123" remote folder
+func serverIDFromRemote(remotePath string) string {
+	folder, _, _ := strings.Cut(remotePath, "/")
+	return strings.TrimPrefix(folder, "server-")
+}
+
 // EnsureLocalFolder makes sure the 'world' folder exists before we try to sync to it
 func EnsureLocalFolder(path string) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -201,24 +223,36 @@ func EnsureLocalFolder(path string) {
 	}
 }
 
-// PurgeRemote completely deletes a folder from the cloud
-func (a *App) PurgeRemote(remotePath string) error {
+// purgeRemote completely deletes a folder from the cloud
+func (a *App) purgeRemote(remotePath string) error {
 	// rclone purge mc-remote:server-srv_123 ...
 	remoteName := "mc-remote:" + remotePath
 	a.Log(fmt.Sprintf("🔥 Deleting Cloud Data: %s", remoteName))
 
-	args := []string{
-		"purge", remoteName,
-		"--config", getRcloneConfig(),
+	cmd, err := a.rcloneCommand(serverIDFromRemote(remotePath), "purge", remoteName)
+	if err != nil {
+		return err
 	}
 
-	cmd := exec.Command(getToolPath("rclone.exe"), args...)
-	prepareCommand(cmd)
-
 	// We don't need to stream logs for this, just run it
 	return cmd.Run()
 }
 
+// syncPercent pulls the overall percentage out of an rclone stats line,
+// e.g. "1.2 MiB / 10 MiB, 12%, 300 KiB/s, ETA 30s". It returns -1 if none.
+func syncPercent(line string) int {
+	for _, field := range strings.Split(line, ",") {
+		field = strings.TrimSpace(field)
+		if !strings.HasSuffix(field, "%") {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(field, "%")); err == nil {
+			return n
+		}
+	}
+	return -1
+}
+
 // Helper to filter out misleading rclone log lines
 func shouldSuppressSyncLog(line string) bool {
 	// Suppress --no-traverse warning and similar non-critical lines
@@ -228,45 +262,44 @@ func shouldSuppressSyncLog(line string) bool {
 	return false
 }
 
-// CheckCloudExists checks if a remote folder exists in the cloud
-func (a *App) CheckCloudExists(folderName string) bool {
+// checkCloudExists checks if a remote folder exists in the cloud
+func (a *App) checkCloudExists(folderName string) bool {
 	fullPath := "mc-remote:" + folderName
-	cmd := exec.Command(getToolPath("rclone.exe"), "lsd", fullPath, "--config", getRcloneConfig())
+	cmd, err := a.rcloneCommand(serverIDFromRemote(folderName), "lsd", fullPath)
+	if err != nil {
+		a.Log("⚠️ " + err.Error())
+		return false
+	}
 	if err := cmd.Run(); err != nil {
 		return false
 	}
 	return true
 }
 
-// ForceSyncUp is called after setup to ensure config files are saved to cloud
-func (a *App) ForceSyncUp(serverID string) string {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	var server ServerGroup
-	err := collection.FindOne(ctx, bson.M{"_id": serverID}).Decode(&server)
+// ForceSyncUp is called after setup to ensure config files are saved to cloud (Admins only)
+func (a *App) ForceSyncUp(serverID string, token string) string {
+	username, err := a.authenticate(token)
 	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if !a.isAdmin(serverID, username) {
+		return "Error: Only admins can overwrite the cloud copy"
+	}
+	if _, err := a.getServer(serverID); err != nil {
 		return "Error: Server not found"
 	}
 
 	localPath := a.getInstancePath(serverID)
-	remotePath := "server-" + serverID
 
 	a.Log("☁️ Uploading Initial Configuration...")
-	syncErr := a.RunSync(SyncUp, remotePath, localPath)
+	syncErr := a.syncUp(serverID, localPath, "force-sync", false)
 	status := "ok"
 	if syncErr != nil {
 		status = "error"
 	}
-	updateSync := bson.M{
-		"$set": bson.M{
-			"last_sync_status": status,
-			"last_sync_user":   "force-sync",
-			"last_sync_time":   time.Now(),
-		},
-	}
-	_, _ = collection.UpdateOne(ctx, bson.M{"_id": serverID}, updateSync)
+	ctx, cancel := dbContext()
+	defer cancel()
+	_ = a.store.SetSyncStatus(ctx, serverID, status, "force-sync", time.Now())
 	if syncErr != nil {
 		return "Error syncing: " + syncErr.Error()
 	}
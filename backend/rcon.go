@@ -0,0 +1,273 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"mc-roam/backend/properties"
+)
+
+// RCON packet types (Source RCON protocol, as implemented by Minecraft)
+const (
+	rconTypeResponse = 0
+	rconTypeCommand  = 2
+	rconTypeAuth     = 3
+)
+
+// rconMaxPayload is the largest packet we accept (Minecraft sends at most 4096 bytes of body)
+const rconMaxPayload = 4096 + 10
+
+// rconTimeout bounds dialing and every command round trip
+const rconTimeout = 5 * time.Second
+
+// Legacy formatting codes some servers put in replies, e.g. "§aDone"
+var formatCodeRe = regexp.MustCompile(`§[0-9a-fk-orA-FK-OR]`)
+
+// RCONClient is a connection to a server's remote console. Unlike stdin it
+// returns each command's output. Safe for concurrent use.
+type RCONClient struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	nextID  int32
+	timeout time.Duration
+}
+
+// DialRCON connects and logs in
+func DialRCON(addr string, password string, timeout time.Duration) (*RCONClient, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &RCONClient{conn: conn, nextID: 1, timeout: timeout}
+
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	id := c.id()
+	if err := c.write(id, rconTypeAuth, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// The server answers a login with the request id, or -1 if the password is wrong.
+	// Some implementations send an empty response packet first.
+	replyID, kind, _, err := c.read()
+	if err == nil && kind == rconTypeResponse {
+		replyID, _, _, err = c.read()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if replyID == -1 || replyID != id {
+		conn.Close()
+		return nil, fmt.Errorf("rcon login rejected")
+	}
+	return c, nil
+}
+
+// Command runs a console command and returns what the server replied
+func (c *RCONClient) Command(command string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+
+	// Long replies are split over several packets with no end marker, so we
+	// follow the command with a bogus request; its answer marks the end.
+	id, marker := c.id(), c.id()
+	if err := c.write(id, rconTypeCommand, command); err != nil {
+		return "", err
+	}
+	if err := c.write(marker, rconTypeResponse, ""); err != nil {
+		return "", err
+	}
+
+	var reply bytes.Buffer
+	for {
+		replyID, _, body, err := c.read()
+		if err != nil {
+			return "", err
+		}
+		switch replyID {
+		case id:
+			reply.WriteString(body)
+		case marker:
+			return formatCodeRe.ReplaceAllString(reply.String(), ""), nil
+		case -1:
+			return "", fmt.Errorf("rcon session is not authenticated")
+		}
+	}
+}
+
+// Close ends the connection
+func (c *RCONClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *RCONClient) id() int32 {
+	id := c.nextID
+	c.nextID++
+	return id
+}
+
+// write sends one packet: length, id, type, body, two NUL bytes
+func (c *RCONClient) write(id int32, kind int32, body string) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, kind)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	_, err := c.conn.Write(buf.Bytes())
+	return err
+}
+
+// read receives one packet
+func (c *RCONClient) read() (id int32, kind int32, body string, err error) {
+	var size int32
+	if err = binary.Read(c.conn, binary.LittleEndian, &size); err != nil {
+		return
+	}
+	if size < 10 || size > rconMaxPayload {
+		err = fmt.Errorf("rcon: bad packet size %d", size)
+		return
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(c.conn, data); err != nil {
+		return
+	}
+	id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	kind = int32(binary.LittleEndian.Uint32(data[4:8]))
+	body = string(bytes.TrimRight(data[8:], "\x00"))
+	return
+}
+
+// rconSession is the RCON endpoint of one running server. The password is
+// new every time the server starts.
+type rconSession struct {
+	addr     string
+	password string
+
+	mu     sync.Mutex
+	client *RCONClient
+}
+
+var (
+	rconMu       sync.Mutex
+	rconSessions = map[string]*rconSession{}
+)
+
+// enableRcon turns RCON on in server.properties with a fresh random password
+func (a *App) enableRcon(serverID string, serverDir string) error {
+	port, err := freeLocalPort()
+	if err != nil {
+		return err
+	}
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	password := hex.EncodeToString(secret)
+
+	err = setServerProperties(serverDir, map[string]string{
+		"enable-rcon":           "true",
+		"rcon.port":             strconv.Itoa(port),
+		"rcon.password":         password,
+		"broadcast-rcon-to-ops": "false",
+	})
+	if err != nil {
+		return err
+	}
+
+	rconMu.Lock()
+	rconSessions[serverID] = &rconSession{addr: fmt.Sprintf("127.0.0.1:%d", port), password: password}
+	rconMu.Unlock()
+	return nil
+}
+
+// disableRcon closes the session and takes the password out of
+// server.properties so it is never synced to the cloud
+func (a *App) disableRcon(serverID string, serverDir string) {
+	rconMu.Lock()
+	session := rconSessions[serverID]
+	delete(rconSessions, serverID)
+	rconMu.Unlock()
+	forgetLiveGameRules(serverID)
+
+	if session != nil {
+		session.mu.Lock()
+		if session.client != nil {
+			session.client.Close()
+			session.client = nil
+		}
+		session.mu.Unlock()
+	}
+
+	setServerProperties(serverDir, map[string]string{
+		"enable-rcon":   "false",
+		"rcon.password": "",
+	})
+}
+
+// rconCommand runs a command over RCON, connecting on first use. It fails
+// while the server is still starting (RCON opens after "Done").
+func (a *App) rconCommand(serverID string, command string) (string, error) {
+	rconMu.Lock()
+	session := rconSessions[serverID]
+	rconMu.Unlock()
+	if session == nil {
+		return "", fmt.Errorf("rcon is not enabled")
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.client == nil {
+		client, err := DialRCON(session.addr, session.password, rconTimeout)
+		if err != nil {
+			return "", err
+		}
+		session.client = client
+	}
+
+	reply, err := session.client.Command(command)
+	if err != nil {
+		// Drop the broken connection; the next command redials
+		session.client.Close()
+		session.client = nil
+		return "", err
+	}
+	return reply, nil
+}
+
+// DialInstanceRCON connects to a server running from serverDir on this PC,
+// using the RCON port and password its host wrote into server.properties
+func DialInstanceRCON(serverDir string) (*RCONClient, error) {
+	props, err := properties.Load(filepath.Join(serverDir, "server.properties"))
+	if err != nil {
+		return nil, err
+	}
+	enabled, _ := props.Get("enable-rcon")
+	password, _ := props.Get("rcon.password")
+	port, _ := props.Get("rcon.port")
+	if enabled != "true" || password == "" {
+		return nil, fmt.Errorf("the server is not running on this PC (RCON is off)")
+	}
+	return DialRCON("127.0.0.1:"+port, password, rconTimeout)
+}
+
+// freeLocalPort asks the OS for an unused TCP port
+func freeLocalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,239 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeRCON is a local RCON server that speaks the protocol the way
+// Minecraft does: replies longer than chunk bytes are split over
+// several packets, and unknown packet types get an "Unknown request" reply.
+type fakeRCON struct {
+	password   string
+	chunk      int
+	emptyFirst bool // send an empty response before the login reply, as some servers do
+	replies    map[string]string
+
+	listener net.Listener
+	logins   atomic.Int32
+	commands atomic.Int32
+}
+
+func newFakeRCON(t *testing.T, password string) *fakeRCON {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRCON{password: password, chunk: 4096, replies: map[string]string{}, listener: l}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRCON) addr() string {
+	return f.listener.Addr().String()
+}
+
+func (f *fakeRCON) serve(conn net.Conn) {
+	defer conn.Close()
+	authed := false
+	for {
+		var size int32
+		if err := binary.Read(conn, binary.LittleEndian, &size); err != nil {
+			return
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			return
+		}
+		id := int32(binary.LittleEndian.Uint32(data[0:4]))
+		kind := int32(binary.LittleEndian.Uint32(data[4:8]))
+		body := string(bytes.TrimRight(data[8:], "\x00"))
+
+		switch {
+		case kind == rconTypeAuth:
+			f.logins.Add(1)
+			if f.emptyFirst {
+				writeRCONPacket(conn, id, rconTypeResponse, "")
+			}
+			if body != f.password {
+				writeRCONPacket(conn, -1, rconTypeCommand, "")
+				continue
+			}
+			authed = true
+			writeRCONPacket(conn, id, rconTypeCommand, "")
+		case !authed:
+			writeRCONPacket(conn, -1, rconTypeResponse, "")
+		case kind == rconTypeCommand:
+			f.commands.Add(1)
+			if body == "drop" {
+				return
+			}
+			reply := f.replies[body]
+			for {
+				n := min(len(reply), f.chunk)
+				writeRCONPacket(conn, id, rconTypeResponse, reply[:n])
+				reply = reply[n:]
+				if reply == "" {
+					break
+				}
+			}
+		default:
+			writeRCONPacket(conn, id, rconTypeResponse, fmt.Sprintf("Unknown request %x", kind))
+		}
+	}
+}
+
+func writeRCONPacket(w io.Writer, id int32, kind int32, body string) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, kind)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	w.Write(buf.Bytes())
+}
+
+func TestDialRCONLogin(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		emptyFirst bool
+		wantErr    bool
+	}{
+		{name: "minecraft login", password: "secret"},
+		{name: "empty packet before login reply", password: "secret", emptyFirst: true},
+		{name: "wrong password", password: "guess", wantErr: true},
+		{name: "wrong password after empty packet", password: "guess", emptyFirst: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeRCON(t, "secret")
+			f.emptyFirst = tt.emptyFirst
+			c, err := DialRCON(f.addr(), tt.password, time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DialRCON error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if c != nil {
+				c.Close()
+			}
+		})
+	}
+}
+
+func TestRCONCommandReplies(t *testing.T) {
+	f := newFakeRCON(t, "secret")
+	f.chunk = 100
+	long := strings.Repeat("minecraft:stone, ", 40)
+	f.replies["list"] = "There are 1 of a max of 20 players online: Steve"
+	f.replies["gamerule keepInventory"] = "Gamerule keepInventory is currently set to: §atrue§r"
+	f.replies["data get block"] = long
+
+	c, err := DialRCON(f.addr(), "secret", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"list", "There are 1 of a max of 20 players online: Steve"},
+		{"gamerule keepInventory", "Gamerule keepInventory is currently set to: true"},
+		{"data get block", long},
+		{"say hi", ""},
+	}
+	for _, tt := range tests {
+		got, err := c.Command(tt.command)
+		if err != nil {
+			t.Fatalf("Command(%q): %v", tt.command, err)
+		}
+		if got != tt.want {
+			t.Errorf("Command(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestRCONCommandRedialsAfterDrop(t *testing.T) {
+	f := newFakeRCON(t, "secret")
+	f.replies["list"] = "There are 0 of a max of 20 players online:"
+
+	a := &App{}
+	serverID := "rcon-test"
+	rconMu.Lock()
+	rconSessions[serverID] = &rconSession{addr: f.addr(), password: "secret"}
+	rconMu.Unlock()
+	t.Cleanup(func() {
+		rconMu.Lock()
+		delete(rconSessions, serverID)
+		rconMu.Unlock()
+	})
+
+	if _, err := a.rconCommand(serverID, "list"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.rconCommand(serverID, "drop"); err == nil {
+		t.Fatal("a dropped connection did not fail the command")
+	}
+	got, err := a.rconCommand(serverID, "list")
+	if err != nil {
+		t.Fatalf("command after a drop: %v", err)
+	}
+	if got != f.replies["list"] {
+		t.Errorf("reply = %q", got)
+	}
+	if f.logins.Load() != 2 {
+		t.Errorf("%d logins, want 2 (one reconnect)", f.logins.Load())
+	}
+
+	if _, err := a.rconCommand("not-running", "list"); err == nil {
+		t.Error("a server without an RCON session accepted a command")
+	}
+}
+
+func TestDialInstanceRCON(t *testing.T) {
+	f := newFakeRCON(t, "secret")
+	_, port, _ := net.SplitHostPort(f.addr())
+
+	tests := []struct {
+		name    string
+		props   string
+		wantErr bool
+	}{
+		{name: "rcon on", props: "enable-rcon=true\nrcon.port=" + port + "\nrcon.password=secret\n"},
+		{name: "rcon off", props: "enable-rcon=false\nrcon.port=" + port + "\nrcon.password=secret\n", wantErr: true},
+		{name: "password cleared on stop", props: "enable-rcon=true\nrcon.port=" + port + "\nrcon.password=\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "server.properties"), []byte(tt.props), 0644)
+			c, err := DialInstanceRCON(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DialInstanceRCON error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if c != nil {
+				c.Close()
+			}
+		})
+	}
+}
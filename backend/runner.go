@@ -1,21 +1,18 @@
 package backend
 
 import (
-	"bufio"
 	"fmt"
-	"io"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
-)
 
-// Global variable for the current session
-var activeCmd *exec.Cmd
-var stdinPipe io.WriteCloser
+	"mc-roam/backend/properties"
+)
 
 // GetFreePort tries 25565 first, then falls back to a random available port
 func GetFreePort() (int, error) {
@@ -39,220 +36,227 @@ func GetFreePort() (int, error) {
 	return l.Addr().(*net.TCPAddr).Port, nil
 }
 
-// UpdateServerProperties forces the server-port setting
-func (a *App) UpdateServerProperties(serverDir string, port int) error {
+// updateServerProperties forces the server-port setting
+func (a *App) updateServerProperties(serverDir string, port int) error {
+	return setServerProperties(serverDir, map[string]string{
+		"server-port": strconv.Itoa(port),
+		"query.port":  strconv.Itoa(port),
+	})
+}
+
+// setServerProperties overwrites keys in server.properties, appending the ones
+// that are missing. The file is created if the server hasn't made one yet;
+// Minecraft fills in the rest on first boot.
+func setServerProperties(serverDir string, values map[string]string) error {
 	propsPath := filepath.Join(serverDir, "server.properties")
-	content, err := os.ReadFile(propsPath)
+	file, err := properties.Load(propsPath)
 	if err != nil {
-		return err // Might not exist yet, that's fine
+		return err
 	}
 
-	lines := strings.Split(string(content), "\n")
-	var newLines []string
-	found := false
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "server-port=") {
-			newLines = append(newLines, fmt.Sprintf("server-port=%d", port))
-			found = true
-		} else if strings.HasPrefix(line, "query.port=") {
-			newLines = append(newLines, fmt.Sprintf("query.port=%d", port))
-		} else {
-			newLines = append(newLines, line)
-		}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
 	}
-	if !found {
-		newLines = append(newLines, fmt.Sprintf("server-port=%d", port))
+	sort.Strings(keys)
+	for _, key := range keys {
+		file.Set(key, values[key])
 	}
-	return os.WriteFile(propsPath, []byte(strings.Join(newLines, "\n")), 0644)
+	return file.Save(propsPath)
 }
 
-// FindProcessLockingFile uses Windows handle.exe to find what's locking a file
-func (a *App) FindProcessLockingFile(filePath string) []int {
-	// Try using PowerShell's Get-Process with handle checking
-	cmd := exec.Command("powershell", "-Command",
-		fmt.Sprintf(`Get-Process | Where-Object {$_.Modules.FileName -like "*%s*"} | Select-Object -ExpandProperty Id`, filepath.Base(filePath)))
-
-	output, err := cmd.CombinedOutput()
+// findProcessLockingFile lists the processes that have a file open
+func (a *App) findProcessLockingFile(filePath string) []int {
+	pids, err := fileLockers(filePath)
 	if err != nil {
 		return nil
 	}
-
-	var pids []int
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if pid, err := strconv.Atoi(line); err == nil && pid > 0 {
-			pids = append(pids, pid)
-		}
-	}
 	return pids
 }
 
-// KillProcessesLockingLogs finds and kills any Java processes holding log files
-func (a *App) KillProcessesLockingLogs(serverDir string) {
+// killProcessesLockingLogs finds and kills any Java processes holding log files
+func (a *App) killProcessesLockingLogs(serverDir string) {
 	logFile := filepath.Join(serverDir, "logs", "latest.log")
+	locked := []string{
+		logFile,
+		filepath.Join(serverDir, "logs", "latest.log.lck"),
+		filepath.Join(serverDir, "world", "session.lock"),
+	}
 
-	// Method 1: Kill all java.exe processes in this directory
-	cmd := exec.Command("powershell", "-Command",
-		fmt.Sprintf(`Get-Process java -ErrorAction SilentlyContinue | Where-Object {$_.Path -like "*%s*"} | Stop-Process -Force`,
-			strings.ReplaceAll(serverDir, "\\", "\\\\")))
-
-	output, err := cmd.CombinedOutput()
-	if err == nil {
-		a.Log("🧹 Killed lingering Java processes")
-	} else {
-		a.Log(fmt.Sprintf("⚠️ Java cleanup: %s", string(output)))
+	killed := false
+	for _, path := range locked {
+		for _, pid := range a.findProcessLockingFile(path) {
+			// Only Java; never kill e.g. an editor that has the log open
+			name, _, err := processInfo(pid)
+			if err != nil || !isJavaName(name) || pid == os.Getpid() {
+				continue
+			}
+			a.Log(fmt.Sprintf("🧹 Killing lingering Java process (PID: %d) holding %s", pid, filepath.Base(path)))
+			killProcessTree(pid)
+			killed = true
+		}
 	}
 
-	// Method 2: Try to delete the log file (might fail, that's OK)
+	// Try to delete the log file (might fail, that's OK)
 	os.Remove(logFile)
 
-	time.Sleep(2 * time.Second) // Wait for handles to release
+	if killed {
+		time.Sleep(2 * time.Second) // Wait for handles to release
+	}
 }
 
-// ForceKillPort finds any process listening on the given port and kills it
-func (a *App) ForceKillPort(port int) {
-	// Command: netstat -ano | findstr :<PORT>
-	cmd := exec.Command("cmd", "/C", fmt.Sprintf("netstat -ano | findstr :%d", port))
-	output, err := cmd.CombinedOutput()
-
-	if err != nil || len(output) == 0 {
+// forceKillPort finds any process listening on the given port and kills it
+func (a *App) forceKillPort(port int) {
+	pids, err := listeningPIDs(port)
+	if err != nil || len(pids) == 0 {
 		return // Port is free
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		parts := strings.Fields(line)
-		// Look for lines ending with PID
-		if len(parts) >= 5 && strings.Contains(parts[1], fmt.Sprintf(":%d", port)) {
-			pidStr := parts[len(parts)-1]
-			pid, err := strconv.Atoi(pidStr)
-			if err == nil && pid > 0 {
-				a.Log(fmt.Sprintf("☢️ Port %d is occupied by PID %d. Killing it...", port, pid))
-				exec.Command("taskkill", "/F", "/PID", pidStr).Run()
-			}
+	for _, pid := range pids {
+		if pid <= 0 || pid == os.Getpid() {
+			continue
 		}
+		a.Log(fmt.Sprintf("☢️ Port %d is occupied by PID %d. Killing it...", port, pid))
+		killProcessTree(pid)
 	}
 	time.Sleep(1 * time.Second) // Wait for release
 }
 
-// RunMinecraftServer launches the server on a dynamic port and streams logs
-func (a *App) RunMinecraftServer(serverDir string, port int) error {
+// runMinecraftServer launches the server on a dynamic port and streams logs.
+// javaPath is the runtime picked for the server's Minecraft version.
+func (a *App) runMinecraftServer(serverID string, port int, javaPath string) error {
+	serverDir := a.getInstancePath(serverID)
+
+	// 0. Never launch a second copy of the same server
+	if a.procs.IsRunning(serverID) {
+		return fmt.Errorf("server is already running on this PC")
+	}
+
 	// 1. KILL ZOMBIE FIRST
-	a.KillZombie(serverDir)
+	a.killZombie(serverDir)
 
 	// 2. KILL LINGERING JAVA PROCESSES HOLDING LOG FILES
-	a.KillProcessesLockingLogs(serverDir)
+	a.killProcessesLockingLogs(serverDir)
 
 	// 3. CLEAN FILE LOCKS
-	a.CleanLocks(serverDir)
+	a.cleanLocks(serverDir)
 
 	// 4. FORCE KILL PORT
-	a.ForceKillPort(port)
+	a.forceKillPort(port)
 
 	// 5. Wait longer for OS to fully release resources
 	time.Sleep(2 * time.Second)
 
 	// 6. Set Port in Config
-	err := a.UpdateServerProperties(serverDir, port)
+	err := a.updateServerProperties(serverDir, port)
 	if err != nil {
 		a.Log(fmt.Sprintf("⚠️ Failed to update port: %v", err))
 	}
-
-	jarName := "server.jar"
-	if _, err := os.Stat(filepath.Join(serverDir, jarName)); os.IsNotExist(err) {
-		return fmt.Errorf("server.jar not found")
+	if err := a.enableRcon(serverID, serverDir); err != nil {
+		a.Log(fmt.Sprintf("⚠️ Failed to enable RCON, commands will not return output: %v", err))
 	}
 
-	// 7. Command
-	cmd := exec.Command("java", "-Xmx2G", "-Xms2G", "-jar", jarName, "nogui")
+	// 7. Command, from the group's launch profile
+	args := a.launchArgs(serverID)
+	if jar := launchTarget(args); jar != "" {
+		if _, err := os.Stat(filepath.Join(serverDir, jar)); os.IsNotExist(err) {
+			return fmt.Errorf("%s not found", jar)
+		}
+	}
+	cmd := exec.Command(javaPath, args...)
 	cmd.Dir = serverDir
+	prepareCommand(cmd)
 
-	// 8. Get stdin pipe for graceful shutdown
-	stdin, err := cmd.StdinPipe()
+	// 8. Hand the process to the supervisor (stdin, log streaming, PID file)
+	live := newLiveServer(serverID)
+	proc, err := a.procs.Start(serverID, cmd, port, a.forwardConsole, a.watchLog(live))
 	if err != nil {
-		return err
-	}
-	stdinPipe = stdin
-
-	// 9. LOG STREAMING MAGIC - Capture BOTH stdout and stderr
-	stdout, _ := cmd.StdoutPipe()
-	stderr, _ := cmd.StderrPipe()
-
-	if err := cmd.Start(); err != nil {
+		a.disableRcon(serverID, serverDir)
 		return err
 	}
 
-	// Stream stdout in background
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			text := scanner.Text()
-			// Print to backend console for debugging
-			fmt.Println("[MC]", text)
-			// Send to frontend
-			a.Log("[MC]: " + text)
-		}
-	}()
+	a.Log(fmt.Sprintf("✅ Minecraft Server Started on Port %d (PID: %d)", port, proc.PID))
 
-	// Stream stderr in background (for errors/warnings)
 	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			text := scanner.Text()
-			// Print to backend console
-			fmt.Println("[MC-ERR]", text)
-			// Send to frontend with error prefix
-			a.Log("[MC]: " + text)
+		<-proc.Done()
+		a.disableRcon(serverID, serverDir)
+		a.finishLog(serverID, live)
+		a.Log("🛑 Minecraft Server Exited.")
+		if proc.Crashed() {
+			a.setState(serverID, StateCrashed)
+		} else if proc.Quit() {
+			a.setState(serverID, StateExited)
 		}
 	}()
 
-	activeCmd = cmd
-	a.Log(fmt.Sprintf("✅ Minecraft Server Started on Port %d (PID: %d)", port, cmd.Process.Pid))
-
-	// 10. Save PID
-	pidFile := filepath.Join(serverDir, "server.pid")
-	os.WriteFile(pidFile, []byte(strconv.Itoa(cmd.Process.Pid)), 0644)
+	return nil
+}
 
-	go func() {
-		cmd.Wait()
-		a.Log("🛑 Minecraft Server Exited.")
-		activeCmd = nil
-		stdinPipe = nil
-		os.Remove(pidFile)
-	}()
+// launchTarget returns the jar or argument file java will load
+func launchTarget(args []string) string {
+	for i, arg := range args {
+		if arg == "-jar" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "@") {
+			return arg[1:]
+		}
+	}
+	return ""
+}
 
-	return nil
+// forwardConsole publishes server output as console events
+func (a *App) forwardConsole(serverID string, line string, isErr bool) {
+	e := Event{
+		Topic:    TopicConsole,
+		Source:   SourceMinecraft,
+		ServerID: serverID,
+		Message:  line,
+		Payload:  ConsoleLine{Stream: "stdout"},
+	}
+	if isErr {
+		e.Severity = SeverityWarn
+		e.Payload = ConsoleLine{Stream: "stderr"}
+	}
+	a.publish(e)
 }
 
-// KillZombie reads the pid file and forces the process to die
-func (a *App) KillZombie(serverDir string) {
+// killZombie reads the pid file and forces the process to die, but only if
+// that PID still belongs to the Java server we started
+func (a *App) killZombie(serverDir string) {
 	pidPath := filepath.Join(serverDir, "server.pid")
-	data, err := os.ReadFile(pidPath)
-	if err != nil {
-		return
-	}
-	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	id, err := readPIDFile(pidPath)
 	if err != nil {
 		return
 	}
+	defer os.Remove(pidPath)
 
-	a.Log(fmt.Sprintf("🧟 Found Zombie Process (PID: %d). Killing it...", pid))
+	if !isOurJava(id) {
+		return // Already gone, or the PID was reused by another program
+	}
 
-	// Try graceful shutdown first
-	exec.Command("taskkill", "/PID", strconv.Itoa(pid)).Run()
-	time.Sleep(3 * time.Second)
+	a.Log(fmt.Sprintf("🧟 Found Zombie Process (PID: %d). Killing it...", id.PID))
 
-	// Force kill if still alive
-	exec.Command("taskkill", "/F", "/PID", strconv.Itoa(pid)).Run()
+	// Try graceful shutdown first, then force kill the whole tree
+	stopProcessTree(id.PID, 3*time.Second)
 	time.Sleep(1 * time.Second)
+}
 
-	os.Remove(pidPath)
+// KillZombie kills a server left running by a crashed host process on this PC
+func (a *App) KillZombie(serverID string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if !a.isMember(serverID, username) {
+		return "Error: You are not a member of this server"
+	}
+	a.killZombie(a.getInstancePath(serverID))
+	return "Success"
 }
 
-// CleanLocks deletes files that cause "FileSystemException"
-func (a *App) CleanLocks(serverDir string) {
+// cleanLocks deletes files that cause "FileSystemException"
+func (a *App) cleanLocks(serverDir string) {
 	targets := []string{
 		filepath.Join(serverDir, "world", "session.lock"),
 		filepath.Join(serverDir, "world_nether", "session.lock"),
@@ -274,34 +278,22 @@ func (a *App) CleanLocks(serverDir string) {
 	a.Log("✅ Lock cleanup complete")
 }
 
-// KillMinecraftServer (Manual Stop from UI) - GRACEFUL SHUTDOWN
-func (a *App) KillMinecraftServer() error {
-	if activeCmd != nil && activeCmd.Process != nil {
-		a.Log("🛑 Gracefully stopping Minecraft server...")
-
-		// Send "stop" command to the server (graceful shutdown)
-		if stdinPipe != nil {
-			stdinPipe.Write([]byte("stop\n"))
-			stdinPipe.Close()
-
-			// Wait up to 10 seconds for graceful shutdown
-			done := make(chan error, 1)
-			go func() {
-				done <- activeCmd.Wait()
-			}()
-
-			select {
-			case <-done:
-				a.Log("✅ Server stopped gracefully")
-				return nil
-			case <-time.After(10 * time.Second):
-				a.Log("⚠️ Graceful shutdown timed out, force killing...")
-				return activeCmd.Process.Kill()
-			}
-		}
+// killMinecraftServer (Manual Stop from UI) - GRACEFUL SHUTDOWN
+func (a *App) killMinecraftServer(serverID string) error {
+	if !a.procs.IsRunning(serverID) {
+		return nil
+	}
+	a.Log("🛑 Gracefully stopping Minecraft server...")
 
-		// Fallback to force kill
-		return activeCmd.Process.Kill()
+	// Send "stop" and wait up to 10 seconds before force killing
+	graceful, err := a.procs.Stop(serverID, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	if graceful {
+		a.Log("✅ Server stopped gracefully")
+	} else {
+		a.Log("⚠️ Graceful shutdown timed out, force killed")
 	}
 	return nil
 }
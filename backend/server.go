@@ -1,9 +1,8 @@
 package backend
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"go.mongodb.org/mongo-driver/bson"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,28 +10,48 @@ import (
 )
 
 // CreateServer creates a new server group (UPDATED)
-func (a *App) CreateServer(serverName string, serverType string, version string, ownerUsername string, configString string) string {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (a *App) CreateServer(serverName string, serverType string, version string, token string, configString string) string {
+	ownerUsername, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx, cancel := dbContext()
 	defer cancel()
 
 	newID := fmt.Sprintf("srv_%d", time.Now().UnixNano())
+	inviteCode := generateInviteCode()
+
+	// Encrypt the cloud keys with a new group key. Only members get a share of it.
+	if _, err := rcloneEnv(configString); err != nil {
+		return "Error: " + err.Error()
+	}
+	encConfig, shares, invite, _, err := a.sealCredentials(newID, []string{ownerUsername}, configString, inviteCode)
+	if err != nil {
+		return "Error: Could not encrypt cloud credentials"
+	}
+	if _, ok := shares[ownerUsername]; !ok {
+		return "Error: Your account has no keys yet. Log out and log in again."
+	}
 
 	newServer := ServerGroup{
-		ID:           newID,
-		Name:         serverName,
-		Type:         serverType, // <--- SAVE TYPE (e.g., "Paper", "Vanilla")
-		Version:      version,    // <--- SAVE VERSION (e.g., "1.20.4")
-		OwnerID:      ownerUsername,
-		Members:      []string{ownerUsername},
-		InviteCode:   generateInviteCode(),
-		RcloneConfig: configString, // <--- SAVE THE KEYS
+		ID:                    newID,
+		Name:                  serverName,
+		Type:                  serverType, // <--- SAVE TYPE (e.g., "Paper", "Vanilla")
+		Version:               version,    // <--- SAVE VERSION (e.g., "1.20.4")
+		OwnerID:               ownerUsername,
+		Members:               []string{ownerUsername},
+		EncryptedRcloneConfig: encConfig, // <--- SAVE THE KEYS (encrypted)
+		KeyShares:             shares,
+		InviteHash:            invite.Hash,
+		EncryptedInviteCode:   invite.EncryptedCode,
+		InviteKeyShare:        invite.KeyShare,
 		Lock: ServerLock{
 			IsRunning: false,
 		},
 	}
 
-	_, err := collection.InsertOne(ctx, newServer)
+	err = a.store.CreateServer(ctx, newServer)
 	if err != nil {
 		return fmt.Sprintf("Error: Failed to create server: %v", err)
 	}
@@ -40,14 +59,17 @@ func (a *App) CreateServer(serverName string, serverType string, version string,
 }
 
 // DeleteServer removes the server from DB, Local Disk, and Cloud
-func (a *App) DeleteServer(serverID string, username string) string {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (a *App) DeleteServer(serverID string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx, cancel := dbContext()
 	defer cancel()
 
 	// 1. Fetch Server to check ownership
-	var serverDoc ServerGroup
-	err := collection.FindOne(ctx, bson.M{"_id": serverID}).Decode(&serverDoc)
+	serverDoc, err := a.store.GetServer(ctx, serverID)
 	if err != nil {
 		return "Error: Server not found."
 	}
@@ -63,13 +85,16 @@ func (a *App) DeleteServer(serverID string, username string) string {
 		return "Error: Stop the server before deleting it."
 	}
 
-	// 4. Delete from Database
-	_, err = collection.DeleteOne(ctx, bson.M{"_id": serverID})
+	// 4. Unlock cloud keys now, the DB record (and key shares) are about to go
+	_, envErr := a.remoteEnv(serverID)
+
+	// 5. Delete from Database
+	err = a.store.DeleteServer(ctx, serverID)
 	if err != nil {
 		return "Error: Failed to delete from DB."
 	}
 
-	// 5. Delete Local Files
+	// 6. Delete Local Files
 	localPath := a.getInstancePath(serverID)
 	err = os.RemoveAll(localPath)
 	if err != nil {
@@ -78,10 +103,15 @@ func (a *App) DeleteServer(serverID string, username string) string {
 		a.Log("🗑️ Deleted local files.")
 	}
 
-	// 6. Delete Cloud Files (Background)
+	// 7. Delete Cloud Files (Background)
+	if envErr != nil {
+		a.Log("⚠️ Cloud files were not deleted: " + envErr.Error())
+		return "Success"
+	}
 	go func() {
+		defer a.forgetRemote(serverID)
 		// Matches the folder name format used in your rclone sync
-		err := a.PurgeRemote("server-" + serverID)
+		err := a.purgeRemote("server-" + serverID)
 		if err != nil {
 			a.Log("⚠️ Failed to delete cloud files: " + err.Error())
 		} else {
@@ -93,39 +123,44 @@ func (a *App) DeleteServer(serverID string, username string) string {
 }
 
 // GetMyServers returns a list of servers the user belongs to
-func (a *App) GetMyServers(username string) []ServerGroup {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// Find servers where 'members' array contains 'username'
-	filter := bson.M{"members": username}
-	cursor, err := collection.Find(ctx, filter)
+func (a *App) GetMyServers(token string) []ServerGroup {
+	username, err := a.authenticate(token)
 	if err != nil {
 		return []ServerGroup{}
 	}
 
-	var servers []ServerGroup
-	if err = cursor.All(ctx, &servers); err != nil {
+	ctx, cancel := dbContext()
+	defer cancel()
+
+	// Find servers where 'members' array contains 'username'
+	servers, err := a.store.ListServersForMember(ctx, username)
+	if err != nil || servers == nil {
 		return []ServerGroup{}
 	}
 
 	for i := range servers {
 		servers[i].Owner = servers[i].OwnerID
+		servers[i].InviteCode = a.inviteCode(servers[i])
 	}
 	return servers
 }
 
 // JoinServer adds the user to a server using an invite code
-func (a *App) JoinServer(inviteCode string, username string) string {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (a *App) JoinServer(inviteCode string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx, cancel := dbContext()
 	defer cancel()
 
-	// 1. Find the server with this code
-	var server ServerGroup
-	err := collection.FindOne(ctx, bson.M{"invite_code": inviteCode}).Decode(&server)
+	// 1. Find the server with this code (only its hash is stored)
+	hash, err := inviteHash(inviteCode)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	server, err := a.store.GetServerByInvite(ctx, hash)
 	if err != nil {
 		return "Error: Invalid invite code"
 	}
@@ -138,63 +173,62 @@ func (a *App) JoinServer(inviteCode string, username string) string {
 	}
 
 	// 3. Add user to members
-	update := bson.M{"$push": bson.M{"members": username}}
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": server.ID}, update)
+	err = a.store.AddMember(ctx, server.ID, username)
 	if err != nil {
 		return "Error: Failed to join server"
 	}
-	return "Success: Joined server!"
-}
 
-func generateInviteCode() string {
-	// Simple timestamp-based unique string for now
-	return fmt.Sprintf("%d", time.Now().UnixNano())[10:]
+	// 4. The invite code unlocks the group key; re-share it to the new member's key
+	if server.InviteKeyShare != "" {
+		if err := a.grantShareFromInvite(server, inviteCode, username); err != nil {
+			a.Log("⚠️ Joined, but could not unlock cloud credentials: " + err.Error())
+			a.Log("ℹ️ You'll get access the next time another member starts the server.")
+		}
+	}
+	return "Success: Joined server!"
 }
 
 // StartServer attempts to acquire the lock for a server
-func (a *App) StartServer(serverID string, username string) string {
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+func (a *App) StartServer(serverID string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
 
 	// 1. Fetch Server from DB
-	var serverDoc ServerGroup
-	err := collection.FindOne(ctx, bson.M{"_id": serverID}).Decode(&serverDoc)
+	serverDoc, err := a.getServer(serverID)
 	if err != nil {
 		return "Error: Server not found."
 	}
+	if !a.isMember(serverID, username) {
+		return "Error: You are not a member of this server"
+	}
 
-	// --- INJECT SHARED CLOUD CREDENTIALS ---
-	// If the current user doesn't have an rclone.conf,
-	// create it using the credentials stored in the Server Object.
-	// This allows Friends to access the Host's Drive without logging in.
+	// The last session's upload never landed; starting now would play (and
+	// later overwrite) the older cloud copy
+	if serverDoc.Lock.PendingUpload {
+		return fmt.Sprintf("Error: %s's last session is still waiting to upload. It is retried automatically; the owner can abandon it.", serverDoc.Lock.HostedBy)
+	}
 
-	// Check if local config exists
-	if _, err := os.Stat("rclone.conf"); os.IsNotExist(err) {
-		if serverDoc.RcloneConfig != "" {
-			a.Log("🔑 Applying Shared Cloud Credentials...")
-			// Write the stored config to a local file
-			err := os.WriteFile("rclone.conf", []byte(serverDoc.RcloneConfig), 0644)
-			if err != nil {
-				return "Error writing shared credentials: " + err.Error()
-			}
-			a.Log("✅ Cloud credentials configured successfully!")
-		} else {
-			return "Error: No cloud credentials found for this server."
-		}
-	} else {
-		// Config exists, but ensure it's up to date with server's config
-		if serverDoc.RcloneConfig != "" {
-			err = a.InjectConfig(serverDoc.RcloneConfig)
-			if err != nil {
-				return "Error: Failed to inject cloud keys."
-			}
-		} else {
-			return "Error: This server has no Cloud Config set up!"
-		}
+	// --- UNLOCK SHARED CLOUD CREDENTIALS ---
+	// The group's rclone config is decrypted with our key share and handed
+	// to rclone through its environment. Nothing is written to disk.
+	if _, err := a.remoteEnv(serverID); err != nil {
+		return "Error: " + err.Error()
 	}
+	a.Log("🔑 Shared cloud credentials unlocked.")
 	// ---------------------------------------------
 
+	// 2. Pick a Java that can run this version (may download one).
+	// Done before locking so a missing runtime doesn't hold the server hostage.
+	javaPath, err := a.ensureJava(serverDoc.Version)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx, cancel := dbContext()
+	defer cancel()
+
 	// 3. Lock the Database
 	// --- NEW: PICK DYNAMIC PORT FIRST ---
 	port, err := GetFreePort()
@@ -203,54 +237,68 @@ func (a *App) StartServer(serverID string, username string) string {
 		port = 25565
 	}
 
-	filter := bson.M{"_id": serverID, "lock.is_running": false}
-	update := bson.M{
-		"$set": bson.M{
-			"lock.is_running": true,
-			"lock.hosted_by":  username,
-			"lock.hosted_at":  time.Now(),
-			"lock.port":       port, // Save the assigned port
-		},
+	now := time.Now()
+	lock := ServerLock{
+		IsRunning:   true,
+		HostedBy:    username,
+		HostedAt:    now,
+		HeartbeatAt: now,
+		Port:        port, // Save the assigned port
 	}
-	result, err := collection.UpdateOne(ctx, filter, update)
+
+	// --- LEASE TAKEOVER ---
+	// If the previous host stopped sending heartbeats (crash, power loss),
+	// take over the lock instead of waiting forever.
+	acquired, takeover, err := a.claimLock(ctx, serverID, serverDoc.Lock, lock)
 	if err != nil {
 		return "Error: Database connection failed"
 	}
-	if result.ModifiedCount == 0 {
+	if !acquired {
 		return "Error: Server is already running (Locked by someone else)!"
 	}
 
+	if takeover {
+		a.Log(fmt.Sprintf("⚠️ Took over an expired lock from %s (no heartbeat since %s).",
+			serverDoc.Lock.HostedBy, serverDoc.Lock.HeartbeatAt.Format(time.RFC1123)))
+		a.Log("⚠️ The last session may not have been uploaded. Recent progress could be missing.")
+	}
+
 	// --- PATH CALCULATION ---
 	localInstance := a.getInstancePath(serverID)
 	remoteFolder := "server-" + serverID
 
 	// 4. Pre-Check Cloud Status
-	if !a.CheckCloudExists(remoteFolder) {
+	if !a.checkCloudExists(remoteFolder) {
 		a.forceUnlock(serverID)
 		return "Error: directory not found (setup required)"
 	}
 
 	// 5. Trigger Sync Down
+	a.setState(serverID, StateSyncingDown)
 	a.Log("🔄 Syncing (down)...")
 	// Clean locks BEFORE sync to avoid Access Denied errors
-	a.CleanLocks(localInstance)
+	a.cleanLocks(localInstance)
 
-	err = a.RunSync(SyncDown, remoteFolder, localInstance)
+	err = a.syncDown(serverID, localInstance)
 	if err != nil {
 		a.forceUnlock(serverID)
 		return fmt.Sprintf("Error: Sync failed: %v", err)
 	}
 
-	// 5.5. Check if server.jar exists (First-time setup check)
-	serverJarPath := filepath.Join(localInstance, "server.jar")
-	if _, err := os.Stat(serverJarPath); os.IsNotExist(err) {
+	// 5.5. Check if the server is installed (First-time setup check)
+	if !serverInstalled(localInstance) {
 		a.Log("📦 First-time setup detected. Downloading server files...")
-		installResult := a.InstallServer(serverID)
+		a.setState(serverID, StateInstalling)
+		installResult := a.installLatest(serverID)
 		if !strings.HasPrefix(installResult, "Success") {
 			a.forceUnlock(serverID)
 			return "Error: Installation failed: " + installResult
 		}
 		a.Log("✅ Server installation completed successfully!")
+	} else if err := a.ensureLoader(localInstance, javaPath); err != nil {
+		// Forge/NeoForge libraries aren't synced; this host rebuilds them
+		a.forceUnlock(serverID)
+		return "Error: Could not set up the server files: " + err.Error()
 	}
 
 	// 6. Deploy User's Playit Config (if exists)
@@ -262,19 +310,26 @@ func (a *App) StartServer(serverID string, username string) string {
 
 	// 7. Launch Game with specific Port
 	a.Log(fmt.Sprintf("🚀 Starting Server on Port %d...", port))
-	err = a.RunMinecraftServer(localInstance, port)
+	a.setState(serverID, StateStarting)
+	err = a.runMinecraftServer(serverID, port, javaPath)
 	if err != nil {
-		a.StopServer(serverID, username)
+		a.stopServer(serverID, username)
 		return fmt.Sprintf("Error: Failed to launch: %v", err)
 	}
 
+	// 7.5. Keep the lease alive while the server runs
+	a.startHeartbeat(serverID, username)
+
 	// 8. Start Playit Tunnel if config was deployed
 	if _, err := os.Stat(playitConfigPath); err == nil {
-		a.Log("🔗 Playit config deployed. Starting tunnel in 3 seconds...")
-		// Delay to ensure server and network are fully ready
+		a.Log("🔗 Playit config deployed. Tunnel will start once the server is ready...")
 		go func() {
-			time.Sleep(3 * time.Second)
-			a.StartPlayitTunnel(serverID)
+			// World generation can take minutes on first boot
+			if !a.waitReady(serverID, serverReadyTimeout) {
+				a.Log("⚠️ Server did not report ready. Skipping tunnel setup.")
+				return
+			}
+			a.startPlayitTunnel(serverID)
 		}()
 	}
 
@@ -283,71 +338,93 @@ func (a *App) StartServer(serverID string, username string) string {
 }
 
 // StopServer syncs data BACK to the specific cloud folder
-func (a *App) StopServer(serverID string, username string) string {
+func (a *App) StopServer(serverID string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	// Checked before anything is killed: only the host may end a session
+	doc, err := a.getServer(serverID)
+	if err != nil {
+		return "Error: Server not found."
+	}
+	if !a.isMember(serverID, username) {
+		return "Error: You are not a member of this server"
+	}
+	if doc.Lock.IsRunning && doc.Lock.HostedBy != username {
+		return fmt.Sprintf("Error: %s is hosting this server; only they can stop it.", doc.Lock.HostedBy)
+	}
+	return a.stopServer(serverID, username)
+}
+
+// stopServer does the actual stop & upload for an authenticated host
+func (a *App) stopServer(serverID string, username string) string {
 
 	// Paths
 	localInstance := a.getInstancePath(serverID)
-	remoteFolder := "server-" + serverID
 
 	// 1. Kill Process & Tunnel
-	a.KillMinecraftServer()
-	a.StopTunnel()
+	a.setState(serverID, StateStopping)
+	a.stopHeartbeat(serverID)
+	a.killMinecraftServer(serverID)
+	a.stopTunnel(serverID)
 	time.Sleep(2 * time.Second) // Wait for file locks to release
 
-	collection := DB.Client.Database("mc_roam").Collection("servers")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	defer cancel()
-
 	// 2. Verify Host
-	filter := bson.M{
-		"_id":             serverID,
-		"lock.is_running": true,
-		"lock.hosted_by":  username,
-	}
-	var doc ServerGroup
-	err := collection.FindOne(ctx, filter).Decode(&doc)
-	if err != nil {
+	doc, err := a.getServer(serverID)
+	if err != nil || !doc.Lock.IsRunning || doc.Lock.HostedBy != username {
+		a.setState(serverID, StateStopped)
 		return "Error: You are not the host, or server is already stopped."
 	}
+	defer a.setState(serverID, StateStopped)
 
 	// 3. Sync Up (Push)
 	// Check if the instance folder exists locally
 	if _, err := os.Stat(localInstance); err == nil {
 		a.Log("🚀 Starting Upload (Sync Up)...")
+		a.setState(serverID, StateSyncingUp)
 
-		// Syncing: ./instances/123 -> server-123 (Cloud)
-		syncErr := a.RunSync(SyncUp, remoteFolder, localInstance)
+		// Syncing: ./instances/123 -> server-123 (Cloud), keeping the
+		// previous cloud state as a snapshot
+		syncErr := a.syncUp(serverID, localInstance, username, true)
 		status := "ok"
+		var conflict *SyncConflictError
+		if errors.As(syncErr, &conflict) {
+			// The cloud is untouched and newer, so others can keep playing it.
+			// Release the lock; this copy stays on disk until it's saved or discarded.
+			a.Log("❌ " + conflict.Error())
+			a.Log("💡 Your copy was NOT uploaded. You can save it as a snapshot instead.")
+			ctx, cancel := dbContext()
+			_ = a.store.SetSyncStatus(ctx, serverID, "conflict", username, time.Now())
+			a.store.ReleaseLock(ctx, serverID, username)
+			cancel()
+			return "Error: " + conflict.Error()
+		}
 		if syncErr != nil {
 			status = "error"
-			a.Log("❌ Upload failed! Data NOT saved. (" + syncErr.Error() + ")")
+			a.Log("❌ Upload failed! (" + syncErr.Error() + ")")
 		} else {
 			a.Log("✅ Upload Complete!")
+			if err := a.pruneSnapshots(serverID); err != nil {
+				a.Log("⚠️ Snapshot cleanup failed: " + err.Error())
+			}
 		}
 		// Update sync state in DB
-		updateSync := bson.M{
-			"$set": bson.M{
-				"last_sync_status": status,
-				"last_sync_user":   username,
-				"last_sync_time":   time.Now(),
-			},
-		}
-		_, _ = collection.UpdateOne(ctx, bson.M{"_id": serverID}, updateSync)
+		ctx, cancel := dbContext()
+		_ = a.store.SetSyncStatus(ctx, serverID, status, username, time.Now())
+		cancel()
 		if syncErr != nil {
-			return fmt.Sprintf("Error: Upload failed! Data NOT saved. (%v)", syncErr)
+			// Keep the lock so nobody plays the older cloud copy, and
+			// keep trying in the background (also after a restart)
+			a.queueUpload(serverID, doc.Lock, localInstance, syncErr)
+			return fmt.Sprintf("Error: Upload failed! Your progress is kept on this PC and will be uploaded automatically. (%v)", syncErr)
 		}
 	}
 
 	// 4. Release Lock
-	update := bson.M{
-		"$set": bson.M{
-			"lock.is_running": false,
-			"lock.hosted_by":  "",
-			"lock.hosted_at":  time.Time{},
-			"lock.port":       0,
-		},
-	}
-	_, err = collection.UpdateOne(ctx, filter, update)
+	ctx, cancel := dbContext()
+	defer cancel()
+	_, err = a.store.ReleaseLock(ctx, serverID, username)
 	if err != nil {
 		return "Error: Database update failed (but files were synced!)"
 	}
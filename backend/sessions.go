@@ -0,0 +1,229 @@
+package backend
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionSecret signs session tokens. Injected at build time via ldflags
+// (or MC_ROAM_SESSION_SECRET). Without it, a random key is generated once
+// and kept next to the executable.
+var SessionSecret string
+
+// sessionTTL is how long a login stays valid
+const sessionTTL = 7 * 24 * time.Hour
+
+// Session is a login stored in the sessions store.
+// Only the SHA-256 of the token is stored, never the token itself.
+type Session struct {
+	ID        string    `bson:"_id" json:"id"`
+	Username  string    `bson:"username" json:"username"`
+	TokenHash string    `bson:"token_hash" json:"-"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// sessionClaims is the signed payload inside a token
+type sessionClaims struct {
+	SessionID string `json:"sid"`
+	Username  string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+var (
+	sessionKeyOnce sync.Once
+	sessionKey     []byte
+)
+
+// getSessionKey returns the HMAC key used to sign tokens
+func getSessionKey() []byte {
+	sessionKeyOnce.Do(func() {
+		secret := os.Getenv("MC_ROAM_SESSION_SECRET")
+		if secret == "" {
+			secret = SessionSecret // Injected at build time via ldflags
+		}
+		if secret != "" {
+			sessionKey = []byte(secret)
+			return
+		}
+
+		// Fallback: a per-install random key
+		keyPath := filepath.Join(ensureDataDir(), "session.key")
+		if data, err := os.ReadFile(keyPath); err == nil && len(data) >= 32 {
+			sessionKey = data
+			return
+		}
+		sessionKey = make([]byte, 32)
+		rand.Read(sessionKey)
+		os.WriteFile(keyPath, sessionKey, 0600)
+	})
+	return sessionKey
+}
+
+// randomHex returns n random bytes as a hex string
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// hashToken returns the value we store for a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// signToken encodes and signs the claims: base64(payload) + "." + base64(hmac)
+func signToken(claims sessionClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, getSessionKey())
+	mac.Write(payload)
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(mac.Sum(nil)), nil
+}
+
+// parseToken checks the signature and expiry of a token
+func parseToken(token string) (sessionClaims, error) {
+	var claims sessionClaims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return claims, fmt.Errorf("invalid session")
+	}
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return claims, fmt.Errorf("invalid session")
+	}
+	sig, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return claims, fmt.Errorf("invalid session")
+	}
+
+	mac := hmac.New(sha256.New, getSessionKey())
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return claims, fmt.Errorf("invalid session")
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, fmt.Errorf("invalid session")
+	}
+	if time.Now().Unix() > claims.ExpiresAt {
+		return claims, fmt.Errorf("session expired, please log in again")
+	}
+	return claims, nil
+}
+
+// createSession issues a new token for a user and stores its hash
+func (a *App) createSession(username string) (string, error) {
+	now := time.Now()
+	claims := sessionClaims{
+		SessionID: randomHex(16),
+		Username:  username,
+		ExpiresAt: now.Add(sessionTTL).Unix(),
+	}
+	token, err := signToken(claims)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := dbContext()
+	defer cancel()
+
+	err = a.store.CreateSession(ctx, Session{
+		ID:        claims.SessionID,
+		Username:  username,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// authenticate turns a session token into the username it was issued to.
+// Every bound method that acts on behalf of a user goes through this.
+func (a *App) authenticate(token string) (string, error) {
+	if token == "" {
+		return "", fmt.Errorf("not logged in")
+	}
+	claims, err := parseToken(token)
+	if err != nil {
+		return "", err
+	}
+
+	// Make sure the session hasn't been revoked
+	ctx, cancel := dbContext()
+	defer cancel()
+
+	session, err := a.store.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		return "", fmt.Errorf("session revoked, please log in again")
+	}
+	if !hmac.Equal([]byte(session.TokenHash), []byte(hashToken(token))) || session.Username != claims.Username {
+		return "", fmt.Errorf("invalid session")
+	}
+	return session.Username, nil
+}
+
+// GetSessionUser returns the username behind a token, or "" if it isn't valid
+func (a *App) GetSessionUser(token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return ""
+	}
+	return username
+}
+
+// Logout revokes the current session
+func (a *App) Logout(token string) string {
+	claims, err := parseToken(token)
+	if err != nil {
+		return "Success: Logged out"
+	}
+
+	ctx, cancel := dbContext()
+	defer cancel()
+
+	err = a.store.DeleteSession(ctx, claims.SessionID, hashToken(token))
+	if err != nil {
+		return "Error: Database error"
+	}
+	a.keys.forget(claims.Username)
+	return "Success: Logged out"
+}
+
+// LogoutEverywhere revokes every session of the current user
+func (a *App) LogoutEverywhere(token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	ctx, cancel := dbContext()
+	defer cancel()
+
+	count, err := a.store.DeleteUserSessions(ctx, username)
+	if err != nil {
+		return "Error: Database error"
+	}
+	a.keys.forget(username)
+	return fmt.Sprintf("Success: Logged out of %d session(s)", count)
+}
@@ -0,0 +1,118 @@
+package backend
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signWith signs a raw payload the way signToken does, with any key
+func signWith(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(payload)) + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseToken(t *testing.T) {
+	t.Setenv("MC_ROAM_SESSION_SECRET", "test-secret")
+	claims := sessionClaims{SessionID: "abc", Username: "alice", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	token, err := signToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseToken(token)
+	if err != nil || got != claims {
+		t.Fatalf("parseToken = %+v, %v; want %+v", got, err, claims)
+	}
+
+	payload, sig, _ := strings.Cut(token, ".")
+	enc := base64.RawURLEncoding
+	forged := enc.EncodeToString([]byte(`{"sid":"abc","sub":"mallory","exp":9999999999}`))
+	flipped, _ := enc.DecodeString(sig)
+	flipped[0] ^= 1
+	expired, err := signToken(sessionClaims{SessionID: "abc", Username: "alice", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{name: "other user's payload", token: forged + "." + sig, wantErr: "invalid session"},
+		{name: "signature changed", token: payload + "." + enc.EncodeToString(flipped), wantErr: "invalid session"},
+		{name: "signed with another key", token: signWith([]byte("other-secret"), `{"sid":"abc","sub":"alice","exp":9999999999}`), wantErr: "invalid session"},
+		{name: "signed garbage", token: signWith(getSessionKey(), "not json"), wantErr: "invalid session"},
+		{name: "expired", token: expired, wantErr: "expired"},
+		{name: "empty", token: "", wantErr: "invalid session"},
+		{name: "no signature", token: payload, wantErr: "invalid session"},
+		{name: "extra part", token: token + ".x", wantErr: "invalid session"},
+		{name: "not base64", token: "!!!." + sig, wantErr: "invalid session"},
+		{name: "signature not base64", token: payload + ".!!!", wantErr: "invalid session"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseToken(tt.token); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseToken = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	a, alice, bob := newTestApp(t)
+
+	if got, err := a.authenticate(alice); err != nil || got != "alice" {
+		t.Fatalf("authenticate(alice) = %q, %v", got, err)
+	}
+	if _, err := a.authenticate(""); err == nil {
+		t.Error("empty token accepted")
+	}
+
+	// Well signed, but the session was never stored
+	unknown, _ := signToken(sessionClaims{SessionID: "nope", Username: "alice", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if _, err := a.authenticate(unknown); err == nil || !strings.Contains(err.Error(), "revoked") {
+		t.Errorf("unknown session = %v, want revoked", err)
+	}
+
+	// bob's session ID under alice's name doesn't make bob alice
+	claims, err := parseToken(bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims.Username = "alice"
+	swapped, _ := signToken(claims)
+	if _, err := a.authenticate(swapped); err == nil {
+		t.Error("bob's session authenticated as alice")
+	}
+
+	// Logging out revokes that token only
+	second, err := a.createSession("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Logout(alice); !strings.HasPrefix(got, "Success") {
+		t.Fatalf("Logout = %q", got)
+	}
+	if _, err := a.authenticate(alice); err == nil {
+		t.Error("token still valid after Logout")
+	}
+	if got := a.GetSessionUser(second); got != "alice" {
+		t.Errorf("other session of alice = %q after Logout", got)
+	}
+
+	if got := a.LogoutEverywhere(second); !strings.HasPrefix(got, "Success") {
+		t.Fatalf("LogoutEverywhere = %q", got)
+	}
+	if got := a.GetSessionUser(second); got != "" {
+		t.Errorf("GetSessionUser = %q after LogoutEverywhere", got)
+	}
+	if got := a.GetSessionUser(bob); got != "bob" {
+		t.Errorf("bob logged out by alice's LogoutEverywhere: %q", got)
+	}
+}
@@ -0,0 +1,240 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshots are full server-side copies of the live cloud folder, stored at
+// server-<id>/snapshots/<timestamp>. runSync excludes that folder so a normal
+// sync never downloads or deletes them.
+const (
+	snapshotsDir         = "snapshots"
+	snapshotTimeLayout   = "20060102-150405" // UTC
+	snapshotKeepSessions = 5                 // Always keep the last N sessions
+	snapshotKeepDaily    = 7                 // Plus the newest snapshot of each of the last N days
+	snapshotKeepWeekly   = 4                 // Plus the newest snapshot of each of the last N weeks
+)
+
+// snapshotRoot returns the remote folder holding a server's snapshots
+func snapshotRoot(serverID string) string {
+	return "mc-remote:server-" + serverID + "/" + snapshotsDir
+}
+
+// createSnapshot copies the current cloud state of a server into a new dated snapshot.
+// It returns the snapshot name.
+func (a *App) createSnapshot(serverID string) (string, error) {
+	name := time.Now().UTC().Format(snapshotTimeLayout)
+	live := "mc-remote:server-" + serverID
+	dest := snapshotRoot(serverID) + "/" + name
+
+	a.Log(fmt.Sprintf("📸 Saving previous cloud state as snapshot %s...", name))
+
+	// Server-side copy where the backend supports it (Drive does)
+	cmd, err := a.rcloneCommand(serverID, "copy", live, dest,
+		"--exclude", "/"+snapshotsDir+"/**",
+		"--transfers", "4",
+	)
+	if err != nil {
+		return "", err
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("snapshot failed: %v (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return name, nil
+}
+
+// ListSnapshots returns every snapshot of a server, newest first
+func (a *App) ListSnapshots(serverID string, token string) []Snapshot {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return []Snapshot{}
+	}
+	if !a.isMember(serverID, username) {
+		return []Snapshot{}
+	}
+	snapshots, err := a.listSnapshots(serverID)
+	if err != nil {
+		a.Log("⚠️ Could not list snapshots: " + err.Error())
+		return []Snapshot{}
+	}
+	return snapshots
+}
+
+// RestoreSnapshot replaces the live cloud folder with a snapshot (Admins only).
+// The current state is snapshotted first so a restore can be undone.
+func (a *App) RestoreSnapshot(serverID string, snapshotName string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if !a.isAdmin(serverID, username) {
+		return "Error: Only admins can restore snapshots"
+	}
+	if _, err := time.Parse(snapshotTimeLayout, snapshotName); err != nil {
+		return "Error: Invalid snapshot name"
+	}
+
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return "Error: Server not found"
+	}
+	if server.Lock.IsRunning || a.procs.IsRunning(serverID) {
+		return "Error: Stop the server before restoring a snapshot."
+	}
+
+	// The lock keeps anyone from starting or syncing the server mid-restore
+	err = a.withServerLock(serverID, username, func(ctx context.Context) error {
+		remote, err := a.remoteSyncState(serverID)
+		if err != nil {
+			return err
+		}
+		if _, err := a.createSnapshot(serverID); err != nil {
+			return fmt.Errorf("Could not back up current state before restoring: %v", err)
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+
+		a.Log(fmt.Sprintf("⏪ Restoring snapshot %s...", snapshotName))
+		source := snapshotRoot(serverID) + "/" + snapshotName
+		live := "mc-remote:server-" + serverID
+		cmd, err := a.rcloneCommand(serverID, "sync", source, live,
+			"--exclude", "/"+snapshotsDir+"/**",
+			"--exclude", "/"+syncStateFile,
+			"--transfers", "4",
+		)
+		if err != nil {
+			return err
+		}
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("Restore failed: %v (%s)", err, strings.TrimSpace(string(output)))
+		}
+
+		// A new generation, so copies made before the restore can't be
+		// uploaded over it
+		_, err = a.recordSyncState(serverID, username, remote)
+		return err
+	})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	a.Log("✅ Snapshot restored. The next host will download it.")
+	return "Success: Restored snapshot " + snapshotName
+}
+
+// pruneSnapshots deletes snapshots that fall outside the retention policy
+func (a *App) pruneSnapshots(serverID string) error {
+	snapshots, err := a.listSnapshots(serverID)
+	if err != nil {
+		return err
+	}
+
+	times := make([]time.Time, 0, len(snapshots))
+	for _, s := range snapshots {
+		times = append(times, s.CreatedAt)
+	}
+
+	for _, t := range snapshotsToPrune(times, time.Now().UTC()) {
+		name := t.Format(snapshotTimeLayout)
+		cmd, err := a.rcloneCommand(serverID, "purge", snapshotRoot(serverID)+"/"+name)
+		if err == nil {
+			err = cmd.Run()
+		}
+		if err != nil {
+			a.Log(fmt.Sprintf("⚠️ Could not delete old snapshot %s: %v", name, err))
+			continue
+		}
+		a.Log("🧹 Removed old snapshot " + name)
+	}
+	return nil
+}
+
+// listSnapshots reads the snapshot folders and their sizes in one listing
+func (a *App) listSnapshots(serverID string) ([]Snapshot, error) {
+	cmd, err := a.rcloneCommand(serverID, "lsjson", snapshotRoot(serverID), "--recursive", "--files-only")
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		// No snapshots folder yet
+		return []Snapshot{}, nil
+	}
+
+	var entries []struct {
+		Path string `json:"Path"`
+		Size int64  `json:"Size"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("could not parse rclone listing: %v", err)
+	}
+
+	byName := map[string]*Snapshot{}
+	for _, e := range entries {
+		name := strings.SplitN(e.Path, "/", 2)[0]
+		created, err := time.Parse(snapshotTimeLayout, name)
+		if err != nil {
+			continue // Not one of ours
+		}
+		snap, ok := byName[name]
+		if !ok {
+			snap = &Snapshot{Name: name, CreatedAt: created}
+			byName[name] = snap
+		}
+		snap.Size += e.Size
+		snap.Files++
+	}
+
+	snapshots := make([]Snapshot, 0, len(byName))
+	for _, s := range byName {
+		snapshots = append(snapshots, *s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// snapshotsToPrune applies the retention policy: keep the last few sessions,
+// plus the newest snapshot of each recent day and week. Everything else goes.
+func snapshotsToPrune(snapshots []time.Time, now time.Time) []time.Time {
+	sorted := append([]time.Time(nil), snapshots...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].After(sorted[j]) })
+
+	keep := map[time.Time]bool{}
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+
+	for i, t := range sorted {
+		if i < snapshotKeepSessions {
+			keep[t] = true
+		}
+
+		day := t.Format("2006-01-02")
+		if !days[day] && now.Sub(t) < time.Duration(snapshotKeepDaily)*24*time.Hour {
+			days[day] = true
+			keep[t] = true
+		}
+
+		year, week := t.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%d", year, week)
+		if !weeks[weekKey] && now.Sub(t) < time.Duration(snapshotKeepWeekly)*7*24*time.Hour {
+			weeks[weekKey] = true
+			keep[t] = true
+		}
+	}
+
+	var prune []time.Time
+	for _, t := range sorted {
+		if !keep[t] {
+			prune = append(prune, t)
+		}
+	}
+	return prune
+}
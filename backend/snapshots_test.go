@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshotsToPrune(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) // Saturday, ISO week Oct 12-18
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	today := func(hours ...int) []time.Time {
+		var times []time.Time
+		for _, h := range hours {
+			times = append(times, at(10, 17, h))
+		}
+		return times
+	}
+
+	tests := []struct {
+		name      string
+		snapshots []time.Time
+		want      []time.Time
+	}{
+		{name: "none", snapshots: nil, want: nil},
+		{
+			name:      "the last sessions are kept however old",
+			snapshots: []time.Time{at(6, 1, 12), at(7, 1, 12), at(8, 1, 12)},
+			want:      nil,
+		},
+		{
+			name:      "one day keeps the last sessions only",
+			snapshots: today(1, 2, 3, 4, 5, 6, 7, 8),
+			want:      []time.Time{at(10, 17, 3), at(10, 17, 2), at(10, 17, 1)},
+		},
+		{
+			name:      "older days keep their newest",
+			snapshots: append(today(7, 8, 9, 10, 11), at(10, 16, 10), at(10, 16, 8), at(10, 14, 10), at(10, 14, 8)),
+			want:      []time.Time{at(10, 16, 8), at(10, 14, 8)},
+		},
+		{
+			name: "past the daily window weeks keep their newest",
+			// Oct 6-7 share the week of Oct 5, Sep 29-30 the week of Sep 28
+			snapshots: append(today(7, 8, 9, 10, 11), at(10, 7, 12), at(10, 6, 12), at(9, 30, 12), at(9, 29, 12)),
+			want:      []time.Time{at(10, 6, 12), at(9, 29, 12)},
+		},
+		{
+			name:      "past the weekly window everything goes",
+			snapshots: append(today(7, 8, 9, 10, 11), at(9, 7, 12), at(8, 1, 12)),
+			want:      []time.Time{at(9, 7, 12), at(8, 1, 12)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snapshotsToPrune(tt.snapshots, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snapshotsToPrune =\n  %v\nwant\n  %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by a Store when a document doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// Store is everything the backend persists: users, sessions, server groups,
+// versions and host locks. MongoStore is the shared cloud implementation;
+// MemoryStore is an embedded one for offline LAN use and tests.
+type Store interface {
+	// --- Users ---
+	GetUser(ctx context.Context, username string) (User, error)
+	CreateUser(ctx context.Context, user User) error
+	SetPlayitConfig(ctx context.Context, username string, content string) error
+	SetUserKeys(ctx context.Context, username string, publicKey string, encryptedPrivateKey string, salt string) error
+
+	// --- Sessions ---
+	CreateSession(ctx context.Context, session Session) error
+	GetSession(ctx context.Context, sessionID string) (Session, error)
+	DeleteSession(ctx context.Context, sessionID string, tokenHash string) error
+	DeleteUserSessions(ctx context.Context, username string) (int64, error)
+
+	// --- Server groups ---
+	GetServer(ctx context.Context, serverID string) (ServerGroup, error)
+	GetServerByInvite(ctx context.Context, inviteHash string) (ServerGroup, error)
+	ListServersForMember(ctx context.Context, username string) ([]ServerGroup, error)
+	CreateServer(ctx context.Context, server ServerGroup) error
+	DeleteServer(ctx context.Context, serverID string) error
+	AddMember(ctx context.Context, serverID string, username string) error
+	RemoveMember(ctx context.Context, serverID string, username string) error
+	AddAdmin(ctx context.Context, serverID string, username string) error
+	RemoveAdmin(ctx context.Context, serverID string, username string) error
+	SetServerVersion(ctx context.Context, serverID string, serverType string, version string) error
+	SetWorldSetting(ctx context.Context, serverID string, key string, value interface{}) error
+	SetSyncStatus(ctx context.Context, serverID string, status string, user string, at time.Time) error
+	// SetSyncState records a new cloud version, but only if the current one is
+	// still prevGeneration. It returns false if another upload got there first.
+	SetSyncState(ctx context.Context, serverID string, prevGeneration int64, state SyncState) (bool, error)
+	SetTunnelURL(ctx context.Context, serverID string, url string) error
+	SetLaunchProfile(ctx context.Context, serverID string, profile LaunchProfile) error
+	// SetServerCredentials replaces the encrypted rclone config, all key shares
+	// and the invite, and clears any legacy plaintext config or invite code.
+	SetServerCredentials(ctx context.Context, serverID string, encryptedConfig string, shares map[string]string, invite GroupInvite) error
+	// SetInvite replaces the invite and clears any legacy plaintext invite code
+	SetInvite(ctx context.Context, serverID string, invite GroupInvite) error
+	SetKeyShare(ctx context.Context, serverID string, username string, share string) error
+
+	// --- Versions ---
+	ListVersions(ctx context.Context) ([]ServerVersion, error)
+	// UpsertVersions adds or updates each version (by type and version), so
+	// readers never see a half-written catalog
+	UpsertVersions(ctx context.Context, versions []ServerVersion) error
+
+	// --- Locks ---
+	// AcquireLock takes a free lock. It returns false if someone else holds it.
+	AcquireLock(ctx context.Context, serverID string, lock ServerLock) (bool, error)
+	// TakeOverLock replaces the exact session in prev, but only if its
+	// heartbeat is older than staleBefore and it has no pending upload.
+	// Two racing hosts can't both win.
+	TakeOverLock(ctx context.Context, serverID string, prev ServerLock, staleBefore time.Time, lock ServerLock) (bool, error)
+	// RefreshLock bumps the heartbeat. It returns false if host no longer holds the lock.
+	RefreshLock(ctx context.Context, serverID string, host string, at time.Time) (bool, error)
+	// ReleaseLock frees the lock if host holds it. An empty host releases unconditionally.
+	ReleaseLock(ctx context.Context, serverID string, host string) (bool, error)
+	// SetPendingUpload flags host's held lock as waiting for an upload.
+	// It returns false if host no longer holds the lock.
+	SetPendingUpload(ctx context.Context, serverID string, host string, pending bool) (bool, error)
+}
+
+// dbContext returns the standard timeout used for store calls
+func dbContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 5*time.Second)
+}
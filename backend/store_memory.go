@@ -0,0 +1,492 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MemoryStore is an embedded Store. Data lives in memory and, if a path is
+// given, is written to a single BSON file after every change. Useful for
+// offline LAN play (no MongoDB needed) and for tests.
+type MemoryStore struct {
+	mu   sync.Mutex
+	path string // "" = memory only
+	data memoryData
+}
+
+// memoryData is the on-disk layout of the embedded store
+type memoryData struct {
+	Users    map[string]User        `bson:"users"`    // by username
+	Sessions map[string]Session     `bson:"sessions"` // by session ID
+	Servers  map[string]ServerGroup `bson:"servers"`  // by server ID
+	Versions []ServerVersion        `bson:"versions"`
+}
+
+// NewMemoryStore creates an embedded store. If path is set, existing data
+// is loaded from it and every change is saved back.
+func NewMemoryStore(path string) (*MemoryStore, error) {
+	s := &MemoryStore{
+		path: path,
+		data: memoryData{
+			Users:    map[string]User{},
+			Sessions: map[string]Session{},
+			Servers:  map[string]ServerGroup{},
+		},
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := bson.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("corrupt store file %s: %v", path, err)
+	}
+	if s.data.Users == nil {
+		s.data.Users = map[string]User{}
+	}
+	if s.data.Sessions == nil {
+		s.data.Sessions = map[string]Session{}
+	}
+	if s.data.Servers == nil {
+		s.data.Servers = map[string]ServerGroup{}
+	}
+	return s, nil
+}
+
+// save writes the store to disk atomically. Caller holds s.mu.
+func (s *MemoryStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	raw, err := bson.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(s.path), 0755)
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// clone deep-copies a document the same way a round trip through MongoDB
+// would, so callers can't mutate stored state by accident.
+func clone[T any](v T) T {
+	raw, err := bson.Marshal(bson.M{"v": v})
+	if err != nil {
+		return v
+	}
+	var wrapper struct {
+		V T `bson:"v"`
+	}
+	if err := bson.Unmarshal(raw, &wrapper); err != nil {
+		return v
+	}
+	return wrapper.V
+}
+
+// updateServer runs fn on a stored server and saves it
+func (s *MemoryStore) updateServer(serverID string, fn func(server *ServerGroup)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server, ok := s.data.Servers[serverID]
+	if !ok {
+		return ErrNotFound
+	}
+	fn(&server)
+	s.data.Servers[serverID] = clone(server)
+	return s.save()
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, value string) []string {
+	out := []string{}
+	for _, v := range list {
+		if v != value {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// --- Users ---
+
+func (s *MemoryStore) GetUser(ctx context.Context, username string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.data.Users[username]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return clone(user), nil
+}
+
+func (s *MemoryStore) CreateUser(ctx context.Context, user User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Users[user.Username]; ok {
+		return fmt.Errorf("user %s already exists", user.Username)
+	}
+	if user.ID == "" {
+		user.ID = randomHex(12)
+	}
+	s.data.Users[user.Username] = clone(user)
+	return s.save()
+}
+
+func (s *MemoryStore) SetPlayitConfig(ctx context.Context, username string, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.data.Users[username]
+	if !ok {
+		return nil // Same as an update matching nothing
+	}
+	user.PlayitTomlContent = content
+	s.data.Users[username] = user
+	return s.save()
+}
+
+func (s *MemoryStore) SetUserKeys(ctx context.Context, username string, publicKey string, encryptedPrivateKey string, salt string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.data.Users[username]
+	if !ok {
+		return nil
+	}
+	user.PublicKey = publicKey
+	user.EncryptedPrivateKey = encryptedPrivateKey
+	user.KeySalt = salt
+	s.data.Users[username] = user
+	return s.save()
+}
+
+// --- Sessions ---
+
+func (s *MemoryStore) CreateSession(ctx context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Sessions[session.ID] = clone(session)
+	return s.save()
+}
+
+func (s *MemoryStore) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.data.Sessions[sessionID]
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	return clone(session), nil
+}
+
+func (s *MemoryStore) DeleteSession(ctx context.Context, sessionID string, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, ok := s.data.Sessions[sessionID]; ok && session.TokenHash == tokenHash {
+		delete(s.data.Sessions, sessionID)
+	}
+	return s.save()
+}
+
+func (s *MemoryStore) DeleteUserSessions(ctx context.Context, username string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, session := range s.data.Sessions {
+		if session.Username == username {
+			delete(s.data.Sessions, id)
+			n++
+		}
+	}
+	return n, s.save()
+}
+
+// --- Server groups ---
+
+func (s *MemoryStore) GetServer(ctx context.Context, serverID string) (ServerGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server, ok := s.data.Servers[serverID]
+	if !ok {
+		return ServerGroup{}, ErrNotFound
+	}
+	return clone(server), nil
+}
+
+func (s *MemoryStore) GetServerByInvite(ctx context.Context, inviteHash string) (ServerGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, server := range s.data.Servers {
+		if server.InviteHash == inviteHash {
+			return clone(server), nil
+		}
+	}
+	return ServerGroup{}, ErrNotFound
+}
+
+func (s *MemoryStore) ListServersForMember(ctx context.Context, username string) ([]ServerGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var servers []ServerGroup
+	for _, server := range s.data.Servers {
+		if containsString(server.Members, username) {
+			servers = append(servers, clone(server))
+		}
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].ID < servers[j].ID })
+	return servers, nil
+}
+
+func (s *MemoryStore) CreateServer(ctx context.Context, server ServerGroup) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Servers[server.ID]; ok {
+		return fmt.Errorf("server %s already exists", server.ID)
+	}
+	s.data.Servers[server.ID] = clone(server)
+	return s.save()
+}
+
+func (s *MemoryStore) DeleteServer(ctx context.Context, serverID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data.Servers, serverID)
+	return s.save()
+}
+
+func (s *MemoryStore) AddMember(ctx context.Context, serverID string, username string) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		if !containsString(server.Members, username) {
+			server.Members = append(server.Members, username)
+		}
+	})
+}
+
+func (s *MemoryStore) RemoveMember(ctx context.Context, serverID string, username string) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		server.Members = removeString(server.Members, username)
+		server.Admins = removeString(server.Admins, username)
+		delete(server.KeyShares, username)
+	})
+}
+
+func (s *MemoryStore) AddAdmin(ctx context.Context, serverID string, username string) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		if !containsString(server.Admins, username) {
+			server.Admins = append(server.Admins, username)
+		}
+	})
+}
+
+func (s *MemoryStore) RemoveAdmin(ctx context.Context, serverID string, username string) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		server.Admins = removeString(server.Admins, username)
+	})
+}
+
+func (s *MemoryStore) SetServerVersion(ctx context.Context, serverID string, serverType string, version string) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		server.Type = serverType
+		server.Version = version
+	})
+}
+
+func (s *MemoryStore) SetWorldSetting(ctx context.Context, serverID string, key string, value interface{}) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		if server.WorldSettings == nil {
+			server.WorldSettings = map[string]interface{}{}
+		}
+		server.WorldSettings[key] = value
+	})
+}
+
+func (s *MemoryStore) SetSyncStatus(ctx context.Context, serverID string, status string, user string, at time.Time) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		server.LastSyncStatus = status
+		server.LastSyncUser = user
+		server.LastSyncTime = at
+	})
+}
+
+func (s *MemoryStore) SetTunnelURL(ctx context.Context, serverID string, url string) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		server.Lock.TunnelURL = url
+	})
+}
+
+func (s *MemoryStore) SetSyncState(ctx context.Context, serverID string, prevGeneration int64, state SyncState) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server, ok := s.data.Servers[serverID]
+	if !ok || server.SyncState.Generation != prevGeneration {
+		return false, nil
+	}
+	server.SyncState = state
+	s.data.Servers[serverID] = clone(server)
+	return true, s.save()
+}
+
+func (s *MemoryStore) SetLaunchProfile(ctx context.Context, serverID string, profile LaunchProfile) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		server.Launch = profile
+	})
+}
+
+func (s *MemoryStore) SetServerCredentials(ctx context.Context, serverID string, encryptedConfig string, shares map[string]string, invite GroupInvite) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		server.RcloneConfig = ""
+		server.EncryptedRcloneConfig = encryptedConfig
+		server.KeyShares = shares
+		setInvite(server, invite)
+	})
+}
+
+func (s *MemoryStore) SetInvite(ctx context.Context, serverID string, invite GroupInvite) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		setInvite(server, invite)
+	})
+}
+
+func setInvite(server *ServerGroup, invite GroupInvite) {
+	server.InviteHash = invite.Hash
+	server.EncryptedInviteCode = invite.EncryptedCode
+	server.InviteKeyShare = invite.KeyShare
+	server.LegacyInviteCode = ""
+}
+
+func (s *MemoryStore) SetKeyShare(ctx context.Context, serverID string, username string, share string) error {
+	return s.updateServer(serverID, func(server *ServerGroup) {
+		if server.KeyShares == nil {
+			server.KeyShares = map[string]string{}
+		}
+		server.KeyShares[username] = share
+	})
+}
+
+// --- Versions ---
+
+func (s *MemoryStore) ListVersions(ctx context.Context) ([]ServerVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions := clone(s.data.Versions)
+	// Sort by Version descending (simplified sort, same as Mongo)
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+	return versions, nil
+}
+
+func (s *MemoryStore) UpsertVersions(ctx context.Context, versions []ServerVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := map[string]int{}
+	for i, v := range s.data.Versions {
+		index[v.Type+"\x00"+v.Version] = i
+	}
+	for _, v := range versions {
+		key := v.Type + "\x00" + v.Version
+		if i, ok := index[key]; ok {
+			v.ID = s.data.Versions[i].ID
+			s.data.Versions[i] = v
+			continue
+		}
+		if v.ID == "" {
+			v.ID = randomHex(12)
+		}
+		index[key] = len(s.data.Versions)
+		s.data.Versions = append(s.data.Versions, v)
+	}
+	return s.save()
+}
+
+// --- Locks ---
+
+func (s *MemoryStore) AcquireLock(ctx context.Context, serverID string, lock ServerLock) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server, ok := s.data.Servers[serverID]
+	if !ok || server.Lock.IsRunning {
+		return false, nil
+	}
+	server.Lock = lock
+	s.data.Servers[serverID] = clone(server)
+	return true, s.save()
+}
+
+func (s *MemoryStore) TakeOverLock(ctx context.Context, serverID string, prev ServerLock, staleBefore time.Time, lock ServerLock) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server, ok := s.data.Servers[serverID]
+	if !ok {
+		return false, nil
+	}
+	cur := server.Lock
+	if !cur.IsRunning || cur.HostedBy != prev.HostedBy || !cur.HostedAt.Equal(prev.HostedAt) || cur.PendingUpload {
+		return false, nil
+	}
+	if !cur.HeartbeatAt.IsZero() && !cur.HeartbeatAt.Before(staleBefore) {
+		return false, nil
+	}
+	server.Lock = lock
+	s.data.Servers[serverID] = clone(server)
+	return true, s.save()
+}
+
+func (s *MemoryStore) RefreshLock(ctx context.Context, serverID string, host string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server, ok := s.data.Servers[serverID]
+	if !ok || !server.Lock.IsRunning || server.Lock.HostedBy != host {
+		return false, nil
+	}
+	server.Lock.HeartbeatAt = at
+	s.data.Servers[serverID] = clone(server)
+	return true, s.save()
+}
+
+func (s *MemoryStore) ReleaseLock(ctx context.Context, serverID string, host string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server, ok := s.data.Servers[serverID]
+	if !ok {
+		return false, nil
+	}
+	if host != "" && (!server.Lock.IsRunning || server.Lock.HostedBy != host) {
+		return false, nil
+	}
+	server.Lock = ServerLock{}
+	s.data.Servers[serverID] = clone(server)
+	return true, s.save()
+}
+
+func (s *MemoryStore) SetPendingUpload(ctx context.Context, serverID string, host string, pending bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server, ok := s.data.Servers[serverID]
+	if !ok || !server.Lock.IsRunning || server.Lock.HostedBy != host {
+		return false, nil
+	}
+	server.Lock.PendingUpload = pending
+	s.data.Servers[serverID] = clone(server)
+	return true, s.save()
+}
@@ -0,0 +1,284 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestStore returns an empty in-memory store with one server group
+func newTestStore(t *testing.T) *MemoryStore {
+	t.Helper()
+	store, err := NewMemoryStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.CreateServer(context.Background(), ServerGroup{ID: "srv", Name: "Survival", Owner: "alice", Members: []string{"alice"}, Admins: []string{"alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestMemoryStoreLocks(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	held := func(host string, heartbeat time.Time) ServerLock {
+		return ServerLock{IsRunning: true, HostedBy: host, HostedAt: t0, HeartbeatAt: heartbeat}
+	}
+
+	// Each step runs against the lock the previous steps left behind
+	type step struct {
+		name string
+		run  func(s *MemoryStore) (bool, error)
+		want bool
+		host string // Holder afterwards ("" = free)
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "acquire and release",
+			steps: []step{
+				{"acquire", func(s *MemoryStore) (bool, error) { return s.AcquireLock(ctx, "srv", held("alice", t0)) }, true, "alice"},
+				{"second acquire", func(s *MemoryStore) (bool, error) { return s.AcquireLock(ctx, "srv", held("bob", t0)) }, false, "alice"},
+				{"release by another host", func(s *MemoryStore) (bool, error) { return s.ReleaseLock(ctx, "srv", "bob") }, false, "alice"},
+				{"release", func(s *MemoryStore) (bool, error) { return s.ReleaseLock(ctx, "srv", "alice") }, true, ""},
+				{"acquire again", func(s *MemoryStore) (bool, error) { return s.AcquireLock(ctx, "srv", held("bob", t0)) }, true, "bob"},
+				{"forced release", func(s *MemoryStore) (bool, error) { return s.ReleaseLock(ctx, "srv", "") }, true, ""},
+			},
+		},
+		{
+			name: "refresh",
+			steps: []step{
+				{"acquire", func(s *MemoryStore) (bool, error) { return s.AcquireLock(ctx, "srv", held("alice", t0)) }, true, "alice"},
+				{"refresh", func(s *MemoryStore) (bool, error) { return s.RefreshLock(ctx, "srv", "alice", t0.Add(time.Minute)) }, true, "alice"},
+				{"refresh by another host", func(s *MemoryStore) (bool, error) { return s.RefreshLock(ctx, "srv", "bob", t0) }, false, "alice"},
+				{"refresh unknown server", func(s *MemoryStore) (bool, error) { return s.RefreshLock(ctx, "nope", "alice", t0) }, false, "alice"},
+			},
+		},
+		{
+			name: "takeover of a stale lock",
+			steps: []step{
+				{"acquire", func(s *MemoryStore) (bool, error) { return s.AcquireLock(ctx, "srv", held("alice", t0)) }, true, "alice"},
+				{"take over", func(s *MemoryStore) (bool, error) {
+					return s.TakeOverLock(ctx, "srv", held("alice", t0), t0.Add(time.Second), held("bob", t0.Add(time.Hour)))
+				}, true, "bob"},
+				{"old host refreshes", func(s *MemoryStore) (bool, error) { return s.RefreshLock(ctx, "srv", "alice", t0) }, false, "bob"},
+				{"old host releases", func(s *MemoryStore) (bool, error) { return s.ReleaseLock(ctx, "srv", "alice") }, false, "bob"},
+			},
+		},
+		{
+			name: "takeover refused",
+			steps: []step{
+				{"acquire", func(s *MemoryStore) (bool, error) { return s.AcquireLock(ctx, "srv", held("alice", t0)) }, true, "alice"},
+				{"fresh heartbeat", func(s *MemoryStore) (bool, error) {
+					return s.TakeOverLock(ctx, "srv", held("alice", t0), t0, held("bob", t0))
+				}, false, "alice"},
+				{"different session", func(s *MemoryStore) (bool, error) {
+					prev := held("alice", t0)
+					prev.HostedAt = t0.Add(-time.Hour)
+					return s.TakeOverLock(ctx, "srv", prev, t0.Add(time.Hour), held("bob", t0))
+				}, false, "alice"},
+				{"pending upload", func(s *MemoryStore) (bool, error) { return s.SetPendingUpload(ctx, "srv", "alice", true) }, true, "alice"},
+				{"stale but pending", func(s *MemoryStore) (bool, error) {
+					return s.TakeOverLock(ctx, "srv", held("alice", t0), t0.Add(time.Hour), held("bob", t0))
+				}, false, "alice"},
+			},
+		},
+		{
+			name: "free lock",
+			steps: []step{
+				{"take over a free lock", func(s *MemoryStore) (bool, error) {
+					return s.TakeOverLock(ctx, "srv", ServerLock{}, t0.Add(time.Hour), held("bob", t0))
+				}, false, ""},
+				{"pending upload without the lock", func(s *MemoryStore) (bool, error) { return s.SetPendingUpload(ctx, "srv", "bob", true) }, false, ""},
+				{"acquire unknown server", func(s *MemoryStore) (bool, error) { return s.AcquireLock(ctx, "nope", held("bob", t0)) }, false, ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			for _, st := range tt.steps {
+				got, err := st.run(s)
+				if err != nil {
+					t.Fatalf("%s: %v", st.name, err)
+				}
+				if got != st.want {
+					t.Errorf("%s = %v, want %v", st.name, got, st.want)
+				}
+				server, _ := s.GetServer(ctx, "srv")
+				if server.Lock.HostedBy != st.host || server.Lock.IsRunning != (st.host != "") {
+					t.Errorf("after %s the lock is %+v, want held by %q", st.name, server.Lock, st.host)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreMembers(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		run         func(s *MemoryStore) error
+		wantMembers []string
+		wantAdmins  []string
+	}{
+		{"add member", func(s *MemoryStore) error { return s.AddMember(ctx, "srv", "bob") }, []string{"alice", "bob"}, []string{"alice"}},
+		{"add member twice", func(s *MemoryStore) error {
+			s.AddMember(ctx, "srv", "bob")
+			return s.AddMember(ctx, "srv", "bob")
+		}, []string{"alice", "bob"}, []string{"alice"}},
+		{"add admin", func(s *MemoryStore) error {
+			s.AddMember(ctx, "srv", "bob")
+			return s.AddAdmin(ctx, "srv", "bob")
+		}, []string{"alice", "bob"}, []string{"alice", "bob"}},
+		{"remove admin", func(s *MemoryStore) error {
+			s.AddMember(ctx, "srv", "bob")
+			s.AddAdmin(ctx, "srv", "bob")
+			return s.RemoveAdmin(ctx, "srv", "bob")
+		}, []string{"alice", "bob"}, []string{"alice"}},
+		{"removing a member drops their admin role and key share", func(s *MemoryStore) error {
+			s.AddMember(ctx, "srv", "bob")
+			s.AddAdmin(ctx, "srv", "bob")
+			s.SetKeyShare(ctx, "srv", "bob", "share")
+			return s.RemoveMember(ctx, "srv", "bob")
+		}, []string{"alice"}, []string{"alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			if err := tt.run(s); err != nil {
+				t.Fatal(err)
+			}
+			server, _ := s.GetServer(ctx, "srv")
+			if !reflect.DeepEqual(server.Members, tt.wantMembers) || !reflect.DeepEqual(server.Admins, tt.wantAdmins) {
+				t.Errorf("members %v admins %v, want %v %v", server.Members, server.Admins, tt.wantMembers, tt.wantAdmins)
+			}
+			if _, ok := server.KeyShares["bob"]; ok && !containsString(server.Members, "bob") {
+				t.Error("a removed member kept their key share")
+			}
+			servers, _ := s.ListServersForMember(ctx, "bob")
+			if (len(servers) == 1) != containsString(tt.wantMembers, "bob") {
+				t.Errorf("ListServersForMember(bob) = %d servers", len(servers))
+			}
+		})
+	}
+
+	s := newTestStore(t)
+	if err := s.AddMember(ctx, "nope", "bob"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AddMember on an unknown server = %v", err)
+	}
+}
+
+func TestMemoryStoreSync(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	if err := s.SetSyncStatus(ctx, "srv", "ok", "alice", at); err != nil {
+		t.Fatal(err)
+	}
+	server, _ := s.GetServer(ctx, "srv")
+	if server.LastSyncStatus != "ok" || server.LastSyncUser != "alice" || !server.LastSyncTime.Equal(at) {
+		t.Errorf("sync status = %q %q %v", server.LastSyncStatus, server.LastSyncUser, server.LastSyncTime)
+	}
+
+	tests := []struct {
+		name string
+		prev int64
+		next int64
+		want bool
+	}{
+		{"first upload", 0, 1, true},
+		{"next upload", 1, 2, true},
+		{"stale generation", 1, 2, false},
+		{"from the future", 5, 6, false},
+	}
+	for _, tt := range tests {
+		ok, err := s.SetSyncState(ctx, "srv", tt.prev, SyncState{Generation: tt.next, UpdatedBy: "alice"})
+		if err != nil || ok != tt.want {
+			t.Errorf("%s: SetSyncState = %v, %v, want %v", tt.name, ok, err, tt.want)
+		}
+	}
+	server, _ = s.GetServer(ctx, "srv")
+	if server.SyncState.Generation != 2 {
+		t.Errorf("generation = %d, want 2", server.SyncState.Generation)
+	}
+}
+
+func TestMemoryStoreSessions(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	for _, session := range []Session{
+		{ID: "s1", Username: "alice", TokenHash: "h1"},
+		{ID: "s2", Username: "alice", TokenHash: "h2"},
+		{ID: "s3", Username: "bob", TokenHash: "h3"},
+	} {
+		if err := s.CreateSession(ctx, session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		run    func() error
+		exists map[string]bool
+	}{
+		{"wrong token hash keeps the session", func() error { return s.DeleteSession(ctx, "s1", "h2") }, map[string]bool{"s1": true, "s2": true, "s3": true}},
+		{"delete one", func() error { return s.DeleteSession(ctx, "s1", "h1") }, map[string]bool{"s1": false, "s2": true, "s3": true}},
+		{"delete a user's sessions", func() error {
+			n, err := s.DeleteUserSessions(ctx, "alice")
+			if n != 1 {
+				t.Errorf("DeleteUserSessions removed %d, want 1", n)
+			}
+			return err
+		}, map[string]bool{"s1": false, "s2": false, "s3": true}},
+	}
+	for _, tt := range tests {
+		if err := tt.run(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for id, want := range tt.exists {
+			_, err := s.GetSession(ctx, id)
+			if got := err == nil; got != want {
+				t.Errorf("%s: session %s exists = %v, want %v", tt.name, id, got, want)
+			}
+		}
+	}
+}
+
+func TestMemoryStorePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.db")
+	s, err := NewMemoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.CreateUser(ctx, User{Username: "alice"})
+	s.CreateServer(ctx, ServerGroup{ID: "srv", Members: []string{"alice"}})
+	s.AcquireLock(ctx, "srv", ServerLock{IsRunning: true, HostedBy: "alice"})
+
+	reopened, err := NewMemoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.GetUser(ctx, "alice"); err != nil {
+		t.Errorf("user lost: %v", err)
+	}
+	if server, err := reopened.GetServer(ctx, "srv"); err != nil || server.Lock.HostedBy != "alice" {
+		t.Errorf("server lost: %+v, %v", server.Lock, err)
+	}
+
+	// Returned documents are copies
+	server, _ := reopened.GetServer(ctx, "srv")
+	server.Members[0] = "mallory"
+	if again, _ := reopened.GetServer(ctx, "srv"); again.Members[0] != "alice" {
+		t.Error("changing a returned server changed the store")
+	}
+}
@@ -0,0 +1,354 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore is the Store backed by the shared MongoDB database
+type MongoStore struct {
+	db *mongo.Database
+}
+
+// NewMongoStore wraps a connected client
+func NewMongoStore(client *DBClient) *MongoStore {
+	return &MongoStore{db: client.Client.Database("mc_roam")}
+}
+
+func (s *MongoStore) users() *mongo.Collection    { return s.db.Collection("users") }
+func (s *MongoStore) sessions() *mongo.Collection { return s.db.Collection("sessions") }
+func (s *MongoStore) servers() *mongo.Collection  { return s.db.Collection("servers") }
+func (s *MongoStore) versions() *mongo.Collection { return s.db.Collection("versions") }
+
+// mongoErr maps driver errors to store errors
+func mongoErr(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// updateServer applies an update to one server and reports ErrNotFound if it's gone
+func (s *MongoStore) updateServer(ctx context.Context, serverID string, update bson.M) error {
+	result, err := s.servers().UpdateOne(ctx, bson.M{"_id": serverID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// --- Users ---
+
+func (s *MongoStore) GetUser(ctx context.Context, username string) (User, error) {
+	var user User
+	err := s.users().FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	return user, mongoErr(err)
+}
+
+func (s *MongoStore) CreateUser(ctx context.Context, user User) error {
+	_, err := s.users().InsertOne(ctx, user)
+	return err
+}
+
+func (s *MongoStore) SetPlayitConfig(ctx context.Context, username string, content string) error {
+	_, err := s.users().UpdateOne(ctx, bson.M{"username": username}, bson.M{
+		"$set": bson.M{"playit_toml_content": content},
+	})
+	return err
+}
+
+func (s *MongoStore) SetUserKeys(ctx context.Context, username string, publicKey string, encryptedPrivateKey string, salt string) error {
+	_, err := s.users().UpdateOne(ctx, bson.M{"username": username}, bson.M{
+		"$set": bson.M{
+			"public_key":            publicKey,
+			"encrypted_private_key": encryptedPrivateKey,
+			"key_salt":              salt,
+		},
+	})
+	return err
+}
+
+// --- Sessions ---
+
+func (s *MongoStore) CreateSession(ctx context.Context, session Session) error {
+	_, err := s.sessions().InsertOne(ctx, session)
+	return err
+}
+
+func (s *MongoStore) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	var session Session
+	err := s.sessions().FindOne(ctx, bson.M{"_id": sessionID}).Decode(&session)
+	return session, mongoErr(err)
+}
+
+func (s *MongoStore) DeleteSession(ctx context.Context, sessionID string, tokenHash string) error {
+	_, err := s.sessions().DeleteOne(ctx, bson.M{"_id": sessionID, "token_hash": tokenHash})
+	return err
+}
+
+func (s *MongoStore) DeleteUserSessions(ctx context.Context, username string) (int64, error) {
+	result, err := s.sessions().DeleteMany(ctx, bson.M{"username": username})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// --- Server groups ---
+
+func (s *MongoStore) GetServer(ctx context.Context, serverID string) (ServerGroup, error) {
+	var server ServerGroup
+	err := s.servers().FindOne(ctx, bson.M{"_id": serverID}).Decode(&server)
+	return server, mongoErr(err)
+}
+
+func (s *MongoStore) GetServerByInvite(ctx context.Context, inviteHash string) (ServerGroup, error) {
+	var server ServerGroup
+	err := s.servers().FindOne(ctx, bson.M{"invite_hash": inviteHash}).Decode(&server)
+	return server, mongoErr(err)
+}
+
+func (s *MongoStore) ListServersForMember(ctx context.Context, username string) ([]ServerGroup, error) {
+	// Find servers where 'members' array contains 'username'
+	cursor, err := s.servers().Find(ctx, bson.M{"members": username})
+	if err != nil {
+		return nil, err
+	}
+	var servers []ServerGroup
+	if err := cursor.All(ctx, &servers); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+func (s *MongoStore) CreateServer(ctx context.Context, server ServerGroup) error {
+	_, err := s.servers().InsertOne(ctx, server)
+	return err
+}
+
+func (s *MongoStore) DeleteServer(ctx context.Context, serverID string) error {
+	_, err := s.servers().DeleteOne(ctx, bson.M{"_id": serverID})
+	return err
+}
+
+func (s *MongoStore) AddMember(ctx context.Context, serverID string, username string) error {
+	return s.updateServer(ctx, serverID, bson.M{"$addToSet": bson.M{"members": username}})
+}
+
+func (s *MongoStore) RemoveMember(ctx context.Context, serverID string, username string) error {
+	return s.updateServer(ctx, serverID, bson.M{
+		"$pull":  bson.M{"members": username, "admins": username},
+		"$unset": bson.M{"key_shares." + username: ""},
+	})
+}
+
+func (s *MongoStore) AddAdmin(ctx context.Context, serverID string, username string) error {
+	return s.updateServer(ctx, serverID, bson.M{"$addToSet": bson.M{"admins": username}})
+}
+
+func (s *MongoStore) RemoveAdmin(ctx context.Context, serverID string, username string) error {
+	return s.updateServer(ctx, serverID, bson.M{"$pull": bson.M{"admins": username}})
+}
+
+func (s *MongoStore) SetServerVersion(ctx context.Context, serverID string, serverType string, version string) error {
+	return s.updateServer(ctx, serverID, bson.M{"$set": bson.M{"type": serverType, "version": version}})
+}
+
+func (s *MongoStore) SetWorldSetting(ctx context.Context, serverID string, key string, value interface{}) error {
+	// Update specific field in the map: world_settings.keepInventory
+	return s.updateServer(ctx, serverID, bson.M{"$set": bson.M{fmt.Sprintf("world_settings.%s", key): value}})
+}
+
+func (s *MongoStore) SetSyncStatus(ctx context.Context, serverID string, status string, user string, at time.Time) error {
+	return s.updateServer(ctx, serverID, bson.M{"$set": bson.M{
+		"last_sync_status": status,
+		"last_sync_user":   user,
+		"last_sync_time":   at,
+	}})
+}
+
+func (s *MongoStore) SetTunnelURL(ctx context.Context, serverID string, url string) error {
+	return s.updateServer(ctx, serverID, bson.M{"$set": bson.M{"lock.tunnel_url": url}})
+}
+
+func (s *MongoStore) SetSyncState(ctx context.Context, serverID string, prevGeneration int64, state SyncState) (bool, error) {
+	filter := bson.M{"_id": serverID, "sync_state.generation": prevGeneration}
+	if prevGeneration == 0 {
+		// Groups created before generations existed have no sync_state
+		filter = bson.M{"_id": serverID, "$or": []bson.M{
+			{"sync_state.generation": 0},
+			{"sync_state.generation": bson.M{"$exists": false}},
+		}}
+	}
+	result, err := s.servers().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"sync_state": state}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (s *MongoStore) SetLaunchProfile(ctx context.Context, serverID string, profile LaunchProfile) error {
+	return s.updateServer(ctx, serverID, bson.M{"$set": bson.M{"launch": profile}})
+}
+
+func (s *MongoStore) SetServerCredentials(ctx context.Context, serverID string, encryptedConfig string, shares map[string]string, invite GroupInvite) error {
+	return s.updateServer(ctx, serverID, bson.M{
+		"$set": bson.M{
+			"rclone_config":     "",
+			"rclone_config_enc": encryptedConfig,
+			"key_shares":        shares,
+			"invite_hash":       invite.Hash,
+			"invite_code_enc":   invite.EncryptedCode,
+			"invite_key_share":  invite.KeyShare,
+		},
+		"$unset": bson.M{"invite_code": ""},
+	})
+}
+
+func (s *MongoStore) SetInvite(ctx context.Context, serverID string, invite GroupInvite) error {
+	return s.updateServer(ctx, serverID, bson.M{
+		"$set": bson.M{
+			"invite_hash":      invite.Hash,
+			"invite_code_enc":  invite.EncryptedCode,
+			"invite_key_share": invite.KeyShare,
+		},
+		"$unset": bson.M{"invite_code": ""},
+	})
+}
+
+func (s *MongoStore) SetKeyShare(ctx context.Context, serverID string, username string, share string) error {
+	return s.updateServer(ctx, serverID, bson.M{"$set": bson.M{"key_shares." + username: share}})
+}
+
+// --- Versions ---
+
+func (s *MongoStore) ListVersions(ctx context.Context) ([]ServerVersion, error) {
+	// Sort by Version descending (simplified sort)
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cursor, err := s.versions().Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var results []ServerVersion
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *MongoStore) UpsertVersions(ctx context.Context, versions []ServerVersion) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(versions))
+	for i, v := range versions {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"type": v.Type, "version": v.Version}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"url":       v.Url,
+				"build":     v.Build,
+				"checksum":  v.Checksum,
+				"installer": v.Installer,
+			}}).
+			SetUpsert(true)
+	}
+	_, err := s.versions().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// --- Locks ---
+
+// lockSet turns a lock into the $set fields we write
+func lockSet(lock ServerLock) bson.M {
+	return bson.M{
+		"lock.is_running":      lock.IsRunning,
+		"lock.hosted_by":       lock.HostedBy,
+		"lock.hosted_at":       lock.HostedAt,
+		"lock.heartbeat_at":    lock.HeartbeatAt,
+		"lock.ip_address":      lock.IPAddress,
+		"lock.port":            lock.Port,
+		"lock.tunnel_url":      lock.TunnelURL,
+		"lock.taken_over_from": lock.TakenOverFrom,
+		"lock.taken_over_at":   lock.TakenOverAt,
+		"lock.pending_upload":  lock.PendingUpload,
+	}
+}
+
+func (s *MongoStore) AcquireLock(ctx context.Context, serverID string, lock ServerLock) (bool, error) {
+	filter := bson.M{"_id": serverID, "lock.is_running": false}
+	result, err := s.servers().UpdateOne(ctx, filter, bson.M{"$set": lockSet(lock)})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (s *MongoStore) TakeOverLock(ctx context.Context, serverID string, prev ServerLock, staleBefore time.Time, lock ServerLock) (bool, error) {
+	// We match on the old session so two members racing for the
+	// same expired lock can't both win.
+	filter := bson.M{
+		"_id":             serverID,
+		"lock.is_running": true,
+		"lock.hosted_by":  prev.HostedBy,
+		"lock.hosted_at":  prev.HostedAt,
+		// A session whose upload is still queued somewhere must not be lost
+		"lock.pending_upload": bson.M{"$ne": true},
+		"$or": []bson.M{
+			{"lock.heartbeat_at": bson.M{"$lt": staleBefore}},
+			{"lock.heartbeat_at": bson.M{"$exists": false}},
+		},
+	}
+	result, err := s.servers().UpdateOne(ctx, filter, bson.M{"$set": lockSet(lock)})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (s *MongoStore) RefreshLock(ctx context.Context, serverID string, host string, at time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":             serverID,
+		"lock.is_running": true,
+		"lock.hosted_by":  host,
+	}
+	result, err := s.servers().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lock.heartbeat_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (s *MongoStore) ReleaseLock(ctx context.Context, serverID string, host string) (bool, error) {
+	filter := bson.M{"_id": serverID}
+	if host != "" {
+		filter["lock.is_running"] = true
+		filter["lock.hosted_by"] = host
+	}
+	result, err := s.servers().UpdateOne(ctx, filter, bson.M{"$set": lockSet(ServerLock{})})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (s *MongoStore) SetPendingUpload(ctx context.Context, serverID string, host string, pending bool) (bool, error) {
+	filter := bson.M{
+		"_id":             serverID,
+		"lock.is_running": true,
+		"lock.hosted_by":  host,
+	}
+	result, err := s.servers().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lock.pending_upload": pending}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
@@ -0,0 +1,111 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// offlineStore stands in when the database couldn't be opened at startup,
+// so every call fails with that error instead of panicking on a nil Store
+type offlineStore struct {
+	err error
+}
+
+func newOfflineStore(err error) *offlineStore {
+	return &offlineStore{err: fmt.Errorf("database unavailable: %v", err)}
+}
+
+func (s *offlineStore) GetUser(ctx context.Context, username string) (User, error) {
+	return User{}, s.err
+}
+func (s *offlineStore) CreateUser(ctx context.Context, user User) error { return s.err }
+func (s *offlineStore) SetPlayitConfig(ctx context.Context, username string, content string) error {
+	return s.err
+}
+func (s *offlineStore) SetUserKeys(ctx context.Context, username string, publicKey string, encryptedPrivateKey string, salt string) error {
+	return s.err
+}
+
+func (s *offlineStore) CreateSession(ctx context.Context, session Session) error { return s.err }
+func (s *offlineStore) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	return Session{}, s.err
+}
+func (s *offlineStore) DeleteSession(ctx context.Context, sessionID string, tokenHash string) error {
+	return s.err
+}
+func (s *offlineStore) DeleteUserSessions(ctx context.Context, username string) (int64, error) {
+	return 0, s.err
+}
+
+func (s *offlineStore) GetServer(ctx context.Context, serverID string) (ServerGroup, error) {
+	return ServerGroup{}, s.err
+}
+func (s *offlineStore) GetServerByInvite(ctx context.Context, inviteHash string) (ServerGroup, error) {
+	return ServerGroup{}, s.err
+}
+func (s *offlineStore) ListServersForMember(ctx context.Context, username string) ([]ServerGroup, error) {
+	return nil, s.err
+}
+func (s *offlineStore) CreateServer(ctx context.Context, server ServerGroup) error { return s.err }
+func (s *offlineStore) DeleteServer(ctx context.Context, serverID string) error    { return s.err }
+func (s *offlineStore) AddMember(ctx context.Context, serverID string, username string) error {
+	return s.err
+}
+func (s *offlineStore) RemoveMember(ctx context.Context, serverID string, username string) error {
+	return s.err
+}
+func (s *offlineStore) AddAdmin(ctx context.Context, serverID string, username string) error {
+	return s.err
+}
+func (s *offlineStore) RemoveAdmin(ctx context.Context, serverID string, username string) error {
+	return s.err
+}
+func (s *offlineStore) SetServerVersion(ctx context.Context, serverID string, serverType string, version string) error {
+	return s.err
+}
+func (s *offlineStore) SetWorldSetting(ctx context.Context, serverID string, key string, value interface{}) error {
+	return s.err
+}
+func (s *offlineStore) SetSyncStatus(ctx context.Context, serverID string, status string, user string, at time.Time) error {
+	return s.err
+}
+func (s *offlineStore) SetSyncState(ctx context.Context, serverID string, prevGeneration int64, state SyncState) (bool, error) {
+	return false, s.err
+}
+func (s *offlineStore) SetTunnelURL(ctx context.Context, serverID string, url string) error {
+	return s.err
+}
+func (s *offlineStore) SetLaunchProfile(ctx context.Context, serverID string, profile LaunchProfile) error {
+	return s.err
+}
+func (s *offlineStore) SetServerCredentials(ctx context.Context, serverID string, encryptedConfig string, shares map[string]string, invite GroupInvite) error {
+	return s.err
+}
+func (s *offlineStore) SetInvite(ctx context.Context, serverID string, invite GroupInvite) error {
+	return s.err
+}
+func (s *offlineStore) SetKeyShare(ctx context.Context, serverID string, username string, share string) error {
+	return s.err
+}
+
+func (s *offlineStore) ListVersions(ctx context.Context) ([]ServerVersion, error) { return nil, s.err }
+func (s *offlineStore) UpsertVersions(ctx context.Context, versions []ServerVersion) error {
+	return s.err
+}
+
+func (s *offlineStore) AcquireLock(ctx context.Context, serverID string, lock ServerLock) (bool, error) {
+	return false, s.err
+}
+func (s *offlineStore) TakeOverLock(ctx context.Context, serverID string, prev ServerLock, staleBefore time.Time, lock ServerLock) (bool, error) {
+	return false, s.err
+}
+func (s *offlineStore) RefreshLock(ctx context.Context, serverID string, host string, at time.Time) (bool, error) {
+	return false, s.err
+}
+func (s *offlineStore) ReleaseLock(ctx context.Context, serverID string, host string) (bool, error) {
+	return false, s.err
+}
+func (s *offlineStore) SetPendingUpload(ctx context.Context, serverID string, host string, pending bool) (bool, error) {
+	return false, s.err
+}
@@ -8,6 +8,13 @@ type User struct {
 	Username          string `bson:"username" json:"username"`
 	PasswordHash      string `bson:"password_hash" json:"-"`       // "-" means never send this to Frontend
 	PlayitTomlContent string `bson:"playit_toml_content" json:"-"` // Store user's playit.toml config
+
+	// --- KEYS ---
+	// Curve25519 key pair used to receive group keys. The private key is
+	// encrypted with a key derived from the user's password.
+	PublicKey           string `bson:"public_key" json:"-"`
+	EncryptedPrivateKey string `bson:"encrypted_private_key" json:"-"`
+	KeySalt             string `bson:"key_salt" json:"-"`
 }
 
 // --- ADD THIS BELOW ---
@@ -18,6 +25,11 @@ type ServerVersion struct {
 	Version string `bson:"version" json:"version"` // e.g., "1.20.4"
 	Type    string `bson:"type" json:"type"`       // e.g., "Paper", "Vanilla"
 	Url     string `bson:"url" json:"url"`         // Direct download link
+
+	// Filled in when a provider resolves the latest build
+	Build     string `bson:"build,omitempty" json:"build,omitempty"`
+	Checksum  string `bson:"checksum,omitempty" json:"checksum,omitempty"`   // "sha256:<hex>", "sha1:<hex>" or "md5:<hex>"
+	Installer bool   `bson:"installer,omitempty" json:"installer,omitempty"` // Url is an installer, not a runnable server jar
 }
 
 // ServerGroup represents a Minecraft Server Group
@@ -30,19 +42,61 @@ type ServerGroup struct {
 	Version string `bson:"version" json:"version"` // e.g. "1.20.4"
 	// ------------------
 
-	InviteCode    string                 `bson:"invite_code" json:"invite_code"`
+	InviteCode    string                 `bson:"-" json:"invite_code"` // Filled in for members from EncryptedInviteCode
 	OwnerID       string                 `bson:"owner_id" json:"owner_id"`
 	Owner         string                 `bson:"-" json:"owner"`
 	Members       []string               `bson:"members" json:"members"`
-	Admins        []string               `bson:"admins" json:"admins"` // List of usernames with admin privileges
-	RcloneConfig  string                 `bson:"rclone_config" json:"-"`
+	Admins        []string               `bson:"admins" json:"admins"`                 // List of usernames with admin privileges
+	RcloneConfig  string                 `bson:"rclone_config" json:"-"`               // Legacy plaintext config, cleared once encrypted
 	WorldSettings map[string]interface{} `bson:"world_settings" json:"world_settings"` // Stores { "keepInventory": true, "difficulty": "hard" }
 	Lock          ServerLock             `bson:"lock" json:"lock"`
 
+	// --- ENCRYPTED CLOUD CREDENTIALS ---
+	// The rclone config is encrypted with a random group key. Each member
+	// gets the group key sealed to their public key; new members unwrap the
+	// invite share with the invite code when they join. The code itself is
+	// only stored hashed (to find the group) and sealed under the group key
+	// (so members can show it); reading the DB doesn't reveal it.
+	EncryptedRcloneConfig string            `bson:"rclone_config_enc" json:"-"`
+	KeyShares             map[string]string `bson:"key_shares" json:"-"` // username -> sealed group key
+	InviteKeyShare        string            `bson:"invite_key_share" json:"-"`
+	InviteHash            string            `bson:"invite_hash" json:"-"`
+	EncryptedInviteCode   string            `bson:"invite_code_enc" json:"-"`
+	LegacyInviteCode      string            `bson:"invite_code,omitempty" json:"-"` // Plaintext code from before hashing, replaced on next unlock
+
+	// --- LAUNCH ---
+	Launch LaunchProfile `bson:"launch" json:"launch"`
+
 	// --- SYNC STATE TRACKING ---
 	LastSyncStatus string    `bson:"last_sync_status" json:"last_sync_status"` // "ok", "error", etc.
 	LastSyncUser   string    `bson:"last_sync_user" json:"last_sync_user"`
 	LastSyncTime   time.Time `bson:"last_sync_time" json:"last_sync_time"`
+	SyncState      SyncState `bson:"sync_state" json:"sync_state"` // Version of the cloud copy
+}
+
+// GroupInvite is what the DB keeps of an invite code
+type GroupInvite struct {
+	Hash          string // Slow hash of the code; JoinServer looks the group up by it
+	EncryptedCode string // The code sealed under the group key, for members to show
+	KeyShare      string // The group key sealed under the code
+}
+
+// SyncState identifies one version of a group's cloud copy
+type SyncState struct {
+	Generation   int64     `bson:"generation" json:"generation"`       // Bumped by every upload
+	ManifestHash string    `bson:"manifest_hash" json:"manifest_hash"` // Hash of the cloud file listing
+	UpdatedBy    string    `bson:"updated_by" json:"updated_by"`
+	UpdatedAt    time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// LaunchProfile is how a group's server is started. Zero values mean defaults.
+type LaunchProfile struct {
+	MinMemoryMB int      `bson:"min_memory_mb" json:"min_memory_mb"` // 0 = same as max
+	MaxMemoryMB int      `bson:"max_memory_mb" json:"max_memory_mb"` // 0 = 2048
+	Preset      string   `bson:"preset" json:"preset"`               // "" or "aikar"
+	JVMArgs     []string `bson:"jvm_args" json:"jvm_args"`           // Extra JVM flags
+	JarName     string   `bson:"jar_name" json:"jar_name"`           // "" = the installed server's jar (or loader @args file)
+	ServerArgs  []string `bson:"server_args" json:"server_args"`     // Passed after "nogui"
 }
 
 type ServerLock struct {
@@ -50,7 +104,28 @@ type ServerLock struct {
 	HostedBy  string    `bson:"hosted_by" json:"hosted_by"`
 	HostedAt  time.Time `bson:"hosted_at" json:"hosted_at"`
 	IPAddress string    `bson:"ip_address" json:"ip_address"`
-	Port      int       `bson:"port" json:"port"` // Active port (25565 or fallback)
+	Port      int       `bson:"port" json:"port"`             // Active port (25565 or fallback)
+	TunnelURL string    `bson:"tunnel_url" json:"tunnel_url"` // Public Playit address, if any
+
+	// --- LEASE ---
+	// The host refreshes HeartbeatAt while the server process is alive.
+	// A lock whose heartbeat is older than leaseTTL can be taken over.
+	HeartbeatAt   time.Time `bson:"heartbeat_at" json:"heartbeat_at"`
+	TakenOverFrom string    `bson:"taken_over_from" json:"taken_over_from,omitempty"` // Previous host if the lock was taken over
+	TakenOverAt   time.Time `bson:"taken_over_at" json:"taken_over_at,omitempty"`
+
+	// PendingUpload is set when the host stopped but its upload failed.
+	// The lock stays held (and can't be taken over) until the upload lands
+	// or the owner abandons that session.
+	PendingUpload bool `bson:"pending_upload" json:"pending_upload,omitempty"`
+}
+
+// Snapshot is a dated copy of a server's cloud folder
+type Snapshot struct {
+	Name      string    `json:"name"` // Folder name, e.g. "20240131-184502"
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"` // Bytes
+	Files     int       `json:"files"`
 }
 
 // PlayerStructs for reading Minecraft JSON files
@@ -0,0 +1,286 @@
+package backend
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OutputListener receives every console line a server process prints.
+// isErr is true for lines that came from stderr.
+type OutputListener func(serverID string, line string, isErr bool)
+
+// ServerProcess is one running Minecraft server owned by the Supervisor
+type ServerProcess struct {
+	ServerID string
+	Dir      string
+	Port     int
+	PID      int
+	Started  time.Time
+
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	pidFile string
+
+	mu        sync.Mutex
+	listeners []OutputListener
+	stdinOpen bool
+	stopping  bool // Stop was called, so an exit is expected
+
+	done    chan struct{}
+	exitErr error
+}
+
+// Supervisor keeps track of running server processes, keyed by serverID.
+// This lets one PC host several groups at once without commands for
+// server A landing in server B.
+type Supervisor struct {
+	mu       sync.Mutex
+	procs    map[string]*ServerProcess
+	starting map[string]bool // Slots reserved by a Start that hasn't finished
+}
+
+// NewSupervisor creates an empty process supervisor
+func NewSupervisor() *Supervisor {
+	return &Supervisor{procs: map[string]*ServerProcess{}, starting: map[string]bool{}}
+}
+
+// Start launches cmd for serverID and takes ownership of its stdin, output,
+// exit status and PID file. Listeners are attached before the process starts
+// so no early output is lost.
+func (s *Supervisor) Start(serverID string, cmd *exec.Cmd, port int, listeners ...OutputListener) (*ServerProcess, error) {
+	s.mu.Lock()
+	if p, ok := s.procs[serverID]; ok && p.Running() {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("server %s is already running (PID: %d)", serverID, p.PID)
+	}
+	if s.starting[serverID] {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("server %s is already starting", serverID)
+	}
+	// Hold the slot until the process is in procs, so two concurrent
+	// starts can't both spawn one
+	s.starting[serverID] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.starting, serverID)
+		s.mu.Unlock()
+	}()
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ServerProcess{
+		ServerID:  serverID,
+		Dir:       cmd.Dir,
+		Port:      port,
+		cmd:       cmd,
+		stdin:     stdin,
+		stdinOpen: true,
+		listeners: listeners,
+		pidFile:   filepath.Join(cmd.Dir, "server.pid"),
+		done:      make(chan struct{}),
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p.PID = cmd.Process.Pid
+	p.Started = time.Now()
+
+	// Save PID so a crashed app can clean up the zombie on next launch
+	writePIDFile(p.pidFile, identify(p.PID))
+
+	s.mu.Lock()
+	s.procs[serverID] = p
+	s.mu.Unlock()
+
+	var streams sync.WaitGroup
+	streams.Add(2)
+	go p.pump(stdout, false, &streams)
+	go p.pump(stderr, true, &streams)
+
+	go func() {
+		// Drain output before Wait closes the pipes
+		streams.Wait()
+		err := cmd.Wait()
+
+		p.mu.Lock()
+		p.exitErr = err
+		p.stdinOpen = false
+		p.mu.Unlock()
+
+		os.Remove(p.pidFile)
+		close(p.done)
+
+		s.mu.Lock()
+		if s.procs[serverID] == p {
+			delete(s.procs, serverID)
+		}
+		s.mu.Unlock()
+	}()
+
+	return p, nil
+}
+
+// Get returns the running process for a server, or nil
+func (s *Supervisor) Get(serverID string) *ServerProcess {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.procs[serverID]
+	if !ok || !p.Running() {
+		return nil
+	}
+	return p
+}
+
+// IsRunning reports whether the server has a live process on this PC
+func (s *Supervisor) IsRunning(serverID string) bool {
+	return s.Get(serverID) != nil
+}
+
+// RunningIDs lists every server currently hosted by this PC
+func (s *Supervisor) RunningIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.procs))
+	for id, p := range s.procs {
+		if p.Running() {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Send writes one console line to the server's stdin
+func (s *Supervisor) Send(serverID string, command string) error {
+	p := s.Get(serverID)
+	if p == nil {
+		return fmt.Errorf("server is not online")
+	}
+	return p.Send(command)
+}
+
+// Stop asks the server to shut down with "stop" and force kills it if it
+// hasn't exited after timeout.
+func (s *Supervisor) Stop(serverID string, timeout time.Duration) (graceful bool, err error) {
+	p := s.Get(serverID)
+	if p == nil {
+		return true, nil
+	}
+	p.mu.Lock()
+	p.stopping = true
+	p.mu.Unlock()
+
+	if err := p.Send("stop"); err == nil {
+		p.closeStdin()
+		select {
+		case <-p.done:
+			return true, nil
+		case <-time.After(timeout):
+		}
+	}
+
+	// Fallback to force kill, including anything the launcher spawned
+	if err := killProcessTree(p.PID); err != nil {
+		return false, err
+	}
+	<-p.done
+	return false, nil
+}
+
+// Running reports whether the process has not exited yet
+func (p *ServerProcess) Running() bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Done is closed when the process exits
+func (p *ServerProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// ExitErr returns the error from cmd.Wait once the process has exited
+func (p *ServerProcess) ExitErr() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exitErr
+}
+
+// Crashed reports whether the process exited on its own with an error
+func (p *ServerProcess) Crashed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.Running() && !p.stopping && p.exitErr != nil
+}
+
+// Quit reports whether the process ended on its own without an error
+// (e.g. someone typed "stop" in the game)
+func (p *ServerProcess) Quit() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.Running() && !p.stopping && p.exitErr == nil
+}
+
+// Send writes a command to the server console
+// Note: Minecraft commands need a newline "\n" at the end
+func (p *ServerProcess) Send(command string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.stdinOpen {
+		return fmt.Errorf("console is closed")
+	}
+	_, err := p.stdin.Write([]byte(command + "\n"))
+	return err
+}
+
+// Subscribe adds an output listener to an already running process
+func (p *ServerProcess) Subscribe(fn OutputListener) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listeners = append(p.listeners, fn)
+}
+
+func (p *ServerProcess) closeStdin() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stdinOpen {
+		p.stdin.Close()
+		p.stdinOpen = false
+	}
+}
+
+// pump reads one output stream line by line and fans it out to listeners
+func (p *ServerProcess) pump(r io.Reader, isErr bool, wg *sync.WaitGroup) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		p.mu.Lock()
+		listeners := append([]OutputListener(nil), p.listeners...)
+		p.mu.Unlock()
+		for _, fn := range listeners {
+			fn(p.ServerID, line, isErr)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package backend
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHelperServer stands in for a Minecraft server when run by
+// helperServer: it echoes console lines, exits on "stop" and crashes on
+// "crash". A "stubborn" one ignores stop so it has to be killed.
+func TestHelperServer(t *testing.T) {
+	mode := os.Getenv("MC_ROAM_HELPER_SERVER")
+	if mode == "" {
+		return
+	}
+	fmt.Println("Done (1.0s)! For help, type \"help\"")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		switch line := scanner.Text(); {
+		case line == "stop" && mode != "stubborn":
+			os.Exit(0)
+		case line == "crash":
+			fmt.Fprintln(os.Stderr, "boom")
+			os.Exit(3)
+		default:
+			fmt.Println(os.Getenv("MC_ROAM_HELPER_ID") + " got " + line)
+		}
+	}
+	if mode == "stubborn" {
+		time.Sleep(time.Minute)
+	}
+	os.Exit(0)
+}
+
+// helperServer returns a command running TestHelperServer in a temp folder
+func helperServer(t *testing.T, serverID string, mode string) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperServer$")
+	cmd.Env = append(os.Environ(), "MC_ROAM_HELPER_SERVER="+mode, "MC_ROAM_HELPER_ID="+serverID)
+	cmd.Dir = t.TempDir()
+	return cmd
+}
+
+type consoleLine struct {
+	serverID string
+	line     string
+	isErr    bool
+}
+
+// console collects the output of every process it listens to
+func console() (OutputListener, chan consoleLine) {
+	lines := make(chan consoleLine, 100)
+	return func(serverID string, line string, isErr bool) {
+		lines <- consoleLine{serverID, line, isErr}
+	}, lines
+}
+
+func waitLine(t *testing.T, lines chan consoleLine, want consoleLine) {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case got := <-lines:
+			if got == want {
+				return
+			}
+			if strings.Contains(got.line, " got ") { // An echo for the wrong server
+				t.Fatalf("got %+v, want %+v", got, want)
+			}
+		case <-timeout:
+			t.Fatalf("no %+v", want)
+		}
+	}
+}
+
+func TestSupervisorKeyedByServerID(t *testing.T) {
+	s := NewSupervisor()
+	listen, lines := console()
+
+	a, err := s.Start("a", helperServer(t, "a", "normal"), 25565, listen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop("a", time.Second)
+	b, err := s.Start("b", helperServer(t, "b", "normal"), 25566, listen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop("b", time.Second)
+
+	if _, err := s.Start("a", helperServer(t, "a", "normal"), 25567); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("second start of a = %v, want already running", err)
+	}
+	ids := s.RunningIDs()
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("RunningIDs = %v", ids)
+	}
+	if s.Get("a") != a || s.Get("b") != b || a.Port != 25565 || b.Port != 25566 {
+		t.Errorf("Get doesn't return the process started for each server")
+	}
+	if _, err := os.Stat(filepath.Join(a.Dir, "server.pid")); err != nil {
+		t.Errorf("no PID file: %v", err)
+	}
+
+	// Commands only reach the server they were sent to
+	if err := s.Send("b", "list"); err != nil {
+		t.Fatal(err)
+	}
+	waitLine(t, lines, consoleLine{"b", "b got list", false})
+	if err := s.Send("a", "list"); err != nil {
+		t.Fatal(err)
+	}
+	waitLine(t, lines, consoleLine{"a", "a got list", false})
+	if err := s.Send("c", "list"); err == nil {
+		t.Error("Send to a server that isn't running succeeded")
+	}
+
+	// Stopping a leaves b alone
+	graceful, err := s.Stop("a", 10*time.Second)
+	if err != nil || !graceful {
+		t.Fatalf("Stop(a) = %v, %v; want a graceful stop", graceful, err)
+	}
+	if s.IsRunning("a") || !s.IsRunning("b") {
+		t.Errorf("after stopping a: a running %v, b running %v", s.IsRunning("a"), s.IsRunning("b"))
+	}
+	if !reflect.DeepEqual(s.RunningIDs(), []string{"b"}) {
+		t.Errorf("RunningIDs = %v, want [b]", s.RunningIDs())
+	}
+	if a.Crashed() || a.Quit() {
+		t.Errorf("a stopped by Stop counts as crashed %v / quit %v", a.Crashed(), a.Quit())
+	}
+	if _, err := os.Stat(filepath.Join(a.Dir, "server.pid")); !os.IsNotExist(err) {
+		t.Errorf("PID file left behind: %v", err)
+	}
+	if err := s.Send("b", "list"); err != nil {
+		t.Fatalf("b stopped listening: %v", err)
+	}
+	waitLine(t, lines, consoleLine{"b", "b got list", false})
+
+	// a can start again once it exited
+	again, err := s.Start("a", helperServer(t, "a", "normal"), 25565)
+	if err != nil {
+		t.Fatalf("restart of a: %v", err)
+	}
+	if again == a || s.Get("a") != again {
+		t.Error("restart didn't replace the old process")
+	}
+}
+
+func TestSupervisorExits(t *testing.T) {
+	s := NewSupervisor()
+	listen, lines := console()
+
+	crash, err := s.Start("crash", helperServer(t, "crash", "normal"), 0, listen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quit, err := s.Start("quit", helperServer(t, "quit", "normal"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crash.Send("crash")
+	quit.Send("stop") // Typed in the game, not through Stop
+
+	waitLine(t, lines, consoleLine{"crash", "boom", true})
+	for _, p := range []*ServerProcess{crash, quit} {
+		select {
+		case <-p.Done():
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%s didn't exit", p.ServerID)
+		}
+	}
+	if !crash.Crashed() || crash.Quit() || crash.ExitErr() == nil {
+		t.Errorf("crash: crashed %v, quit %v, err %v", crash.Crashed(), crash.Quit(), crash.ExitErr())
+	}
+	if quit.Crashed() || !quit.Quit() {
+		t.Errorf("quit: crashed %v, quit %v, err %v", quit.Crashed(), quit.Quit(), quit.ExitErr())
+	}
+	if ids := s.RunningIDs(); len(ids) != 0 {
+		t.Errorf("RunningIDs = %v after both exited", ids)
+	}
+	if err := crash.Send("list"); err == nil {
+		t.Error("Send to an exited process succeeded")
+	}
+}
+
+func TestSupervisorStopKills(t *testing.T) {
+	s := NewSupervisor()
+	p, err := s.Start("stubborn", helperServer(t, "stubborn", "stubborn"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	graceful, err := s.Stop("stubborn", 200*time.Millisecond)
+	if err != nil || graceful {
+		t.Fatalf("Stop = %v, %v; want a forced stop", graceful, err)
+	}
+	if p.Running() || s.IsRunning("stubborn") {
+		t.Error("still running after Stop")
+	}
+
+	// Stopping what isn't running is a no-op
+	if graceful, err := s.Stop("stubborn", time.Second); err != nil || !graceful {
+		t.Errorf("second Stop = %v, %v", graceful, err)
+	}
+}
@@ -0,0 +1,424 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Every upload bumps the group's generation and records a hash of the cloud
+// file listing. The state is kept in the store and, so the folder describes
+// itself, in syncStateFile at the remote root. Each host remembers the state
+// it last downloaded (or uploaded) in syncBaseFile; an upload is only allowed
+// if the cloud still matches that base.
+const (
+	syncStateFile = ".mcroam-sync.json" // Remote: state of the cloud copy
+	syncBaseFile  = ".mcroam-base.json" // Local: the state this copy came from
+)
+
+// SyncConflictError means the cloud moved on since this host downloaded it
+type SyncConflictError struct {
+	Reason string
+	Local  SyncState
+	Remote SyncState
+}
+
+func (e *SyncConflictError) Error() string {
+	return "Sync conflict: " + e.Reason
+}
+
+// syncExcludes are never synced (and not part of the manifest)
+func syncExcludes() []string {
+	return []string{
+		"--exclude", "session.lock",
+		"--exclude", "logs/**",
+		"--exclude", "cache/**",
+		// Rebuilt on each host from the build in loaderFile (see ensureLoader)
+		"--exclude", "/libraries/**",
+		"--exclude", "/versions/**",
+		"--exclude", "/.fabric/**",
+		"--exclude", "crash-reports/**",
+		"--exclude", "playit.toml", // NEVER sync - stored in user's DB instead
+		"--exclude", "/" + snapshotsDir + "/**", // Snapshots live remote-side only
+		"--exclude", "/" + syncStateFile,
+		"--exclude", "/" + syncBaseFile,
+	}
+}
+
+// remoteSyncState reads the cloud copy's state. The store and the remote
+// file should agree; if one update was lost, the higher generation wins.
+func (a *App) remoteSyncState(serverID string) (SyncState, error) {
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return SyncState{}, err
+	}
+	state := server.SyncState
+
+	cmd, err := a.rcloneCommand(serverID, "cat", "mc-remote:server-"+serverID+"/"+syncStateFile)
+	if err != nil {
+		return SyncState{}, err
+	}
+	if out, err := cmd.Output(); err == nil {
+		var file SyncState
+		if json.Unmarshal(out, &file) == nil && file.Generation > state.Generation {
+			state = file
+		}
+	}
+	return state, nil
+}
+
+// remoteManifest hashes the cloud file listing (path, size, mod time)
+func (a *App) remoteManifest(serverID string) (string, error) {
+	args := append([]string{"lsjson", "mc-remote:server-" + serverID, "--recursive", "--files-only"}, syncExcludes()...)
+	cmd, err := a.rcloneCommand(serverID, args...)
+	if err != nil {
+		return "", err
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not list cloud files: %v", err)
+	}
+
+	var entries []struct {
+		Path    string    `json:"Path"`
+		Size    int64     `json:"Size"`
+		ModTime time.Time `json:"ModTime"`
+	}
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return "", fmt.Errorf("could not parse rclone listing: %v", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	hash := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(hash, "%s\t%d\t%d\n", e.Path, e.Size, e.ModTime.Unix())
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func readSyncBase(localPath string) (SyncState, bool) {
+	data, err := os.ReadFile(filepath.Join(localPath, syncBaseFile))
+	if err != nil {
+		return SyncState{}, false
+	}
+	var base SyncState
+	if json.Unmarshal(data, &base) != nil {
+		return SyncState{}, false
+	}
+	return base, true
+}
+
+func writeSyncBase(localPath string, state SyncState) error {
+	data, _ := json.MarshalIndent(state, "", "  ")
+	return os.WriteFile(filepath.Join(localPath, syncBaseFile), data, 0644)
+}
+
+// syncDown downloads the cloud copy and remembers which state it was
+func (a *App) syncDown(serverID string, localPath string) error {
+	// A failed download must not leave the old base behind, or a stale
+	// copy could later be uploaded as if it were current
+	os.Remove(filepath.Join(localPath, syncBaseFile))
+
+	state, err := a.remoteSyncState(serverID)
+	if err != nil {
+		return err
+	}
+	if err := a.runSync(SyncDown, "server-"+serverID, localPath); err != nil {
+		return err
+	}
+
+	manifest, err := a.remoteManifest(serverID)
+	if err != nil {
+		return err
+	}
+	if state.ManifestHash != "" && state.ManifestHash != manifest {
+		a.Log("⚠️ Cloud files were changed outside mc-roam since the last upload.")
+	}
+	state.ManifestHash = manifest
+	return writeSyncBase(localPath, state)
+}
+
+// checkSyncUp refuses an upload that would overwrite progress made elsewhere
+func (a *App) checkSyncUp(serverID string, localPath string) (SyncState, error) {
+	remote, err := a.remoteSyncState(serverID)
+	if err != nil {
+		return SyncState{}, err
+	}
+	base, ok := readSyncBase(localPath)
+	return remote, syncUpConflict(base, ok, remote, func() (string, error) {
+		return a.remoteManifest(serverID)
+	})
+}
+
+// syncUpConflict compares the state a copy was downloaded from (hasBase is
+// false if it never was) with the cloud's. manifest lists the cloud files;
+// it is only called once the generations match.
+func syncUpConflict(base SyncState, hasBase bool, remote SyncState, manifest func() (string, error)) error {
+	if !hasBase {
+		// Only a brand-new (or pre-generation) group may receive a copy
+		// that was never downloaded
+		if remote.Generation > 0 {
+			return &SyncConflictError{
+				Reason: "this copy was never downloaded from the cloud (or the last download failed)",
+				Remote: remote,
+			}
+		}
+		return nil
+	}
+
+	if base.Generation != remote.Generation {
+		return &SyncConflictError{
+			Reason: fmt.Sprintf("%s uploaded a newer version (%d) at %s; this copy is based on version %d",
+				remote.UpdatedBy, remote.Generation, remote.UpdatedAt.Local().Format("Jan 2 15:04"), base.Generation),
+			Local:  base,
+			Remote: remote,
+		}
+	}
+
+	current, err := manifest()
+	if err != nil {
+		return err
+	}
+	if base.ManifestHash != "" && current != base.ManifestHash {
+		return &SyncConflictError{
+			Reason: "the cloud files changed since this copy was downloaded",
+			Local:  base,
+			Remote: remote,
+		}
+	}
+	return nil
+}
+
+// syncUp uploads a local copy if it is still based on the cloud's current
+// state, then bumps the generation. With snapshotFirst the previous cloud
+// state is kept as a snapshot.
+func (a *App) syncUp(serverID string, localPath string, username string, snapshotFirst bool) error {
+	remote, err := a.checkSyncUp(serverID, localPath)
+	if err != nil {
+		return err
+	}
+
+	if snapshotFirst {
+		if _, err := a.createSnapshot(serverID); err != nil {
+			a.Log("⚠️ Could not snapshot previous state: " + err.Error())
+		}
+	}
+	return a.pushSync(serverID, localPath, username, remote)
+}
+
+// checkResumeSyncUp is checkSyncUp for retrying our own failed upload. The
+// cloud files may be half-written by that attempt, so only the generation
+// is compared; the held lock kept anyone else from uploading meanwhile.
+func (a *App) checkResumeSyncUp(serverID string, localPath string) (SyncState, error) {
+	remote, err := a.remoteSyncState(serverID)
+	if err != nil {
+		return SyncState{}, err
+	}
+	base, ok := readSyncBase(localPath)
+	if !ok && remote.Generation > 0 {
+		return remote, &SyncConflictError{Reason: "this copy was never downloaded from the cloud", Remote: remote}
+	}
+	if ok && base.Generation != remote.Generation {
+		return remote, &SyncConflictError{
+			Reason: fmt.Sprintf("%s uploaded a newer version (%d) while this upload was pending", remote.UpdatedBy, remote.Generation),
+			Local:  base,
+			Remote: remote,
+		}
+	}
+	return remote, nil
+}
+
+// pushSync uploads localPath over the cloud copy whose state is remote and
+// records the next generation. It fails if the generation moved meanwhile.
+func (a *App) pushSync(serverID string, localPath string, username string, remote SyncState) error {
+	if err := a.runSync(SyncUp, "server-"+serverID, localPath); err != nil {
+		return err
+	}
+	next, err := a.recordSyncState(serverID, username, remote)
+	if err != nil {
+		return err
+	}
+	return writeSyncBase(localPath, next)
+}
+
+// recordSyncState records the next generation after the cloud copy whose
+// state was remote has been replaced, in the store and the remote state file.
+func (a *App) recordSyncState(serverID string, username string, remote SyncState) (SyncState, error) {
+	next := SyncState{
+		Generation: remote.Generation + 1,
+		UpdatedBy:  username,
+		UpdatedAt:  time.Now(),
+	}
+	var err error
+	if next.ManifestHash, err = a.remoteManifest(serverID); err != nil {
+		a.Log("⚠️ " + err.Error())
+	}
+
+	// Without the new generation recorded, neither the remote state file nor
+	// our base may claim it. The upload stays pending; a retry either records
+	// it or finds the conflict.
+	ctx, cancel := dbContext()
+	updated, err := a.store.SetSyncState(ctx, serverID, remote.Generation, next)
+	cancel()
+	if err != nil {
+		return SyncState{}, fmt.Errorf("could not record the new cloud version: %v", err)
+	}
+	if !updated {
+		return SyncState{}, fmt.Errorf("could not record the new cloud version; another host uploaded at the same time")
+	}
+
+	data, _ := json.MarshalIndent(next, "", "  ")
+	cmd, err := a.rcloneCommand(serverID, "rcat", "mc-remote:server-"+serverID+"/"+syncStateFile)
+	if err == nil {
+		cmd.Stdin = bytes.NewReader(data)
+		if out, runErr := cmd.CombinedOutput(); runErr != nil {
+			a.Log("⚠️ Could not write cloud version file: " + strings.TrimSpace(string(out)))
+		}
+	}
+	return next, nil
+}
+
+// SyncNow downloads the cloud copy to this PC or uploads this PC's copy,
+// outside of a hosting session. Nobody may be hosting the server, and the
+// server lock is held until the sync is done.
+func (a *App) SyncNow(serverID string, token string, direction SyncDirection) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if !a.isMember(serverID, username) {
+		return "Error: You are not a member of this server"
+	}
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return "Error: Server not found"
+	}
+	if server.Lock.IsRunning || a.procs.IsRunning(serverID) {
+		return "Error: Stop the server first."
+	}
+
+	if direction != SyncDown && direction != SyncUp {
+		return "Error: Unknown direction " + string(direction)
+	}
+
+	localPath := a.getInstancePath(serverID)
+	err = a.withServerLock(serverID, username, func(context.Context) error {
+		switch direction {
+		case SyncDown:
+			if err := os.MkdirAll(localPath, 0755); err != nil {
+				return err
+			}
+			if err := a.syncDown(serverID, localPath); err != nil {
+				return fmt.Errorf("Sync failed: %v", err)
+			}
+		case SyncUp:
+			if _, err := os.Stat(localPath); err != nil {
+				return fmt.Errorf("There is no local copy of this server on this PC")
+			}
+			err := a.syncUp(serverID, localPath, username, true)
+			status := "ok"
+			if err != nil {
+				status = "error"
+				var conflict *SyncConflictError
+				if errors.As(err, &conflict) {
+					status = "conflict"
+				}
+			}
+			ctx, cancel := dbContext()
+			_ = a.store.SetSyncStatus(ctx, serverID, status, username, time.Now())
+			cancel()
+			if err != nil {
+				return fmt.Errorf("Upload failed: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Success: Synced " + string(direction)
+}
+
+// SyncStatus summarizes where a group's data stands
+type SyncStatus struct {
+	LastStatus    string         `json:"last_status"` // "ok", "error", "conflict", "abandoned"
+	LastUser      string         `json:"last_user"`
+	LastTime      time.Time      `json:"last_time"`
+	Cloud         SyncState      `json:"cloud"`          // Version of the cloud copy
+	PendingUpload bool           `json:"pending_upload"` // A session's upload hasn't landed yet
+	PendingHost   string         `json:"pending_host,omitempty"`
+	Local         *PendingUpload `json:"local,omitempty"` // The queued upload, if it is on this PC
+}
+
+// GetSyncStatus returns the sync state of a server
+func (a *App) GetSyncStatus(serverID string, token string) SyncStatus {
+	username, err := a.authenticate(token)
+	if err != nil || !a.isMember(serverID, username) {
+		return SyncStatus{}
+	}
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return SyncStatus{}
+	}
+
+	status := SyncStatus{
+		LastStatus:    server.LastSyncStatus,
+		LastUser:      server.LastSyncUser,
+		LastTime:      server.LastSyncTime,
+		Cloud:         server.SyncState,
+		PendingUpload: server.Lock.PendingUpload,
+	}
+	if status.PendingUpload {
+		status.PendingHost = server.Lock.HostedBy
+	}
+	for _, p := range a.pendingUploads() {
+		if p.ServerID == serverID {
+			p := p
+			status.Local = &p
+		}
+	}
+	return status
+}
+
+// SaveLocalCopyAsSnapshot uploads this PC's copy of a server as a new
+// snapshot instead of overwriting the cloud. Used after a sync conflict.
+func (a *App) SaveLocalCopyAsSnapshot(serverID string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if !a.isMember(serverID, username) {
+		return "Error: You are not a member of this server"
+	}
+	if a.procs.IsRunning(serverID) {
+		return "Error: Stop the server first."
+	}
+
+	localPath := a.getInstancePath(serverID)
+	if _, err := os.Stat(localPath); err != nil {
+		return "Error: There is no local copy of this server on this PC"
+	}
+
+	name := time.Now().UTC().Format(snapshotTimeLayout)
+	a.Log(fmt.Sprintf("📸 Saving your local copy as snapshot %s...", name))
+	args := append([]string{"copy", localPath, snapshotRoot(serverID) + "/" + name, "--transfers", "4"}, syncExcludes()...)
+	cmd, err := a.rcloneCommand(serverID, args...)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Sprintf("Error: Upload failed: %v (%s)", err, strings.TrimSpace(string(output)))
+	}
+
+	a.Log("✅ Your copy is saved. An admin can restore it from the snapshots list.")
+	return "Success: Saved as snapshot " + name
+}
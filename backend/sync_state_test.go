@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncUpConflict(t *testing.T) {
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	state := func(generation int64, manifest string) SyncState {
+		return SyncState{Generation: generation, ManifestHash: manifest, UpdatedBy: "bob", UpdatedAt: at}
+	}
+	listing := func(hash string) func() (string, error) {
+		return func() (string, error) { return hash, nil }
+	}
+	unlisted := func() (string, error) {
+		return "", errors.New("manifest listed although the generations differ")
+	}
+
+	tests := []struct {
+		name     string
+		base     SyncState
+		hasBase  bool
+		remote   SyncState
+		manifest func() (string, error)
+		wantErr  string // "" for no error
+		conflict bool
+	}{
+		{name: "new group, never downloaded", remote: state(0, ""), manifest: unlisted},
+		{name: "never downloaded", remote: state(3, "m3"), manifest: unlisted, wantErr: "never downloaded", conflict: true},
+		{name: "up to date", base: state(3, "m3"), hasBase: true, remote: state(3, "m3"), manifest: listing("m3")},
+		{name: "newer generation", base: state(2, "m2"), hasBase: true, remote: state(3, "m3"), manifest: unlisted, wantErr: "bob uploaded a newer version (3)", conflict: true},
+		{name: "older generation", base: state(4, "m4"), hasBase: true, remote: state(3, "m3"), manifest: unlisted, wantErr: "based on version 4", conflict: true},
+		{name: "files changed in the cloud", base: state(3, "m3"), hasBase: true, remote: state(3, "m3"), manifest: listing("edited"), wantErr: "cloud files changed", conflict: true},
+		{name: "base from before manifests", base: state(3, ""), hasBase: true, remote: state(3, "m3"), manifest: listing("anything")},
+		{
+			name: "listing fails", base: state(3, "m3"), hasBase: true, remote: state(3, "m3"),
+			manifest: func() (string, error) { return "", errors.New("could not list cloud files") },
+			wantErr:  "could not list",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := syncUpConflict(tt.base, tt.hasBase, tt.remote, tt.manifest)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("syncUpConflict = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("syncUpConflict = %v, want %q", err, tt.wantErr)
+			}
+			var conflict *SyncConflictError
+			if errors.As(err, &conflict) != tt.conflict {
+				t.Errorf("conflict = %v, want %v", !tt.conflict, tt.conflict)
+			}
+			if conflict != nil && conflict.Remote != tt.remote {
+				t.Errorf("conflict.Remote = %+v, want %+v", conflict.Remote, tt.remote)
+			}
+		})
+	}
+}
+
+func TestSyncBase(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok := readSyncBase(dir); ok {
+		t.Error("base read from an empty folder")
+	}
+
+	want := SyncState{Generation: 7, ManifestHash: "abc", UpdatedBy: "alice", UpdatedAt: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)}
+	if err := writeSyncBase(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := readSyncBase(dir)
+	if !ok || got.Generation != want.Generation || got.ManifestHash != want.ManifestHash || got.UpdatedBy != want.UpdatedBy || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("readSyncBase = %+v, %v; want %+v", got, ok, want)
+	}
+
+	// A corrupt base counts as none, so it can't vouch for the copy
+	if err := os.WriteFile(filepath.Join(dir, syncBaseFile), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readSyncBase(dir); ok {
+		t.Error("corrupt base accepted")
+	}
+}
@@ -0,0 +1,373 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// When the upload at the end of a session fails, the session is queued here
+// and retried in the background. The host keeps the lock, flagged as
+// pending_upload, so nobody can start (or take over) the group and play on
+// an older copy until the upload lands or the owner abandons it.
+const (
+	uploadRetryMin = 30 * time.Second
+	uploadRetryMax = 15 * time.Minute
+)
+
+// PendingUpload is a session on this PC whose upload hasn't landed yet
+type PendingUpload struct {
+	ServerID    string    `json:"server_id"`
+	Username    string    `json:"username"`  // Host of the session
+	HostedAt    time.Time `json:"hosted_at"` // Identifies the session's lock
+	LocalPath   string    `json:"local_path"`
+	FailedAt    time.Time `json:"failed_at"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// uploadWorker retries one pending upload until it is done or stopped
+type uploadWorker struct {
+	stop chan struct{}
+	kick chan struct{}
+}
+
+var (
+	uploadQueueMu sync.Mutex
+	uploadQueue   = map[string]*PendingUpload{}
+	uploadWorkers = map[string]*uploadWorker{}
+)
+
+func uploadQueuePath() string {
+	return filepath.Join(getAppDir(), "pending_uploads.json")
+}
+
+// saveUploadQueueLocked writes the queue to disk. Caller holds uploadQueueMu.
+func saveUploadQueueLocked() error {
+	list := make([]PendingUpload, 0, len(uploadQueue))
+	for _, p := range uploadQueue {
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].FailedAt.Before(list[j].FailedAt) })
+
+	path := uploadQueuePath()
+	if len(list) == 0 {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	data, _ := json.MarshalIndent(list, "", "  ")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// uploadBackoff is the wait after the given number of failed attempts
+func uploadBackoff(attempts int) time.Duration {
+	wait := uploadRetryMin
+	for i := 1; i < attempts && wait < uploadRetryMax; i++ {
+		wait *= 2
+	}
+	if wait > uploadRetryMax {
+		wait = uploadRetryMax
+	}
+	return wait
+}
+
+// queueUpload records a failed end-of-session upload and starts retrying it
+func (a *App) queueUpload(serverID string, lock ServerLock, localPath string, cause error) {
+	now := time.Now()
+	p := &PendingUpload{
+		ServerID:    serverID,
+		Username:    lock.HostedBy,
+		HostedAt:    lock.HostedAt,
+		LocalPath:   localPath,
+		FailedAt:    now,
+		Attempts:    1,
+		LastError:   cause.Error(),
+		NextAttempt: now.Add(uploadBackoff(1)),
+	}
+
+	ctx, cancel := dbContext()
+	if _, err := a.store.SetPendingUpload(ctx, serverID, lock.HostedBy, true); err != nil {
+		// The worker sets it again once the database is reachable
+		a.Log("⚠️ Could not mark the upload as pending: " + err.Error())
+	}
+	cancel()
+
+	uploadQueueMu.Lock()
+	uploadQueue[serverID] = p
+	if err := saveUploadQueueLocked(); err != nil {
+		a.Log("⚠️ Could not save the upload queue: " + err.Error())
+	}
+	uploadQueueMu.Unlock()
+
+	a.Log(fmt.Sprintf("📥 Upload queued. Retrying in %s; the server stays locked until it lands.", uploadBackoff(1)))
+	a.startUploadWorker(serverID)
+}
+
+// resumeUploads restarts the retries left over from the last run
+func (a *App) resumeUploads() {
+	if a.store == nil {
+		return // Offline; the queue stays on disk for the next run
+	}
+	data, err := os.ReadFile(uploadQueuePath())
+	if err != nil {
+		return
+	}
+	var list []PendingUpload
+	if err := json.Unmarshal(data, &list); err != nil {
+		a.Log("⚠️ Could not read the upload queue: " + err.Error())
+		return
+	}
+
+	uploadQueueMu.Lock()
+	for i := range list {
+		p := list[i]
+		uploadQueue[p.ServerID] = &p
+	}
+	uploadQueueMu.Unlock()
+
+	for _, p := range list {
+		a.Log(fmt.Sprintf("📥 Resuming pending upload for %s (failed %s).", p.ServerID, p.FailedAt.Format("Jan 2 15:04")))
+		a.startUploadWorker(p.ServerID)
+	}
+}
+
+// kickUploads retries every pending upload now (e.g. after logging in,
+// since uploads need the user's unlocked keys)
+func (a *App) kickUploads() {
+	uploadQueueMu.Lock()
+	defer uploadQueueMu.Unlock()
+	for _, w := range uploadWorkers {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (a *App) startUploadWorker(serverID string) {
+	uploadQueueMu.Lock()
+	if old, ok := uploadWorkers[serverID]; ok {
+		close(old.stop)
+	}
+	w := &uploadWorker{stop: make(chan struct{}), kick: make(chan struct{}, 1)}
+	uploadWorkers[serverID] = w
+	uploadQueueMu.Unlock()
+
+	go func() {
+		for {
+			uploadQueueMu.Lock()
+			p, ok := uploadQueue[serverID]
+			var wait time.Duration
+			if ok {
+				wait = time.Until(p.NextAttempt)
+			}
+			uploadQueueMu.Unlock()
+			if !ok {
+				return
+			}
+
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-w.stop:
+					timer.Stop()
+					return
+				case <-w.kick:
+					timer.Stop()
+				case <-timer.C:
+				}
+			}
+
+			select {
+			case <-w.stop:
+				return
+			default:
+			}
+			if a.retryUpload(serverID) {
+				a.finishUpload(serverID, w)
+				return
+			}
+		}
+	}()
+}
+
+// finishUpload drops a queue entry and its worker
+func (a *App) finishUpload(serverID string, w *uploadWorker) {
+	uploadQueueMu.Lock()
+	defer uploadQueueMu.Unlock()
+	delete(uploadQueue, serverID)
+	if cur, ok := uploadWorkers[serverID]; ok && (w == nil || cur == w) {
+		if w == nil {
+			close(cur.stop)
+		}
+		delete(uploadWorkers, serverID)
+	}
+	if err := saveUploadQueueLocked(); err != nil {
+		a.Log("⚠️ Could not save the upload queue: " + err.Error())
+	}
+}
+
+// retryUpload makes one attempt. It returns true when the entry is done
+// with: uploaded, in conflict, or abandoned.
+func (a *App) retryUpload(serverID string) bool {
+	uploadQueueMu.Lock()
+	entry, ok := uploadQueue[serverID]
+	var p PendingUpload
+	if ok {
+		p = *entry
+	}
+	uploadQueueMu.Unlock()
+	if !ok {
+		return true // Abandoned meanwhile
+	}
+
+	err := a.attemptUpload(p)
+	if err == nil {
+		return true
+	}
+	var conflict *SyncConflictError
+	if errors.As(err, &conflict) {
+		a.Log("❌ Pending upload for " + serverID + ": " + conflict.Error())
+		a.Log("💡 Your copy was NOT uploaded. You can save it as a snapshot instead.")
+		ctx, cancel := dbContext()
+		_ = a.store.SetSyncStatus(ctx, serverID, "conflict", p.Username, time.Now())
+		a.store.ReleaseLock(ctx, serverID, p.Username)
+		cancel()
+		return true
+	}
+
+	uploadQueueMu.Lock()
+	if cur, ok := uploadQueue[serverID]; ok {
+		cur.Attempts++
+		cur.LastError = err.Error()
+		cur.NextAttempt = time.Now().Add(uploadBackoff(cur.Attempts))
+		p = *cur
+		saveUploadQueueLocked()
+	}
+	uploadQueueMu.Unlock()
+	a.Log(fmt.Sprintf("⚠️ Upload for %s failed again (attempt %d): %v. Retrying in %s.",
+		serverID, p.Attempts, err, uploadBackoff(p.Attempts)))
+	return false
+}
+
+func (a *App) attemptUpload(p PendingUpload) error {
+	doc, err := a.getServer(p.ServerID)
+	if err != nil {
+		return fmt.Errorf("could not reach the database: %v", err)
+	}
+	lock := doc.Lock
+	if !lock.IsRunning || lock.HostedBy != p.Username || !lock.HostedAt.Equal(p.HostedAt) {
+		a.Log(fmt.Sprintf("🗑️ The pending upload for %s was abandoned. Your local copy stays on this PC.", p.ServerID))
+		return nil
+	}
+	if !lock.PendingUpload {
+		// Marking it failed when the session ended (database was down)
+		ctx, cancel := dbContext()
+		a.store.SetPendingUpload(ctx, p.ServerID, p.Username, true)
+		cancel()
+	}
+	if _, err := os.Stat(p.LocalPath); err != nil {
+		return fmt.Errorf("local copy is missing: %v", err)
+	}
+
+	a.Log(fmt.Sprintf("🚀 Retrying upload for %s (attempt %d)...", p.ServerID, p.Attempts+1))
+	remote, err := a.checkResumeSyncUp(p.ServerID, p.LocalPath)
+	if err != nil {
+		return err
+	}
+	if err := a.pushSync(p.ServerID, p.LocalPath, p.Username, remote); err != nil {
+		return err
+	}
+
+	a.Log("✅ Pending upload complete! The server is free again.")
+	if err := a.pruneSnapshots(p.ServerID); err != nil {
+		a.Log("⚠️ Snapshot cleanup failed: " + err.Error())
+	}
+	ctx, cancel := dbContext()
+	defer cancel()
+	_ = a.store.SetSyncStatus(ctx, p.ServerID, "ok", p.Username, time.Now())
+	a.store.ReleaseLock(ctx, p.ServerID, p.Username)
+	return nil
+}
+
+// GetPendingUploads lists the caller's sessions on this PC still waiting to upload
+func (a *App) GetPendingUploads(token string) []PendingUpload {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return []PendingUpload{}
+	}
+	list := []PendingUpload{}
+	for _, p := range a.pendingUploads() {
+		if a.isMember(p.ServerID, username) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// pendingUploads lists every session on this PC still waiting to upload
+func (a *App) pendingUploads() []PendingUpload {
+	uploadQueueMu.Lock()
+	defer uploadQueueMu.Unlock()
+	list := make([]PendingUpload, 0, len(uploadQueue))
+	for _, p := range uploadQueue {
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].FailedAt.Before(list[j].FailedAt) })
+	return list
+}
+
+// AbandonPendingUpload gives up on a session whose upload never landed
+// (owner only). The cloud keeps its last good copy and the group is
+// unlocked; the progress from that session is lost.
+func (a *App) AbandonPendingUpload(serverID string, token string) string {
+	username, err := a.authenticate(token)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	doc, err := a.getServer(serverID)
+	if err != nil {
+		return "Error: Server not found."
+	}
+	if doc.OwnerID != username {
+		return "Error: Only the server owner can abandon a pending upload."
+	}
+	if !doc.Lock.PendingUpload {
+		return "Error: This server has no pending upload."
+	}
+
+	ctx, cancel := dbContext()
+	defer cancel()
+	released, err := a.store.ReleaseLock(ctx, serverID, doc.Lock.HostedBy)
+	if err != nil {
+		return "Error: Database update failed"
+	}
+	if !released {
+		return "Error: The session already ended."
+	}
+	_ = a.store.SetSyncStatus(ctx, serverID, "abandoned", username, time.Now())
+
+	// If the session is queued on this PC, stop retrying it here
+	uploadQueueMu.Lock()
+	_, local := uploadQueue[serverID]
+	uploadQueueMu.Unlock()
+	if local {
+		a.finishUpload(serverID, nil)
+	}
+
+	a.Log(fmt.Sprintf("🗑️ Abandoned %s's pending upload. The cloud copy from before that session is kept.", doc.Lock.HostedBy))
+	return "Success"
+}
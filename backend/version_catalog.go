@@ -0,0 +1,247 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// versionCatalogTTL is how long a fetched catalog or resolved build is reused
+const versionCatalogTTL = 6 * time.Hour
+
+// VersionCatalog merges every provider into one list and caches it with a TTL.
+// If a provider is down, its entries from the last good fetch are kept.
+type VersionCatalog struct {
+	providers []VersionProvider
+	ttl       time.Duration
+
+	mu        sync.Mutex
+	versions  []ServerVersion
+	byType    map[string][]ServerVersion // Last good list per provider
+	fetchedAt time.Time
+	resolved  map[string]resolvedVersion // "type/version" -> latest build
+}
+
+type resolvedVersion struct {
+	version ServerVersion
+	at      time.Time
+}
+
+// NewVersionCatalog creates a catalog over the given providers
+func NewVersionCatalog(ttl time.Duration, providers ...VersionProvider) *VersionCatalog {
+	return &VersionCatalog{
+		providers: providers,
+		ttl:       ttl,
+		byType:    map[string][]ServerVersion{},
+		resolved:  map[string]resolvedVersion{},
+	}
+}
+
+// provider finds the provider for a server type (case-insensitive)
+func (c *VersionCatalog) provider(serverType string) (VersionProvider, error) {
+	for _, p := range c.providers {
+		if strings.EqualFold(p.Type(), serverType) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown server type %q", serverType)
+}
+
+// List returns the merged catalog, refreshing it if the cache has expired
+func (c *VersionCatalog) List(ctx context.Context) ([]ServerVersion, error) {
+	c.mu.Lock()
+	if c.versions != nil && time.Since(c.fetchedAt) < c.ttl {
+		list := append([]ServerVersion(nil), c.versions...)
+		c.mu.Unlock()
+		return list, nil
+	}
+	c.mu.Unlock()
+
+	type result struct {
+		serverType string
+		versions   []ServerVersion
+		err        error
+	}
+	results := make(chan result, len(c.providers))
+	for _, p := range c.providers {
+		go func(p VersionProvider) {
+			ids, err := p.Versions(ctx)
+			list := make([]ServerVersion, 0, len(ids))
+			for _, id := range ids {
+				list = append(list, ServerVersion{Type: p.Type(), Version: id})
+			}
+			results <- result{p.Type(), list, err}
+		}(p)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []string
+	for range c.providers {
+		r := <-results
+		if r.err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", r.serverType, r.err))
+			continue // Keep the stale list for this type
+		}
+		c.byType[r.serverType] = r.versions
+	}
+
+	var merged []ServerVersion
+	for _, p := range c.providers {
+		merged = append(merged, c.byType[p.Type()]...)
+	}
+	if len(merged) == 0 {
+		return nil, fmt.Errorf("no version provider reachable (%s)", strings.Join(errs, "; "))
+	}
+
+	c.versions = sortVersions(merged, c.providers)
+	// A partial failure is retried sooner than a full success
+	if len(errs) > 0 {
+		c.fetchedAt = time.Now().Add(-c.ttl + time.Minute)
+	} else {
+		c.fetchedAt = time.Now()
+	}
+	return append([]ServerVersion(nil), c.versions...), nil
+}
+
+// Resolve returns the latest build of a version with its URL and checksum
+func (c *VersionCatalog) Resolve(ctx context.Context, serverType string, version string) (ServerVersion, error) {
+	p, err := c.provider(serverType)
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	key := p.Type() + "/" + version
+
+	c.mu.Lock()
+	cached, ok := c.resolved[key]
+	c.mu.Unlock()
+	if ok && time.Since(cached.at) < c.ttl {
+		return cached.version, nil
+	}
+
+	v, err := p.Resolve(ctx, version)
+	if err != nil {
+		return ServerVersion{}, err
+	}
+
+	c.mu.Lock()
+	c.resolved[key] = resolvedVersion{version: v, at: time.Now()}
+	c.mu.Unlock()
+	return v, nil
+}
+
+// ResolveBuild finds a given build of a version, or the latest if build is
+// empty. Pinned builds aren't cached: they never change.
+func (c *VersionCatalog) ResolveBuild(ctx context.Context, serverType string, version string, build string) (ServerVersion, error) {
+	if build == "" {
+		return c.Resolve(ctx, serverType, version)
+	}
+	p, err := c.provider(serverType)
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	pinned, ok := p.(BuildResolver)
+	if !ok {
+		return ServerVersion{}, fmt.Errorf("%s builds can't be pinned", p.Type())
+	}
+	return pinned.ResolveBuild(ctx, version, build)
+}
+
+// sortVersions removes duplicates and orders the list by provider, then
+// newest version first
+func sortVersions(list []ServerVersion, providers []VersionProvider) []ServerVersion {
+	rank := map[string]int{}
+	for i, p := range providers {
+		rank[p.Type()] = i
+	}
+	typeRank := func(t string) int {
+		if r, ok := rank[t]; ok {
+			return r
+		}
+		return len(providers)
+	}
+
+	seen := map[string]bool{}
+	out := make([]ServerVersion, 0, len(list))
+	for _, v := range list {
+		key := v.Type + "/" + v.Version
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, v)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		ri, rj := typeRank(out[i].Type), typeRank(out[j].Type)
+		if ri != rj {
+			return ri < rj
+		}
+		if out[i].Type != out[j].Type {
+			return out[i].Type < out[j].Type
+		}
+		return compareVersions(out[i].Version, out[j].Version) > 0
+	})
+	return out
+}
+
+// compareVersions compares Minecraft versions like "1.20.4", "1.21" or
+// "1.20.5-pre1". Missing parts count as 0 and pre-releases sort before
+// the release. It returns -1, 0 or 1.
+func compareVersions(a string, b string) int {
+	coreA, preA, _ := strings.Cut(a, "-")
+	coreB, preB, _ := strings.Cut(b, "-")
+
+	if c := compareDotted(coreA, coreB); c != 0 {
+		return c
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return compareDotted(preA, preB)
+}
+
+// compareDotted compares dot-separated parts, numerically where both are numbers
+func compareDotted(a string, b string) int {
+	partsA := strings.Split(a, ".")
+	partsB := strings.Split(b, ".")
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		pa, pb := "0", "0"
+		if i < len(partsA) {
+			pa = partsA[i]
+		}
+		if i < len(partsB) {
+			pb = partsB[i]
+		}
+		na, errA := strconv.Atoi(pa)
+		nb, errB := strconv.Atoi(pb)
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil: // Numbers sort after words ("1.0" > "1.beta")
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(pa, pb); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,553 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionProvider knows the versions of one server type and how to get
+// the latest build of each. Base URLs are fields so they can be pointed
+// at mirrors or local fixtures.
+type VersionProvider interface {
+	// Type is the name shown in the UI, e.g. "Paper"
+	Type() string
+	// Versions lists the Minecraft versions this type supports
+	Versions(ctx context.Context) ([]string, error)
+	// Resolve finds the latest build of a version, with its download URL and checksum
+	Resolve(ctx context.Context, version string) (ServerVersion, error)
+}
+
+// BuildResolver is implemented by providers whose builds can be pinned, so
+// a modpack gets the exact loader it was made with
+type BuildResolver interface {
+	// ResolveBuild finds a given build of a version
+	ResolveBuild(ctx context.Context, version string, build string) (ServerVersion, error)
+}
+
+// DefaultVersionProviders returns the providers for every supported server type
+func DefaultVersionProviders() []VersionProvider {
+	return []VersionProvider{
+		&PaperProvider{},
+		&VanillaProvider{},
+		&PurpurProvider{},
+		&FabricProvider{},
+		&ForgeProvider{},
+		&NeoForgeProvider{},
+	}
+}
+
+var providerClient = &http.Client{Timeout: 30 * time.Second}
+
+// getJSON fetches a URL and decodes the JSON body into v
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	body, err := getBody(ctx, client, url)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("bad response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// getBody fetches a URL and returns the whole body
+func getBody(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	if client == nil {
+		client = providerClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "mc-roam")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// checksumOf formats a checksum as "algo:hex", or "" if the provider gave none
+func checksumOf(algo string, hex string) string {
+	if hex == "" {
+		return ""
+	}
+	return algo + ":" + strings.ToLower(hex)
+}
+
+// --- Vanilla (Mojang piston-meta) ---
+
+// VanillaProvider reads Mojang's version manifest
+type VanillaProvider struct {
+	ManifestURL string // Default: piston-meta version_manifest_v2.json
+	Client      *http.Client
+}
+
+type vanillaManifest struct {
+	Versions []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"` // "release", "snapshot", "old_beta"...
+		URL  string `json:"url"`
+	} `json:"versions"`
+}
+
+func (p *VanillaProvider) Type() string { return "Vanilla" }
+
+func (p *VanillaProvider) manifest(ctx context.Context) (vanillaManifest, error) {
+	url := p.ManifestURL
+	if url == "" {
+		url = "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"
+	}
+	var m vanillaManifest
+	err := getJSON(ctx, p.Client, url, &m)
+	return m, err
+}
+
+func (p *VanillaProvider) Versions(ctx context.Context) ([]string, error) {
+	m, err := p.manifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, v := range m.Versions {
+		if v.Type == "release" {
+			versions = append(versions, v.ID)
+		}
+	}
+	return versions, nil
+}
+
+func (p *VanillaProvider) Resolve(ctx context.Context, version string) (ServerVersion, error) {
+	m, err := p.manifest(ctx)
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	for _, v := range m.Versions {
+		if v.ID != version {
+			continue
+		}
+		var meta struct {
+			Downloads struct {
+				Server struct {
+					URL  string `json:"url"`
+					SHA1 string `json:"sha1"`
+				} `json:"server"`
+			} `json:"downloads"`
+		}
+		if err := getJSON(ctx, p.Client, v.URL, &meta); err != nil {
+			return ServerVersion{}, err
+		}
+		if meta.Downloads.Server.URL == "" {
+			return ServerVersion{}, fmt.Errorf("vanilla %s has no server download", version)
+		}
+		return ServerVersion{
+			Type:     p.Type(),
+			Version:  version,
+			Url:      meta.Downloads.Server.URL,
+			Build:    version,
+			Checksum: checksumOf("sha1", meta.Downloads.Server.SHA1),
+		}, nil
+	}
+	return ServerVersion{}, fmt.Errorf("vanilla %s not found", version)
+}
+
+// --- Paper (PaperMC builds API) ---
+
+// PaperProvider reads the PaperMC v2 API. Project defaults to "paper".
+type PaperProvider struct {
+	BaseURL string // Default: https://api.papermc.io/v2
+	Project string
+	Client  *http.Client
+}
+
+func (p *PaperProvider) Type() string { return "Paper" }
+
+func (p *PaperProvider) base() string {
+	base := p.BaseURL
+	if base == "" {
+		base = "https://api.papermc.io/v2"
+	}
+	project := p.Project
+	if project == "" {
+		project = "paper"
+	}
+	return strings.TrimRight(base, "/") + "/projects/" + project
+}
+
+func (p *PaperProvider) Versions(ctx context.Context) ([]string, error) {
+	var project struct {
+		Versions []string `json:"versions"`
+	}
+	if err := getJSON(ctx, p.Client, p.base(), &project); err != nil {
+		return nil, err
+	}
+	return project.Versions, nil
+}
+
+func (p *PaperProvider) Resolve(ctx context.Context, version string) (ServerVersion, error) {
+	var list struct {
+		Builds []struct {
+			Build     int    `json:"build"`
+			Channel   string `json:"channel"` // "default" or "experimental"
+			Downloads struct {
+				Application struct {
+					Name   string `json:"name"`
+					SHA256 string `json:"sha256"`
+				} `json:"application"`
+			} `json:"downloads"`
+		} `json:"builds"`
+	}
+	versionURL := p.base() + "/versions/" + version
+	if err := getJSON(ctx, p.Client, versionURL+"/builds", &list); err != nil {
+		return ServerVersion{}, err
+	}
+	if len(list.Builds) == 0 {
+		return ServerVersion{}, fmt.Errorf("paper %s has no builds", version)
+	}
+
+	// Builds are oldest first. Prefer the newest stable one.
+	pick := list.Builds[len(list.Builds)-1]
+	for i := len(list.Builds) - 1; i >= 0; i-- {
+		if list.Builds[i].Channel == "default" {
+			pick = list.Builds[i]
+			break
+		}
+	}
+	app := pick.Downloads.Application
+	return ServerVersion{
+		Type:     p.Type(),
+		Version:  version,
+		Url:      fmt.Sprintf("%s/builds/%d/downloads/%s", versionURL, pick.Build, app.Name),
+		Build:    strconv.Itoa(pick.Build),
+		Checksum: checksumOf("sha256", app.SHA256),
+	}, nil
+}
+
+// --- Purpur ---
+
+// PurpurProvider reads the Purpur API. Purpur only publishes MD5 checksums.
+type PurpurProvider struct {
+	BaseURL string // Default: https://api.purpurmc.org/v2/purpur
+	Client  *http.Client
+}
+
+func (p *PurpurProvider) Type() string { return "Purpur" }
+
+func (p *PurpurProvider) base() string {
+	if p.BaseURL == "" {
+		return "https://api.purpurmc.org/v2/purpur"
+	}
+	return strings.TrimRight(p.BaseURL, "/")
+}
+
+func (p *PurpurProvider) Versions(ctx context.Context) ([]string, error) {
+	var project struct {
+		Versions []string `json:"versions"`
+	}
+	if err := getJSON(ctx, p.Client, p.base(), &project); err != nil {
+		return nil, err
+	}
+	return project.Versions, nil
+}
+
+func (p *PurpurProvider) Resolve(ctx context.Context, version string) (ServerVersion, error) {
+	var v struct {
+		Builds struct {
+			Latest string `json:"latest"`
+		} `json:"builds"`
+	}
+	if err := getJSON(ctx, p.Client, p.base()+"/"+version, &v); err != nil {
+		return ServerVersion{}, err
+	}
+	if v.Builds.Latest == "" {
+		return ServerVersion{}, fmt.Errorf("purpur %s has no builds", version)
+	}
+
+	buildURL := p.base() + "/" + version + "/" + v.Builds.Latest
+	var build struct {
+		MD5 string `json:"md5"`
+	}
+	if err := getJSON(ctx, p.Client, buildURL, &build); err != nil {
+		return ServerVersion{}, err
+	}
+	return ServerVersion{
+		Type:     p.Type(),
+		Version:  version,
+		Url:      buildURL + "/download",
+		Build:    v.Builds.Latest,
+		Checksum: checksumOf("md5", build.MD5),
+	}, nil
+}
+
+// --- Fabric ---
+
+// FabricProvider reads Fabric meta. The server launcher jar it serves is
+// runnable directly. Fabric doesn't publish checksums for it.
+type FabricProvider struct {
+	BaseURL string // Default: https://meta.fabricmc.net/v2
+	Client  *http.Client
+}
+
+type fabricEntry struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+func (p *FabricProvider) Type() string { return "Fabric" }
+
+func (p *FabricProvider) base() string {
+	if p.BaseURL == "" {
+		return "https://meta.fabricmc.net/v2"
+	}
+	return strings.TrimRight(p.BaseURL, "/")
+}
+
+// latestStable returns the first stable entry (Fabric meta lists newest first)
+func (p *FabricProvider) latestStable(ctx context.Context, path string) (string, error) {
+	var entries []fabricEntry
+	if err := getJSON(ctx, p.Client, p.base()+path, &entries); err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.Stable {
+			return e.Version, nil
+		}
+	}
+	if len(entries) > 0 {
+		return entries[0].Version, nil
+	}
+	return "", fmt.Errorf("no fabric releases at %s", path)
+}
+
+func (p *FabricProvider) Versions(ctx context.Context) ([]string, error) {
+	var games []fabricEntry
+	if err := getJSON(ctx, p.Client, p.base()+"/versions/game", &games); err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, g := range games {
+		if g.Stable {
+			versions = append(versions, g.Version)
+		}
+	}
+	return versions, nil
+}
+
+func (p *FabricProvider) Resolve(ctx context.Context, version string) (ServerVersion, error) {
+	loader, err := p.latestStable(ctx, "/versions/loader")
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	return p.ResolveBuild(ctx, version, loader)
+}
+
+// ResolveBuild pins the loader version; the installer doesn't change what runs
+func (p *FabricProvider) ResolveBuild(ctx context.Context, version string, loader string) (ServerVersion, error) {
+	installer, err := p.latestStable(ctx, "/versions/installer")
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	return ServerVersion{
+		Type:    p.Type(),
+		Version: version,
+		Url:     fmt.Sprintf("%s/versions/loader/%s/%s/%s/server/jar", p.base(), version, loader, installer),
+		Build:   loader,
+	}, nil
+}
+
+// --- Forge ---
+
+// ForgeProvider reads Forge's promotions and Maven. Forge ships an
+// installer, not a server jar, so resolved versions have Installer set.
+type ForgeProvider struct {
+	PromotionsURL string // Default: files.minecraftforge.net promotions_slim.json
+	MavenURL      string // Default: https://maven.minecraftforge.net/net/minecraftforge/forge
+	Client        *http.Client
+}
+
+func (p *ForgeProvider) Type() string { return "Forge" }
+
+func (p *ForgeProvider) promotions(ctx context.Context) (map[string]string, error) {
+	url := p.PromotionsURL
+	if url == "" {
+		url = "https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json"
+	}
+	var promos struct {
+		Promos map[string]string `json:"promos"` // "1.20.4-recommended": "49.0.31"
+	}
+	if err := getJSON(ctx, p.Client, url, &promos); err != nil {
+		return nil, err
+	}
+	return promos.Promos, nil
+}
+
+func (p *ForgeProvider) Versions(ctx context.Context) ([]string, error) {
+	promos, err := p.promotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var versions []string
+	for key := range promos {
+		mc := strings.TrimSuffix(strings.TrimSuffix(key, "-latest"), "-recommended")
+		if mc == key || seen[mc] {
+			continue
+		}
+		seen[mc] = true
+		versions = append(versions, mc)
+	}
+	sort.Strings(versions) // Map order is random; the catalog re-sorts properly
+	return versions, nil
+}
+
+func (p *ForgeProvider) Resolve(ctx context.Context, version string) (ServerVersion, error) {
+	promos, err := p.promotions(ctx)
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	build := promos[version+"-recommended"]
+	if build == "" {
+		build = promos[version+"-latest"]
+	}
+	if build == "" {
+		return ServerVersion{}, fmt.Errorf("forge %s not found", version)
+	}
+	return p.ResolveBuild(ctx, version, build)
+}
+
+func (p *ForgeProvider) ResolveBuild(ctx context.Context, version string, build string) (ServerVersion, error) {
+	maven := p.MavenURL
+	if maven == "" {
+		maven = "https://maven.minecraftforge.net/net/minecraftforge/forge"
+	}
+	coord := version + "-" + build
+	url := fmt.Sprintf("%s/%s/forge-%s-installer.jar", strings.TrimRight(maven, "/"), coord, coord)
+
+	checksum := ""
+	if sum, err := getBody(ctx, p.Client, url+".sha1"); err == nil {
+		checksum = checksumOf("sha1", strings.TrimSpace(string(sum)))
+	}
+	return ServerVersion{
+		Type:      p.Type(),
+		Version:   version,
+		Url:       url,
+		Build:     build,
+		Checksum:  checksum,
+		Installer: true,
+	}, nil
+}
+
+// --- NeoForge ---
+
+// NeoForgeProvider reads NeoForge's Maven. Its versions encode the Minecraft
+// version (21.1.77 is for 1.21.1, 20.4.237 for 1.20.4). Like Forge it ships
+// an installer.
+type NeoForgeProvider struct {
+	MavenURL string // Default: https://maven.neoforged.net
+	Client   *http.Client
+}
+
+func (p *NeoForgeProvider) Type() string { return "NeoForge" }
+
+func (p *NeoForgeProvider) base() string {
+	if p.MavenURL == "" {
+		return "https://maven.neoforged.net"
+	}
+	return strings.TrimRight(p.MavenURL, "/")
+}
+
+// builds lists every NeoForge version
+func (p *NeoForgeProvider) builds(ctx context.Context) ([]string, error) {
+	var meta struct {
+		Versions []string `json:"versions"`
+	}
+	if err := getJSON(ctx, p.Client, p.base()+"/api/maven/versions/releases/net/neoforged/neoforge", &meta); err != nil {
+		return nil, err
+	}
+	return meta.Versions, nil
+}
+
+// neoForgeMinecraft maps a NeoForge version to its Minecraft version
+func neoForgeMinecraft(build string) (string, bool) {
+	parts := strings.SplitN(build, ".", 3)
+	if len(parts) < 3 {
+		return "", false
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return "", false
+	}
+	if minor == 0 {
+		return fmt.Sprintf("1.%d", major), true
+	}
+	return fmt.Sprintf("1.%d.%d", major, minor), true
+}
+
+func (p *NeoForgeProvider) Versions(ctx context.Context) ([]string, error) {
+	builds, err := p.builds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var versions []string
+	for _, b := range builds {
+		if mc, ok := neoForgeMinecraft(b); ok && !seen[mc] {
+			seen[mc] = true
+			versions = append(versions, mc)
+		}
+	}
+	return versions, nil
+}
+
+func (p *NeoForgeProvider) Resolve(ctx context.Context, version string) (ServerVersion, error) {
+	builds, err := p.builds(ctx)
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	// Newest stable build, or the newest beta if there is no stable one yet
+	build := ""
+	for _, b := range builds {
+		if mc, ok := neoForgeMinecraft(b); !ok || mc != version {
+			continue
+		}
+		stable, buildStable := !strings.Contains(b, "-"), !strings.Contains(build, "-")
+		if build == "" || (stable && !buildStable) || (stable == buildStable && compareVersions(b, build) > 0) {
+			build = b
+		}
+	}
+	if build == "" {
+		return ServerVersion{}, fmt.Errorf("neoforge %s not found", version)
+	}
+	return p.ResolveBuild(ctx, version, build)
+}
+
+func (p *NeoForgeProvider) ResolveBuild(ctx context.Context, version string, build string) (ServerVersion, error) {
+	if mc, ok := neoForgeMinecraft(build); !ok || mc != version {
+		return ServerVersion{}, fmt.Errorf("neoforge %s is not for Minecraft %s", build, version)
+	}
+	url := fmt.Sprintf("%s/releases/net/neoforged/neoforge/%s/neoforge-%s-installer.jar", p.base(), build, build)
+	checksum := ""
+	if sum, err := getBody(ctx, p.Client, url+".sha1"); err == nil {
+		checksum = checksumOf("sha1", strings.TrimSpace(string(sum)))
+	}
+	return ServerVersion{
+		Type:      p.Type(),
+		Version:   version,
+		Url:       url,
+		Build:     build,
+		Checksum:  checksum,
+		Installer: true,
+	}, nil
+}
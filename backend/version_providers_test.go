@@ -0,0 +1,269 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// upstreamFixture stands in for every version API. Paths are relative to
+// the test server; "{base}" in a body becomes its URL.
+func upstreamFixture(t *testing.T, routes map[string]string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(strings.ReplaceAll(body, "{base}", srv.URL)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+var upstreamRoutes = map[string]string{
+	// Mojang piston-meta
+	"/mojang/version_manifest_v2.json": `{"versions": [
+		{"id": "24w14a", "type": "snapshot", "url": "{base}/mojang/24w14a.json"},
+		{"id": "1.20.4", "type": "release", "url": "{base}/mojang/1.20.4.json"},
+		{"id": "1.20.2", "type": "release", "url": "{base}/mojang/1.20.2.json"},
+		{"id": "b1.7.3", "type": "old_beta", "url": "{base}/mojang/b1.7.3.json"}]}`,
+	"/mojang/1.20.4.json": `{"downloads": {"server": {"url": "{base}/jars/vanilla-1.20.4.jar", "sha1": "ABCDEF0123"}}}`,
+	"/mojang/1.20.2.json": `{"downloads": {}}`,
+
+	// PaperMC v2
+	"/paper/projects/paper": `{"versions": ["1.20.2", "1.20.4"]}`,
+	"/paper/projects/paper/versions/1.20.4/builds": `{"builds": [
+		{"build": 400, "channel": "default", "downloads": {"application": {"name": "paper-1.20.4-400.jar", "sha256": "aa11"}}},
+		{"build": 401, "channel": "default", "downloads": {"application": {"name": "paper-1.20.4-401.jar", "sha256": "bb22"}}},
+		{"build": 402, "channel": "experimental", "downloads": {"application": {"name": "paper-1.20.4-402.jar", "sha256": "cc33"}}}]}`,
+	"/paper/projects/paper/versions/1.21/builds": `{"builds": [
+		{"build": 3, "channel": "experimental", "downloads": {"application": {"name": "paper-1.21-3.jar", "sha256": "dd44"}}}]}`,
+
+	// Purpur
+	"/purpur":             `{"versions": ["1.20.4"]}`,
+	"/purpur/1.20.4":      `{"builds": {"latest": "2176"}}`,
+	"/purpur/1.20.4/2176": `{"md5": "0f0f"}`,
+
+	// Fabric meta
+	"/fabric/versions/game":      `[{"version": "1.20.5-rc1", "stable": false}, {"version": "1.20.4", "stable": true}]`,
+	"/fabric/versions/loader":    `[{"version": "0.15.10", "stable": false}, {"version": "0.15.9", "stable": true}]`,
+	"/fabric/versions/installer": `[{"version": "1.0.1", "stable": true}]`,
+
+	// Forge
+	"/forge/promotions_slim.json":                                         `{"promos": {"1.20.4-latest": "49.0.40", "1.20.4-recommended": "49.0.31", "1.19.2-latest": "43.3.9"}}`,
+	"/forge/maven/1.20.4-49.0.31/forge-1.20.4-49.0.31-installer.jar.sha1": "1111aaaa\n",
+
+	// NeoForge
+	"/neo/api/maven/versions/releases/net/neoforged/neoforge":                            `{"versions": ["20.4.200", "20.4.237", "21.0.1-beta", "21.1.5-beta", "21.1.77"]}`,
+	"/neo/releases/net/neoforged/neoforge/20.4.237/neoforge-20.4.237-installer.jar.sha1": "2222bbbb",
+}
+
+// fixtureProviders points every provider at the fixture
+func fixtureProviders(base string) []VersionProvider {
+	return []VersionProvider{
+		&VanillaProvider{ManifestURL: base + "/mojang/version_manifest_v2.json"},
+		&PaperProvider{BaseURL: base + "/paper"},
+		&PurpurProvider{BaseURL: base + "/purpur"},
+		&FabricProvider{BaseURL: base + "/fabric"},
+		&ForgeProvider{PromotionsURL: base + "/forge/promotions_slim.json", MavenURL: base + "/forge/maven"},
+		&NeoForgeProvider{MavenURL: base + "/neo"},
+	}
+}
+
+func TestProvidersResolve(t *testing.T) {
+	srv := upstreamFixture(t, upstreamRoutes)
+	providers := map[string]VersionProvider{}
+	for _, p := range fixtureProviders(srv.URL) {
+		providers[p.Type()] = p
+	}
+
+	tests := []struct {
+		name      string
+		provider  string
+		version   string
+		wantURL   string // Relative to the fixture
+		wantBuild string
+		wantSum   string
+		installer bool
+		wantErr   string
+	}{
+		{name: "vanilla release", provider: "Vanilla", version: "1.20.4", wantURL: "/jars/vanilla-1.20.4.jar", wantBuild: "1.20.4", wantSum: "sha1:abcdef0123"},
+		{name: "vanilla without server jar", provider: "Vanilla", version: "1.20.2", wantErr: "no server download"},
+		{name: "vanilla unknown", provider: "Vanilla", version: "9.9", wantErr: "not found"},
+		{name: "paper skips experimental", provider: "Paper", version: "1.20.4", wantURL: "/paper/projects/paper/versions/1.20.4/builds/401/downloads/paper-1.20.4-401.jar", wantBuild: "401", wantSum: "sha256:bb22"},
+		{name: "paper only experimental", provider: "Paper", version: "1.21", wantURL: "/paper/projects/paper/versions/1.21/builds/3/downloads/paper-1.21-3.jar", wantBuild: "3", wantSum: "sha256:dd44"},
+		{name: "paper unknown", provider: "Paper", version: "1.8", wantErr: "404"},
+		{name: "purpur", provider: "Purpur", version: "1.20.4", wantURL: "/purpur/1.20.4/2176/download", wantBuild: "2176", wantSum: "md5:0f0f"},
+		{name: "fabric stable loader", provider: "Fabric", version: "1.20.4", wantURL: "/fabric/versions/loader/1.20.4/0.15.9/1.0.1/server/jar", wantBuild: "0.15.9"},
+		{name: "forge recommended", provider: "Forge", version: "1.20.4", wantURL: "/forge/maven/1.20.4-49.0.31/forge-1.20.4-49.0.31-installer.jar", wantBuild: "49.0.31", wantSum: "sha1:1111aaaa", installer: true},
+		{name: "forge latest without checksum", provider: "Forge", version: "1.19.2", wantURL: "/forge/maven/1.19.2-43.3.9/forge-1.19.2-43.3.9-installer.jar", wantBuild: "43.3.9", installer: true},
+		{name: "neoforge newest stable", provider: "NeoForge", version: "1.20.4", wantURL: "/neo/releases/net/neoforged/neoforge/20.4.237/neoforge-20.4.237-installer.jar", wantBuild: "20.4.237", wantSum: "sha1:2222bbbb", installer: true},
+		{name: "neoforge stable beats beta", provider: "NeoForge", version: "1.21.1", wantURL: "/neo/releases/net/neoforged/neoforge/21.1.77/neoforge-21.1.77-installer.jar", wantBuild: "21.1.77", installer: true},
+		{name: "neoforge beta only", provider: "NeoForge", version: "1.21", wantURL: "/neo/releases/net/neoforged/neoforge/21.0.1-beta/neoforge-21.0.1-beta-installer.jar", wantBuild: "21.0.1-beta", installer: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := providers[tt.provider].Resolve(context.Background(), tt.version)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			want := ServerVersion{Type: tt.provider, Version: tt.version, Url: srv.URL + tt.wantURL, Build: tt.wantBuild, Checksum: tt.wantSum, Installer: tt.installer}
+			if got != want {
+				t.Errorf("Resolve = %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestPinnedBuilds(t *testing.T) {
+	srv := upstreamFixture(t, upstreamRoutes)
+	catalog := NewVersionCatalog(time.Hour, fixtureProviders(srv.URL)...)
+	ctx := context.Background()
+
+	v, err := catalog.ResolveBuild(ctx, "fabric", "1.20.4", "0.14.0")
+	if err != nil || v.Build != "0.14.0" || !strings.HasSuffix(v.Url, "/loader/1.20.4/0.14.0/1.0.1/server/jar") {
+		t.Errorf("fabric pinned = %+v, %v", v, err)
+	}
+	if _, err := catalog.ResolveBuild(ctx, "NeoForge", "1.20.4", "21.1.77"); err == nil {
+		t.Error("NeoForge 21.1.77 was accepted for 1.20.4")
+	}
+	if _, err := catalog.ResolveBuild(ctx, "Paper", "1.20.4", "400"); err == nil {
+		t.Error("Paper builds can't be pinned, but ResolveBuild accepted one")
+	}
+	v, err = catalog.ResolveBuild(ctx, "Paper", "1.20.4", "")
+	if err != nil || v.Build != "401" {
+		t.Errorf("empty build should resolve the latest, got %+v, %v", v, err)
+	}
+}
+
+func TestCatalogMergesAndSorts(t *testing.T) {
+	srv := upstreamFixture(t, upstreamRoutes)
+	catalog := NewVersionCatalog(time.Hour, fixtureProviders(srv.URL)...)
+
+	list, err := catalog.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range list {
+		got = append(got, v.Type+" "+v.Version)
+	}
+	want := []string{
+		"Vanilla 1.20.4", "Vanilla 1.20.2",
+		"Paper 1.20.4", "Paper 1.20.2",
+		"Purpur 1.20.4",
+		"Fabric 1.20.4",
+		"Forge 1.20.4", "Forge 1.19.2",
+		"NeoForge 1.21.1", "NeoForge 1.21", "NeoForge 1.20.4",
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("List =\n  %s\nwant\n  %s", strings.Join(got, ", "), strings.Join(want, ", "))
+	}
+}
+
+func TestCatalogCachesAndKeepsStaleLists(t *testing.T) {
+	var hits, failing atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() == 1 {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"versions": ["1.20.4"]}`))
+	}))
+	defer srv.Close()
+
+	catalog := NewVersionCatalog(time.Hour, &PurpurProvider{BaseURL: srv.URL})
+	ctx := context.Background()
+	if _, err := catalog.List(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := catalog.List(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("upstream hit %d times within the TTL, want 1", n)
+	}
+
+	// Expire the cache and take the upstream down: the last good list stays
+	catalog.fetchedAt = time.Now().Add(-2 * time.Hour)
+	failing.Store(1)
+	list, err := catalog.List(ctx)
+	if err != nil || len(list) != 1 || list[0].Version != "1.20.4" {
+		t.Errorf("List with upstream down = %v, %v; want the stale list", list, err)
+	}
+
+	empty := NewVersionCatalog(time.Hour, &PurpurProvider{BaseURL: srv.URL})
+	if _, err := empty.List(ctx); err == nil {
+		t.Error("List with nothing cached and upstream down should fail")
+	}
+}
+
+func TestSortVersionsDeduplicates(t *testing.T) {
+	providers := []VersionProvider{&VanillaProvider{}, &PaperProvider{}}
+	list := sortVersions([]ServerVersion{
+		{Type: "Paper", Version: "1.20.4"},
+		{Type: "Vanilla", Version: "1.9"},
+		{Type: "Vanilla", Version: "1.20.4"},
+		{Type: "Paper", Version: "1.20.4"},
+		{Type: "Vanilla", Version: "1.10"},
+	}, providers)
+	var got []string
+	for _, v := range list {
+		got = append(got, v.Type+" "+v.Version)
+	}
+	want := "Vanilla 1.20.4, Vanilla 1.10, Vanilla 1.9, Paper 1.20.4"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("sortVersions = %s, want %s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.20.4", "1.20.4", 0},
+		{"1.20", "1.20.0", 0},
+		{"1.10", "1.9", 1},
+		{"1.20.4", "1.20.10", -1},
+		{"1.20.5-pre1", "1.20.5", -1},
+		{"1.20.5-pre2", "1.20.5-pre1", 1},
+		{"1.21", "1.20.6", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestUpsertVersionsKeepsCatalog(t *testing.T) {
+	store, _ := NewMemoryStore("")
+	ctx := context.Background()
+	store.UpsertVersions(ctx, []ServerVersion{{Type: "Paper", Version: "1.20.4", Url: "old"}, {Type: "Vanilla", Version: "1.20.4"}})
+	store.UpsertVersions(ctx, []ServerVersion{{Type: "Paper", Version: "1.20.4", Url: "new"}})
+
+	list, _ := store.ListVersions(ctx)
+	if len(list) != 2 {
+		t.Fatalf("got %d versions, want 2 (upsert must not drop or duplicate)", len(list))
+	}
+	for _, v := range list {
+		if v.Type == "Paper" && v.Url != "new" {
+			t.Errorf("Paper 1.20.4 url = %q, want the updated one", v.Url)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"mc-roam/backend"
+)
+
+func (c *cli) listServers() error {
+	s, err := c.session()
+	if err != nil {
+		return err
+	}
+	servers := c.app.GetMyServers(s.Token)
+	if c.json {
+		c.printJSON(servers)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tOWNER\tSTATUS")
+	for _, server := range servers {
+		fmt.Fprintf(w, "%s\t%s\t%s %s\t%s\t%s\n", server.ID, server.Name, server.Type, server.Version, server.OwnerID, lockStatus(server.Lock))
+	}
+	return w.Flush()
+}
+
+func lockStatus(lock backend.ServerLock) string {
+	switch {
+	case lock.PendingUpload:
+		return "upload pending (" + lock.HostedBy + ")"
+	case lock.IsRunning:
+		return "running (" + lock.HostedBy + ")"
+	}
+	return "stopped"
+}
+
+func (c *cli) sync(direction backend.SyncDirection, serverID string) error {
+	s, err := c.unlocked()
+	if err != nil {
+		return err
+	}
+	return c.result(c.app.SyncNow(serverID, s.Token, direction))
+}
+
+func (c *cli) listBackups(serverID string) error {
+	s, err := c.unlocked()
+	if err != nil {
+		return err
+	}
+	snapshots := c.app.ListSnapshots(serverID, s.Token)
+	if c.json {
+		c.printJSON(snapshots)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCREATED\tFILES\tSIZE")
+	for _, snap := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.1f MB\n", snap.Name, snap.CreatedAt.Local().Format("2006-01-02 15:04"), snap.Files, float64(snap.Size)/(1024*1024))
+	}
+	return w.Flush()
+}
+
+func (c *cli) restoreBackup(serverID string, name string) error {
+	s, err := c.unlocked()
+	if err != nil {
+		return err
+	}
+	return c.result(c.app.RestoreSnapshot(serverID, name, s.Token))
+}
+
+func (c *cli) listVersions() error {
+	versions := c.app.GetVersions()
+	if c.json {
+		c.printJSON(versions)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tVERSION")
+	for _, v := range versions {
+		fmt.Fprintf(w, "%s\t%s\n", v.Type, v.Version)
+	}
+	return w.Flush()
+}
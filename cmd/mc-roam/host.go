@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"mc-roam/backend"
+)
+
+// A hosting process (start or daemon) leaves hosts/<id>.pid while it runs a
+// server. "mc-roam stop <id>" asks it to stop by creating hosts/<id>.stop,
+// which works the same on every OS and lets the host upload before exiting.
+const (
+	stopPollInterval = 2 * time.Second
+	stopWaitTimeout  = 15 * time.Minute // Stop and upload by the host process
+)
+
+// host runs servers from this process until they stop or we're told to quit
+type host struct {
+	c     *cli
+	token string
+	dir   string
+
+	mu      sync.Mutex
+	servers map[string]bool
+	ended   chan string // Servers whose game quit or crashed by itself
+}
+
+func (c *cli) newHost(token string) (*host, error) {
+	base, err := stateDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(base, "hosts")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	h := &host{c: c, token: token, dir: dir, servers: map[string]bool{}, ended: make(chan string, 8)}
+
+	c.events.Subscribe(backend.TopicLifecycle, func(e backend.Event) {
+		state, ok := e.Payload.(backend.ServerState)
+		if !ok || (state.State != backend.StateExited && state.State != backend.StateCrashed) {
+			return
+		}
+		if h.hosting(e.ServerID) {
+			go func() { h.ended <- e.ServerID }() // Publish must not block
+		}
+	})
+	return h, nil
+}
+
+func (h *host) hosting(serverID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.servers[serverID]
+}
+
+func (h *host) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.servers)
+}
+
+func (h *host) start(serverID string) error {
+	res := h.c.app.StartServer(serverID, h.token)
+	if !strings.HasPrefix(res, "Success") {
+		return fmt.Errorf("%s", strings.TrimPrefix(res, "Error: "))
+	}
+
+	h.mu.Lock()
+	h.servers[serverID] = true
+	h.mu.Unlock()
+	os.Remove(filepath.Join(h.dir, serverID+".stop"))
+	backend.WriteHostPIDFile(filepath.Join(h.dir, serverID+".pid"))
+	return h.c.result(fmt.Sprintf("Success: %s is starting on port %s", serverID, strings.TrimPrefix(res, "Success:")))
+}
+
+// stop shuts a server down and uploads it
+func (h *host) stop(serverID string) {
+	res := h.c.app.StopServer(serverID, h.token)
+
+	h.mu.Lock()
+	delete(h.servers, serverID)
+	h.mu.Unlock()
+	os.Remove(filepath.Join(h.dir, serverID+".pid"))
+	os.Remove(filepath.Join(h.dir, serverID+".stop"))
+
+	if err := h.c.result(res); err != nil {
+		h.c.fail(err)
+	}
+}
+
+func (h *host) stopAll() {
+	h.mu.Lock()
+	ids := make([]string, 0, len(h.servers))
+	for id := range h.servers {
+		ids = append(ids, id)
+	}
+	h.mu.Unlock()
+	for _, id := range ids {
+		h.stop(id)
+	}
+}
+
+// serve waits for stop requests, games that quit, and Ctrl+C/SIGTERM.
+// Without keepAlive it returns once no server is left.
+func (h *host) serve(keepAlive bool) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+
+	for keepAlive || h.count() > 0 {
+		select {
+		case <-signals:
+			h.c.app.Log("🛑 Shutting down...")
+			h.stopAll()
+			return
+		case id := <-h.ended:
+			if h.hosting(id) {
+				h.stop(id) // Upload what the session saved and free the lock
+			}
+		case <-ticker.C:
+			h.mu.Lock()
+			ids := make([]string, 0, len(h.servers))
+			for id := range h.servers {
+				ids = append(ids, id)
+			}
+			h.mu.Unlock()
+			for _, id := range ids {
+				if _, err := os.Stat(filepath.Join(h.dir, id+".stop")); err == nil {
+					h.stop(id)
+				}
+			}
+		}
+	}
+}
+
+// startForeground hosts one server; stdin lines go to its console
+func (c *cli) startForeground(serverID string) error {
+	s, err := c.unlocked()
+	if err != nil {
+		return err
+	}
+	h, err := c.newHost(s.Token)
+	if err != nil {
+		return err
+	}
+	if err := h.start(serverID); err != nil {
+		return err
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			// Replies come back as console events
+			if res := c.app.SendConsoleCommand(serverID, s.Token, line); strings.HasPrefix(res, "Error") {
+				fmt.Fprintln(os.Stderr, res)
+			}
+		}
+	}()
+
+	h.serve(false)
+	return nil
+}
+
+// daemon hosts the given servers and keeps running so pending uploads are
+// retried (and the API stays up), until Ctrl+C/SIGTERM
+func (c *cli) daemon(serverIDs []string, apiAddress string, apiLAN bool) error {
+	s, err := c.unlocked()
+	if err != nil {
+		return err
+	}
+	h, err := c.newHost(s.Token)
+	if err != nil {
+		return err
+	}
+
+	c.app.Log(fmt.Sprintf("🖥️ Daemon running as %s (PID %d).", s.Username, os.Getpid()))
+	if apiAddress != "" || c.app.GetHostSettings().APIAddress != "" {
+		if err := c.result(c.app.StartAPI(s.Token, apiAddress, apiLAN)); err != nil {
+			return err
+		}
+		defer c.app.StopAPI(s.Token)
+	}
+	for _, id := range serverIDs {
+		if err := h.start(id); err != nil {
+			c.fail(fmt.Errorf("%s: %v", id, err))
+		}
+	}
+	h.serve(true)
+	return nil
+}
+
+// stop asks the process hosting a server on this PC to stop it. If that
+// process is gone, the server is stopped and uploaded from here.
+func (c *cli) stop(serverID string) error {
+	s, err := c.session()
+	if err != nil {
+		return err
+	}
+	base, err := stateDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(base, "hosts")
+	pidPath := filepath.Join(dir, serverID+".pid")
+
+	if pid, ok := backend.HostPIDAlive(pidPath); ok {
+		if err := os.WriteFile(filepath.Join(dir, serverID+".stop"), nil, 0600); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Asked the host process (PID %d) to stop %s. Waiting for the upload...\n", pid, serverID)
+		deadline := time.Now().Add(stopWaitTimeout)
+		for {
+			time.Sleep(time.Second)
+			if _, ok := backend.HostPIDAlive(pidPath); !ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("the host process (PID %d) hasn't finished after %s; it may still be uploading", pid, stopWaitTimeout)
+			}
+		}
+		for _, server := range c.app.GetMyServers(s.Token) {
+			if server.ID != serverID {
+				continue
+			}
+			switch {
+			case server.Lock.PendingUpload:
+				return fmt.Errorf("upload failed; the host keeps retrying it")
+			case server.Lock.IsRunning:
+				return fmt.Errorf("the server is still locked by %s", server.Lock.HostedBy)
+			}
+			return c.result("Success: Server Stopped & Saved!")
+		}
+		return fmt.Errorf("server not found")
+	}
+
+	// No host process: it crashed or was killed. Clean up its game and upload.
+	if s, err = c.unlocked(); err != nil {
+		return err
+	}
+	os.Remove(pidPath)
+	c.app.KillZombie(serverID, s.Token)
+	return c.result(c.app.StopServer(serverID, s.Token))
+}
+
+// console sends one command, or opens an interactive console, to a server
+// running on this PC (from this CLI or the app)
+func (c *cli) console(serverID string, command string) error {
+	s, err := c.session()
+	if err != nil {
+		return err
+	}
+	if !c.isAdmin(s, serverID) {
+		return fmt.Errorf("only admins can send console commands")
+	}
+
+	client, err := backend.DialInstanceRCON(backend.InstancePath(serverID))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	send := func(line string) error {
+		reply, err := client.Command(line)
+		if err != nil {
+			return err
+		}
+		if c.json {
+			c.printJSON(map[string]interface{}{"ok": true, "command": line, "reply": reply})
+		} else if reply != "" {
+			fmt.Println(reply)
+		}
+		return nil
+	}
+
+	if command != "" {
+		return send(command)
+	}
+	fmt.Fprintln(os.Stderr, "Connected. Type commands, Ctrl+D to quit.")
+	scanner := bufio.NewScanner(os.Stdin)
+	for fmt.Fprint(os.Stderr, "> "); scanner.Scan(); fmt.Fprint(os.Stderr, "> ") {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			if err := send(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *cli) isAdmin(s savedSession, serverID string) bool {
+	for _, server := range c.app.GetMyServers(s.Token) {
+		if server.ID != serverID {
+			continue
+		}
+		if server.OwnerID == s.Username {
+			return true
+		}
+		for _, admin := range server.Admins {
+			if admin == s.Username {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+// Command mc-roam drives the MC Roam backend without the desktop window:
+// one-shot commands for scripting, and a daemon mode so a headless box can
+// act as an always-on host.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"mc-roam/backend"
+)
+
+const usage = `Usage: mc-roam [--json] <command> [args]
+
+Commands:
+  login <username>              Log in and remember the session
+  logout                        Forget the session
+  servers list                  List your server groups
+  start <id>                    Host a server in the foreground (Ctrl+C stops & uploads)
+  stop <id>                     Stop a server hosted on this PC and upload it
+  console <id> [command...]     Send a command (or open a console) to a server on this PC
+  sync up|down <id>             Upload or download a server while nobody hosts it
+  backup list <id>              List snapshots
+  backup restore <id> <name>    Restore a snapshot (admins)
+  versions                      List server types and versions
+  daemon [--host id,id...] [--api addr] [--api-lan]
+                                Run as an always-on host, optionally serving
+                                the HTTP API (e.g. --api 127.0.0.1:8765);
+                                --api-lan allows addresses other PCs can reach
+
+Flags:
+  --json                        Machine-readable output
+
+The password is read from MC_ROAM_PASSWORD, or asked for when needed.
+`
+
+// cli holds what every command needs
+type cli struct {
+	app    *backend.App
+	events *backend.EventBus
+	json   bool
+}
+
+func main() {
+	args, jsonOut := extractFlag(os.Args[1:], "--json")
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(usage)
+		return
+	}
+
+	store, err := backend.OpenStore()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: Database connection failed:", err)
+		os.Exit(1)
+	}
+	events := backend.NewEventBus()
+	c := &cli{
+		app:    backend.NewHeadlessApp(store, events),
+		events: events,
+		json:   jsonOut,
+	}
+	c.app.Startup(context.Background())
+
+	if err := c.run(args[0], args[1:]); err != nil {
+		if err == errUsage {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		c.fail(err)
+		os.Exit(1)
+	}
+}
+
+var errUsage = fmt.Errorf("usage")
+
+func (c *cli) run(command string, args []string) error {
+	// Hosting commands stream events to stdout; the rest log to stderr
+	// so their stdout stays clean for scripts
+	switch command {
+	case "start", "daemon":
+		c.events.Subscribe("", c.printEvent)
+	default:
+		c.events.Subscribe("", func(e backend.Event) {
+			if e.Message != "" && e.Topic != backend.TopicConsole {
+				fmt.Fprintln(os.Stderr, e.Text())
+			}
+		})
+	}
+
+	switch command {
+	case "login":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return c.login(args[0])
+	case "logout":
+		return c.logout()
+	case "servers":
+		if len(args) != 1 || args[0] != "list" {
+			return errUsage
+		}
+		return c.listServers()
+	case "start":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return c.startForeground(args[0])
+	case "stop":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return c.stop(args[0])
+	case "console":
+		if len(args) < 1 {
+			return errUsage
+		}
+		return c.console(args[0], strings.Join(args[1:], " "))
+	case "sync":
+		if len(args) != 2 || (args[0] != "up" && args[0] != "down") {
+			return errUsage
+		}
+		return c.sync(backend.SyncDirection(args[0]), args[1])
+	case "backup":
+		switch {
+		case len(args) == 2 && args[0] == "list":
+			return c.listBackups(args[1])
+		case len(args) == 3 && args[0] == "restore":
+			return c.restoreBackup(args[1], args[2])
+		}
+		return errUsage
+	case "versions":
+		return c.listVersions()
+	case "daemon":
+		var hosted []string
+		rest, value := extractValue(args, "--host")
+		rest, apiAddress := extractValue(rest, "--api")
+		rest, apiLAN := extractFlag(rest, "--api-lan")
+		if len(rest) != 0 {
+			return errUsage
+		}
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				hosted = append(hosted, id)
+			}
+		}
+		return c.daemon(hosted, apiAddress, apiLAN)
+	}
+	return errUsage
+}
+
+// extractFlag removes a boolean flag from anywhere in args
+func extractFlag(args []string, name string) ([]string, bool) {
+	found := false
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == name || arg == "-"+strings.TrimLeft(name, "-") {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// extractValue removes "--name value" or "--name=value" from args
+func extractValue(args []string, name string) ([]string, string) {
+	value := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == name && i+1 < len(args):
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], name+"="):
+			value = strings.TrimPrefix(args[i], name+"=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return rest, value
+}
+
+// --- OUTPUT ---
+
+// result turns a backend "Success..." / "Error: ..." string into output
+// and an error
+func (c *cli) result(res string) error {
+	if strings.HasPrefix(res, "Error") {
+		return fmt.Errorf("%s", strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(res, "Error"), ":")))
+	}
+	message := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(res, "Success"), ":"))
+	if c.json {
+		c.printJSON(map[string]interface{}{"ok": true, "message": message})
+	} else if message != "" {
+		fmt.Println(message)
+	} else {
+		fmt.Println("OK")
+	}
+	return nil
+}
+
+func (c *cli) fail(err error) {
+	if c.json {
+		data, _ := json.Marshal(map[string]interface{}{"ok": false, "error": err.Error()})
+		fmt.Fprintln(os.Stderr, string(data))
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+}
+
+func (c *cli) printJSON(v interface{}) {
+	data, _ := json.Marshal(v)
+	fmt.Println(string(data))
+}
+
+// printEvent streams an event: one JSON object per line, or its text
+func (c *cli) printEvent(e backend.Event) {
+	if c.json {
+		c.printJSON(e)
+		return
+	}
+	if e.Message != "" {
+		fmt.Println(e.Text())
+	}
+}
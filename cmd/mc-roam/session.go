@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// savedSession is the CLI's login, kept in the user's config folder.
+// Only the session token is stored; keys are unlocked per process.
+type savedSession struct {
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+// stateDir is where the CLI keeps its session and host files
+func stateDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "mc-roam")
+	return dir, os.MkdirAll(dir, 0700)
+}
+
+func loadSession() (savedSession, error) {
+	var s savedSession
+	dir, err := stateDir()
+	if err != nil {
+		return s, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "session.json"))
+	if err != nil || json.Unmarshal(data, &s) != nil || s.Token == "" {
+		return s, fmt.Errorf("not logged in (run: mc-roam login <username>)")
+	}
+	return s, nil
+}
+
+func saveSession(s savedSession) error {
+	dir, err := stateDir()
+	if err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return os.WriteFile(filepath.Join(dir, "session.json"), data, 0600)
+}
+
+// password comes from MC_ROAM_PASSWORD or a prompt on the terminal
+func password(username string) (string, error) {
+	if pw := os.Getenv("MC_ROAM_PASSWORD"); pw != "" {
+		return pw, nil
+	}
+	fmt.Fprintf(os.Stderr, "Password for %s: ", username)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("no password given")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *cli) login(username string) error {
+	pw, err := password(username)
+	if err != nil {
+		return err
+	}
+	res := c.app.Login(username, pw)
+	if !strings.HasPrefix(res, "Success:") {
+		return c.result(res)
+	}
+	if err := saveSession(savedSession{Username: username, Token: strings.TrimPrefix(res, "Success:")}); err != nil {
+		return err
+	}
+	return c.result("Success: Logged in as " + username)
+}
+
+func (c *cli) logout() error {
+	s, err := loadSession()
+	if err != nil {
+		return c.result("Success: Logged out")
+	}
+	c.app.Logout(s.Token)
+	dir, _ := stateDir()
+	os.Remove(filepath.Join(dir, "session.json"))
+	return c.result("Success: Logged out")
+}
+
+// session returns the saved login, checking it is still valid
+func (c *cli) session() (savedSession, error) {
+	s, err := loadSession()
+	if err != nil {
+		return s, err
+	}
+	if c.app.GetSessionUser(s.Token) == "" {
+		return s, fmt.Errorf("session expired (run: mc-roam login %s)", s.Username)
+	}
+	return s, nil
+}
+
+// unlocked returns the saved login with the user's keys unlocked, which
+// anything touching the cloud needs
+func (c *cli) unlocked() (savedSession, error) {
+	s, err := c.session()
+	if err != nil {
+		return s, err
+	}
+	pw, err := password(s.Username)
+	if err != nil {
+		return s, err
+	}
+	if res := c.app.UnlockKeys(s.Token, pw); strings.HasPrefix(res, "Error") {
+		return s, fmt.Errorf("%s", strings.TrimPrefix(res, "Error: "))
+	}
+	return s, nil
+}
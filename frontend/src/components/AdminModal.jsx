@@ -2,7 +2,7 @@ import { useState, useEffect } from 'react';
 import { GetAdmins, SetAdmin, RemoveAdmin } from '../../wailsjs/go/backend/App';
 import './AdminModal.css';
 
-export default function AdminModal({ server, currentUser, onClose }) {
+export default function AdminModal({ server, currentUser, sessionToken, onClose }) {
     const [admins, setAdmins] = useState([]);
     const [newAdminName, setNewAdminName] = useState('');
     const [loading, setLoading] = useState(false);
@@ -17,7 +17,7 @@ export default function AdminModal({ server, currentUser, onClose }) {
 
     const loadAdmins = async () => {
         try {
-            const adminsList = await GetAdmins(server.id);
+            const adminsList = await GetAdmins(server.id, sessionToken);
             setAdmins(adminsList);
         } catch (err) {
             console.error('Failed to load admins:', err);
@@ -34,7 +34,7 @@ export default function AdminModal({ server, currentUser, onClose }) {
         setMessage('');
 
         try {
-            const result = await SetAdmin(server.id, newAdminName.trim(), currentUser);
+            const result = await SetAdmin(server.id, newAdminName.trim(), sessionToken);
 
             if (result === 'Success') {
                 setMessage(`Success: ${newAdminName} is now an admin`);
@@ -62,7 +62,7 @@ export default function AdminModal({ server, currentUser, onClose }) {
         setMessage('');
 
         try {
-            const result = await RemoveAdmin(server.id, username, currentUser);
+            const result = await RemoveAdmin(server.id, username, sessionToken);
 
             if (result === 'Success') {
                 setMessage(`Success: ${username} removed from admins`);
@@ -0,0 +1,93 @@
+import { useState, useEffect } from 'react';
+import { ListSnapshots, ChooseExportFile, ExportWorld } from '../../wailsjs/go/backend/App';
+
+// Progress shows in the global progress bar (bus:export)
+export default function ExportModal({ server, sessionToken, onClose }) {
+    const [snapshots, setSnapshots] = useState([]);
+    const [snapshot, setSnapshot] = useState(""); // "" = this PC's copy
+    const [options, setOptions] = useState({ configs: false, player_data: true, flat: false });
+    const [isExporting, setIsExporting] = useState(false);
+
+    useEffect(() => {
+        ListSnapshots(server.id, sessionToken).then(list => setSnapshots(list || []));
+        // eslint-disable-next-line
+    }, []);
+
+    const toggle = (key) => setOptions(prev => {
+        const next = { ...prev, [key]: !prev[key] };
+        // Configs don't fit in a flat archive
+        if (key === 'flat' && next.flat) next.configs = false;
+        if (key === 'configs' && next.configs) next.flat = false;
+        return next;
+    });
+
+    const handleExport = async () => {
+        const dest = await ChooseExportFile(server.id);
+        if (!dest) return;
+        setIsExporting(true);
+        const res = await ExportWorld(server.id, sessionToken, dest, { ...options, snapshot });
+        setIsExporting(false);
+        alert(res);
+        if (res.startsWith("Success")) onClose();
+    };
+
+    const formatSnapshot = (s) => `${new Date(s.created_at).toLocaleString()} (${(s.size / 1024 / 1024).toFixed(0)} MB)`;
+
+    const checkbox = (key, label, hint) => (
+        <label style={styles.option}>
+            <input type="checkbox" checked={options[key]} onChange={() => toggle(key)} />
+            <div>
+                <div style={styles.label}>{label}</div>
+                <div style={styles.hint}>{hint}</div>
+            </div>
+        </label>
+    );
+
+    return (
+        <div style={styles.overlay} onClick={onClose}>
+            <div style={styles.modal} onClick={(e) => e.stopPropagation()}>
+                <div style={styles.header}>
+                    <div>
+                        <h2 style={{ margin: 0, color: '#fff' }}>Export World</h2>
+                        <div style={{ fontSize: '0.8rem', color: '#aaa' }}>{server.name}</div>
+                    </div>
+                    <button onClick={onClose} style={styles.closeBtn}>×</button>
+                </div>
+
+                <div style={styles.content}>
+                    <label style={styles.label}>Source</label>
+                    <select value={snapshot} onChange={(e) => setSnapshot(e.target.value)} style={styles.select}>
+                        <option value="">This PC's copy{server.lock?.is_running ? " (saved first if running here)" : ""}</option>
+                        {snapshots.map(s => (
+                            <option key={s.name} value={s.name}>Snapshot: {formatSnapshot(s)}</option>
+                        ))}
+                    </select>
+
+                    {checkbox('player_data', 'Player Data', 'Inventories, stats and advancements')}
+                    {checkbox('configs', 'Server Configs', 'server.properties, ops, whitelist and bans')}
+                    {checkbox('flat', 'World Files at the Top', 'No world folder inside the archive (.mcworld style)')}
+
+                    <button style={styles.exportBtn} disabled={isExporting} onClick={handleExport}>
+                        {isExporting ? "Exporting..." : "📦 Export to Zip..."}
+                    </button>
+                </div>
+            </div>
+        </div>
+    );
+}
+
+const styles = {
+    overlay: { position: "fixed", top: 0, left: 0, right: 0, bottom: 0, background: "rgba(0,0,0,0.8)", display: "flex", justifyContent: "center", alignItems: "center", zIndex: 3000, backdropFilter: "blur(5px)" },
+    modal: { background: "#18181b", width: "480px", borderRadius: "16px", border: "1px solid #27272a", display: "flex", flexDirection: "column", overflow: "hidden", boxShadow: "0 25px 50px -12px rgba(0, 0, 0, 0.5)" },
+
+    header: { padding: "24px", borderBottom: "1px solid #27272a", display: "flex", justifyContent: "space-between", alignItems: "center", background: "#202023" },
+    closeBtn: { background: "none", border: "none", color: "#71717a", fontSize: "2rem", cursor: "pointer", lineHeight: "1" },
+
+    content: { padding: "24px", display: "flex", flexDirection: "column", gap: "14px" },
+    select: { background: "#27272a", border: "1px solid #3f3f46", color: "white", padding: "10px 12px", borderRadius: "6px", fontWeight: "bold", cursor: "pointer" },
+    option: { display: "flex", gap: "12px", alignItems: "flex-start", background: "#27272a", padding: "12px 16px", borderRadius: "8px", border: "1px solid #3f3f46", cursor: "pointer" },
+    label: { fontSize: "0.95rem", color: "#e4e4e7", fontWeight: "500" },
+    hint: { fontSize: "0.8rem", color: "#71717a", marginTop: "2px" },
+
+    exportBtn: { marginTop: "6px", padding: "12px", borderRadius: "8px", fontWeight: "bold", cursor: "pointer", fontSize: "0.95rem", background: "#fab005", color: "#18181b", border: "none" }
+};
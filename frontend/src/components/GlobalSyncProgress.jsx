@@ -6,36 +6,29 @@ export default function GlobalSyncProgress() {
     const [syncState, setSyncState] = useState(null); // { message, percent, isActive }
 
     useEffect(() => {
-        const stop = EventsOn("server-log", (msg) => {
-            // Parse sync messages
-            if (msg.includes("STARTING DOWNLOAD") || msg.includes("STARTING UPLOAD")) {
-                const isDownload = msg.includes("DOWNLOAD");
+        const stop = EventsOn("bus:sync", (event) => {
+            const { direction, phase, percent } = event.payload || {};
+            const isDownload = direction === "down";
+
+            if (phase === "start") {
                 setSyncState({
                     message: isDownload ? "Downloading from Cloud..." : "Uploading to Cloud...",
                     percent: 0,
                     isActive: true
                 });
             }
-            else if (msg.startsWith("[Sync]:") && msg.includes("Checks:") && msg.includes("%")) {
-                // Parse: "Checks: 14 / 14, 100%"
-                const match = msg.match(/Checks:\s+(\d+)\s+\/\s+(\d+)/);
-                if (match) {
-                    const current = parseInt(match[1]);
-                    const total = parseInt(match[2]);
-                    const percent = total > 0 ? Math.round((current / total) * 100) : 0;
-
-                    setSyncState(prev => prev ? {
-                        ...prev,
-                        percent,
-                        isActive: true
-                    } : null);
-                }
+            else if (phase === "progress" && percent >= 0) {
+                setSyncState(prev => prev ? {
+                    ...prev,
+                    percent,
+                    isActive: true
+                } : null);
             }
-            else if (msg.includes("Download Complete") || msg.includes("Upload Complete")) {
+            else if (phase === "done") {
                 setSyncState(prev => prev ? {
                     ...prev,
                     percent: 100,
-                    message: msg.includes("Download") ? "Download Complete!" : "Upload Complete!",
+                    message: isDownload ? "Download Complete!" : "Upload Complete!",
                     isActive: true
                 } : null);
 
@@ -44,6 +37,54 @@ export default function GlobalSyncProgress() {
                     setSyncState(null);
                 }, 2000);
             }
+            else if (phase === "error") {
+                setSyncState(null);
+            }
+        });
+
+        return () => stop && stop();
+    }, []);
+
+    // Jar & tool downloads report byte progress separately
+    useEffect(() => {
+        const stop = EventsOn("bus:download", (event) => {
+            const p = event.payload;
+            if (p.error) {
+                setSyncState(null);
+                return;
+            }
+            const percent = p.total > 0 ? Math.round((p.downloaded / p.total) * 100) : 0;
+            setSyncState({
+                message: p.done ? `${p.name} downloaded!` : `Downloading ${p.name}...`,
+                percent: p.done ? 100 : percent,
+                isActive: true
+            });
+            if (p.done) {
+                setTimeout(() => setSyncState(null), 2000);
+            }
+        });
+
+        return () => stop && stop();
+    }, []);
+
+    // World exports report files written
+    useEffect(() => {
+        const stop = EventsOn("bus:export", (event) => {
+            const p = event.payload || {};
+            if (p.phase === "error") {
+                setSyncState(null);
+                return;
+            }
+            const messages = { saving: "Saving the world...", downloading: "Downloading snapshot...", writing: "Exporting world...", done: "World exported!" };
+            const percent = p.total_bytes > 0 ? Math.round((p.bytes / p.total_bytes) * 100) : 0;
+            setSyncState({
+                message: messages[p.phase] || "Exporting world...",
+                percent: p.phase === "done" ? 100 : percent,
+                isActive: true
+            });
+            if (p.phase === "done") {
+                setTimeout(() => setSyncState(null), 2000);
+            }
         });
 
         return () => stop && stop();
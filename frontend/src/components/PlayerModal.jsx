@@ -3,12 +3,13 @@ import { GetPlayerLists, ManagePlayer } from '../../wailsjs/go/backend/App';
 import PlayerDetail from './PlayerDetail';
 import './PlayerModal.css';
 
-export default function PlayerModal({ server, currentUser, onClose }) {
+export default function PlayerModal({ server, currentUser, sessionToken, onClose }) {
     const [lists, setLists] = useState({ ops: [], whitelist: [], banned: [], history: [] });
     const [activeTab, setActiveTab] = useState("HISTORY");
     const [inputName, setInputName] = useState("");
     const [refreshTrigger, setRefreshTrigger] = useState(0);
     const [selectedPlayer, setSelectedPlayer] = useState(null);
+    const [lastReply, setLastReply] = useState("");
 
     const isRunning = server.lock.is_running;
 
@@ -18,7 +19,7 @@ export default function PlayerModal({ server, currentUser, onClose }) {
     }, [refreshTrigger]);
 
     const loadData = async () => {
-        const data = await GetPlayerLists(server.id);
+        const data = await GetPlayerLists(server.id, sessionToken);
         setLists(data);
     };
 
@@ -30,8 +31,10 @@ export default function PlayerModal({ server, currentUser, onClose }) {
             return;
         }
 
-        const res = await ManagePlayer(server.id, currentUser, action, targetName, extra);
-        if (res === "Success") {
+        const res = await ManagePlayer(server.id, sessionToken, action, targetName, extra);
+        if (res.startsWith("Success")) {
+            // The server's own answer, e.g. "Made Steve a server operator"
+            setLastReply(res.replace(/^Success:?\s*/, ""));
             // Wait a sec for server to update JSON files, then refresh UI
             setTimeout(() => setRefreshTrigger(prev => prev + 1), 1000);
             setInputName("");
@@ -103,6 +106,7 @@ export default function PlayerModal({ server, currentUser, onClose }) {
                                 </button>
                             </div>
                         )}
+                        {lastReply && <div className="player-modal-reply">{lastReply}</div>}
 
                         {/* LIST CONTENT */}
                         <div className="player-modal-content">
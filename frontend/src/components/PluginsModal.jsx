@@ -0,0 +1,161 @@
+import { useState, useEffect } from 'react';
+import { GetPluginProviders, SearchPlugins, ListPlugins, InstallPlugin, UpdatePlugins, UninstallPlugin } from '../../wailsjs/go/backend/App';
+
+// Plugins are installed into the group's synced folder, so the server has
+// to be stopped while they change (the backend checks it too)
+export default function PluginsModal({ server, sessionToken, onClose }) {
+    const [providers, setProviders] = useState([]);
+    const [provider, setProvider] = useState("");
+    const [query, setQuery] = useState("");
+    const [results, setResults] = useState([]);
+    const [installed, setInstalled] = useState([]);
+    const [busy, setBusy] = useState(""); // What we're waiting for, shown in the header
+    const [message, setMessage] = useState("");
+
+    const isRunning = server.lock?.is_running;
+
+    const refresh = () => ListPlugins(server.id, sessionToken).then(list => setInstalled(list || []));
+
+    useEffect(() => {
+        GetPluginProviders().then(list => {
+            setProviders(list || []);
+            if (list && list.length > 0) setProvider(list[0]);
+        });
+        refresh();
+        // eslint-disable-next-line
+    }, []);
+
+    const search = async (e) => {
+        e.preventDefault();
+        setBusy("Searching...");
+        const list = await SearchPlugins(server.id, sessionToken, provider, query);
+        setResults(list || []);
+        setBusy("");
+    };
+
+    // Runs an install/update/uninstall and reloads the list
+    const run = async (label, action) => {
+        setBusy(label);
+        setMessage("");
+        const result = await action();
+        setMessage(result);
+        setBusy("");
+        refresh();
+    };
+
+    const isInstalled = (p) => installed.some(i => i.provider === p.provider && i.project_id === p.id);
+
+    return (
+        <div style={styles.overlay} onClick={onClose}>
+            <div style={styles.modal} onClick={(e) => e.stopPropagation()}>
+
+                {/* HEADER */}
+                <div style={styles.header}>
+                    <div>
+                        <h2 style={{ margin: 0, color: '#fff' }}>Plugins</h2>
+                        <div style={{ fontSize: '0.8rem', color: '#aaa' }}>
+                            {busy || `${server.type} ${server.version}`}
+                        </div>
+                    </div>
+                    <button onClick={onClose} style={styles.closeBtn}>×</button>
+                </div>
+
+                <div style={styles.content}>
+                    {isRunning && <div style={styles.warning}>Stop the server to install or remove plugins.</div>}
+                    {message && (
+                        <div style={{ ...styles.message, color: message.startsWith('Error') ? '#ef4444' : '#10b981' }}>{message}</div>
+                    )}
+
+                    {/* INSTALLED */}
+                    <div style={styles.sectionRow}>
+                        <h3 style={styles.sectionTitle}>Installed ({installed.length})</h3>
+                        <button
+                            style={styles.button}
+                            disabled={!!busy || isRunning || installed.length === 0}
+                            onClick={() => run("Updating plugins...", () => UpdatePlugins(server.id, sessionToken))}
+                        >
+                            Update All
+                        </button>
+                    </div>
+                    {installed.length === 0 && <div style={styles.empty}>No plugins installed through the app yet.</div>}
+                    {installed.map(p => (
+                        <div key={p.provider + p.project_id} style={styles.card}>
+                            <div style={styles.info}>
+                                <div style={styles.name}>{p.name} <span style={styles.version}>{p.version}</span></div>
+                                <div style={styles.meta}>{p.provider} · {p.file_name} · added by {p.installed_by}</div>
+                            </div>
+                            <button
+                                style={styles.removeBtn}
+                                disabled={!!busy || isRunning}
+                                onClick={() => run(`Removing ${p.name}...`, () => UninstallPlugin(server.id, sessionToken, p.provider, p.project_id))}
+                            >
+                                Remove
+                            </button>
+                        </div>
+                    ))}
+
+                    {/* SEARCH */}
+                    <h3 style={{ ...styles.sectionTitle, marginTop: '24px' }}>Find Plugins</h3>
+                    <form onSubmit={search} style={styles.searchRow}>
+                        <select value={provider} onChange={(e) => setProvider(e.target.value)} style={styles.select}>
+                            {providers.map(p => <option key={p} value={p}>{p}</option>)}
+                        </select>
+                        <input
+                            value={query}
+                            onChange={(e) => setQuery(e.target.value)}
+                            placeholder="Search plugins..."
+                            style={styles.input}
+                        />
+                        <button type="submit" style={styles.button} disabled={!!busy}>Search</button>
+                    </form>
+                    {results.map(p => (
+                        <div key={p.provider + p.id} style={styles.card}>
+                            {p.icon_url && <img src={p.icon_url} alt="" style={styles.icon} />}
+                            <div style={styles.info}>
+                                <div style={styles.name}>{p.name} <span style={styles.version}>by {p.author}</span></div>
+                                <div style={styles.meta}>{p.description}</div>
+                            </div>
+                            <button
+                                style={styles.button}
+                                disabled={!!busy || isRunning}
+                                onClick={() => run(`Installing ${p.name}...`, () => InstallPlugin(server.id, sessionToken, p.provider, p.id))}
+                            >
+                                {isInstalled(p) ? "Reinstall" : "Install"}
+                            </button>
+                        </div>
+                    ))}
+                </div>
+            </div>
+        </div>
+    );
+}
+
+const styles = {
+    overlay: { position: "fixed", top: 0, left: 0, right: 0, bottom: 0, background: "rgba(0,0,0,0.8)", display: "flex", justifyContent: "center", alignItems: "center", zIndex: 3000, backdropFilter: "blur(5px)" },
+    modal: { background: "#18181b", width: "800px", height: "600px", borderRadius: "16px", border: "1px solid #27272a", display: "flex", flexDirection: "column", overflow: "hidden", boxShadow: "0 25px 50px -12px rgba(0, 0, 0, 0.5)" },
+
+    header: { padding: "24px", borderBottom: "1px solid #27272a", display: "flex", justifyContent: "space-between", alignItems: "center", background: "#202023" },
+    closeBtn: { background: "none", border: "none", color: "#71717a", fontSize: "2rem", cursor: "pointer", lineHeight: "1" },
+
+    content: { padding: "24px 30px", overflowY: "auto", flex: 1, background: "#18181b", scrollbarWidth: "thin", scrollbarColor: "#3f3f46 #18181b" },
+    warning: { background: "rgba(250, 176, 5, 0.1)", border: "1px solid #fab005", color: "#fab005", padding: "10px 14px", borderRadius: "8px", marginBottom: "16px", fontSize: "0.9rem" },
+    message: { marginBottom: "16px", fontSize: "0.9rem" },
+
+    sectionRow: { display: "flex", justifyContent: "space-between", alignItems: "center", marginBottom: "10px" },
+    sectionTitle: { margin: "0 0 10px 0", color: "#e4e4e7", fontSize: "1rem" },
+    empty: { color: "#71717a", fontSize: "0.9rem" },
+
+    searchRow: { display: "flex", gap: "8px", marginBottom: "14px" },
+    select: { background: "#27272a", border: "1px solid #3f3f46", color: "white", padding: "8px 12px", borderRadius: "6px", fontWeight: "bold", cursor: "pointer" },
+    input: { flex: 1, background: "#27272a", border: "1px solid #3f3f46", color: "white", padding: "8px 12px", borderRadius: "6px" },
+
+    card: { background: "#27272a", padding: "12px 16px", borderRadius: "8px", display: "flex", alignItems: "center", gap: "12px", border: "1px solid #3f3f46", marginBottom: "8px" },
+    icon: { width: "36px", height: "36px", borderRadius: "6px" },
+    info: { flex: 1, minWidth: 0 },
+    name: { fontSize: "0.95rem", color: "#e4e4e7", fontWeight: "500" },
+    version: { fontSize: "0.75rem", color: "#71717a", fontFamily: "monospace", marginLeft: "6px" },
+    meta: { fontSize: "0.8rem", color: "#a1a1aa", marginTop: "2px", overflow: "hidden", textOverflow: "ellipsis", whiteSpace: "nowrap" },
+
+    button: { padding: "8px 16px", borderRadius: "6px", fontWeight: "bold", cursor: "pointer", fontSize: "0.85rem", background: "rgba(250, 176, 5, 0.15)", color: "#fab005", border: "1px solid #fab005" },
+    removeBtn: { padding: "8px 16px", borderRadius: "6px", fontWeight: "bold", cursor: "pointer", fontSize: "0.85rem", background: "rgba(239, 68, 68, 0.15)", color: "#ef4444", border: "1px solid #ef4444" }
+};
@@ -1,7 +1,7 @@
 import React, { useState } from 'react';
 import './ServerCard.css';
 
-const ServerCard = ({ server, currentUser, onStart, onStop, onDelete, onSettings, onWorld, onPlayers, onAdmins }) => {
+const ServerCard = ({ server, currentUser, onStart, onStop, onDelete, onSettings, onWorld, onPlugins, onExport, onPlayers, onAdmins, onAbandonUpload }) => {
     const { name, invite_code, status } = server;
     const owner = server.owner || server.owner_id;
     const isRunning = server.lock.is_running;
@@ -10,6 +10,7 @@ const ServerCard = ({ server, currentUser, onStart, onStop, onDelete, onSettings
     const isOwner = (owner === currentUser);
     const isAdmin = isOwner || (server.admins && server.admins.includes(currentUser));
     const isHost = (server.lock.hosted_by === currentUser);
+    const isPendingUpload = !!server.lock.pending_upload;
 
     const [showInvite, setShowInvite] = useState(false);
     const [copiedInvite, setCopiedInvite] = useState(false);
@@ -74,6 +75,22 @@ const ServerCard = ({ server, currentUser, onStart, onStop, onDelete, onSettings
                                 <span style={{ fontSize: '14px' }}>🌍</span>
                             </button>
                         )}
+                        {isAdmin && ['paper', 'purpur'].includes(server.type?.toLowerCase()) && (
+                            <button
+                                className="server-card__icon-btn"
+                                onClick={onPlugins}
+                                title="Plugins"
+                            >
+                                <span style={{ fontSize: '14px' }}>🔌</span>
+                            </button>
+                        )}
+                        <button
+                            className="server-card__icon-btn"
+                            onClick={onExport}
+                            title="Export World"
+                        >
+                            <span style={{ fontSize: '14px' }}>📦</span>
+                        </button>
                         {isAdmin && (
                             <button
                                 className="server-card__icon-btn"
@@ -117,7 +134,7 @@ const ServerCard = ({ server, currentUser, onStart, onStop, onDelete, onSettings
                                 onClick={() => setShowInvite(!showInvite)}
                                 title="Click to Reveal"
                             >
-                                {showInvite ? invite_code : '••••••••'}
+                                {showInvite ? (invite_code || '?') : '••••••••'}
                             </span>
                             <button
                                 className="server-card__copy-btn"
@@ -151,14 +168,38 @@ const ServerCard = ({ server, currentUser, onStart, onStop, onDelete, onSettings
                     )}
                 </div>
 
+                {/* PENDING UPLOAD: last session hasn't reached the cloud yet */}
+                {isPendingUpload && (
+                    <div className="server-card__pending">
+                        <span>
+                            {isHost ? 'Your last session' : `${server.lock.hosted_by}'s last session`} is still uploading (retrying automatically).
+                        </span>
+                        {isOwner && (
+                            <button
+                                className="server-card__copy-btn"
+                                onClick={onAbandonUpload}
+                                title="Discard that session and unlock the server"
+                            >
+                                Abandon
+                            </button>
+                        )}
+                    </div>
+                )}
+
                 {/* BIG ACTION BUTTON */}
-                <button
-                    className={`server-card__action-btn ${isRunning ? 'server-card__action-btn--running' : 'server-card__action-btn--stopped'}`}
-                    onClick={isRunning ? onStop : onStart}
-                    disabled={isRunning && !isHost}
-                >
-                    {isRunning ? (isHost ? 'STOP SERVER' : 'SERVER ONLINE') : 'START SERVER'}
-                </button>
+                {isPendingUpload ? (
+                    <button className="server-card__action-btn server-card__action-btn--running" disabled>
+                        UPLOAD PENDING
+                    </button>
+                ) : (
+                    <button
+                        className={`server-card__action-btn ${isRunning ? 'server-card__action-btn--running' : 'server-card__action-btn--stopped'}`}
+                        onClick={isRunning ? onStop : onStart}
+                        disabled={isRunning && !isHost}
+                    >
+                        {isRunning ? (isHost ? 'STOP SERVER' : 'SERVER ONLINE') : 'START SERVER'}
+                    </button>
+                )}
             </div>
         </div>
     );
@@ -1,9 +1,9 @@
 import { useState, useEffect } from 'react';
-import { GetServerOptions, SaveServerOptions, GetVersions } from '../../wailsjs/go/backend/App';
+import { GetServerOptions, SaveServerOptions, GetVersions, GetServerProperties, SetServerProperties } from '../../wailsjs/go/backend/App';
 import { ChangeServerVersionWails } from '../../wailsjs/go/backend/App';
 import './SettingsModal.css';
 
-export default function SettingsModal({ serverId, currentUser, onClose }) {
+export default function SettingsModal({ serverId, currentUser, sessionToken, onClose }) {
     const [props, setProps] = useState(null);
     const [isLoading, setIsLoading] = useState(true);
     const [availableVersions, setAvailableVersions] = useState([]);
@@ -11,6 +11,9 @@ export default function SettingsModal({ serverId, currentUser, onClose }) {
     const [selectedVersion, setSelectedVersion] = useState('');
     const [isChangingVersion, setIsChangingVersion] = useState(false);
     const [versionChangeMsg, setVersionChangeMsg] = useState('');
+    const [allProps, setAllProps] = useState([]);
+    const [edits, setEdits] = useState({});
+    const [showAdvanced, setShowAdvanced] = useState(false);
 
     useEffect(() => {
         loadSettings();
@@ -19,8 +22,9 @@ export default function SettingsModal({ serverId, currentUser, onClose }) {
     }, []);
 
     const loadSettings = async () => {
-        const data = await GetServerOptions(serverId);
+        const data = await GetServerOptions(serverId, sessionToken);
         setProps(data);
+        setAllProps(await GetServerProperties(serverId, sessionToken) || []);
         setSelectedType(data?.type || '');
         setSelectedVersion(data?.version || '');
         setIsLoading(false);
@@ -33,8 +37,13 @@ export default function SettingsModal({ serverId, currentUser, onClose }) {
 
     const handleSave = async () => {
         const updatedProps = { ...props, version: selectedVersion, type: selectedType };
-        const result = await SaveServerOptions(serverId, currentUser, updatedProps);
+        let result = await SaveServerOptions(serverId, sessionToken, updatedProps);
+        // Advanced edits go last so they win over the form above
+        if (result.startsWith('Success') && Object.keys(edits).length > 0) {
+            result = await SetServerProperties(serverId, sessionToken, edits);
+        }
         alert(result);
+        if (!result.startsWith('Success')) return;
         onClose();
     };
 
@@ -181,6 +190,21 @@ export default function SettingsModal({ serverId, currentUser, onClose }) {
                             <Toggle label="Animals" checked={props["spawn-animals"]} onChange={(v) => handleChange("spawn-animals", v)} />
                             <Toggle label="Villagers" checked={props["spawn-npcs"]} onChange={(v) => handleChange("spawn-npcs", v)} />
                         </div>
+
+                        {/* Every key in server.properties */}
+                        <div className="settings-modal-section settings-modal-section--wide">
+                            <h4 className="settings-modal-advanced-toggle" onClick={() => setShowAdvanced(!showAdvanced)}>
+                                {showAdvanced ? '▾' : '▸'} All Properties
+                            </h4>
+                            {showAdvanced && allProps.map(entry => (
+                                <PropertyField
+                                    key={entry.key}
+                                    entry={entry}
+                                    value={edits[entry.key] ?? entry.value}
+                                    onChange={(v) => setEdits(prev => ({ ...prev, [entry.key]: v }))}
+                                />
+                            ))}
+                        </div>
                     </div>
                 </div>
 
@@ -207,4 +231,35 @@ function Toggle({ label, checked, onChange }) {
             </label>
         </div>
     );
-}
\ No newline at end of file
+}
+
+// One server.properties key, with an input that fits its type
+function PropertyField({ entry, value, onChange }) {
+    let input;
+    if (entry.type === 'bool') {
+        input = <input type="checkbox" checked={value === 'true'} onChange={(e) => onChange(String(e.target.checked))} />;
+    } else if (entry.type === 'enum') {
+        input = (
+            <select value={value} onChange={(e) => onChange(e.target.value)} className="settings-modal-input">
+                {entry.values.map(v => <option key={v} value={v}>{v}</option>)}
+            </select>
+        );
+    } else {
+        input = (
+            <input
+                type={entry.type === 'int' ? 'number' : 'text'}
+                min={entry.min}
+                max={entry.max}
+                value={value}
+                onChange={(e) => onChange(e.target.value)}
+                className="settings-modal-input"
+            />
+        );
+    }
+    return (
+        <div className="settings-modal-property" title={entry.description}>
+            <span className={`settings-modal-property-key ${entry.known ? '' : 'settings-modal-property-key--custom'}`}>{entry.key}</span>
+            <div className="settings-modal-input-wrapper">{input}</div>
+        </div>
+    );
+}
@@ -26,6 +26,20 @@ export default function Terminal({ selectedServer }) {
             });
         });
 
+        // Console lines come as typed events, tagged with their server & source
+        const stopConsole = EventsOn("bus:console", (event) => {
+            const now = new Date(event.time).toLocaleTimeString('en-GB', { hour12: false });
+            bufferRef.current.push({
+                id: Date.now() + Math.random(),
+                text: event.message,
+                time: now,
+                console: true,
+                source: event.source,
+                serverId: event.serverId,
+                severity: event.severity
+            });
+        });
+
         // Flush buffer every 50ms
         const interval = setInterval(() => {
             if (bufferRef.current.length > 0) {
@@ -37,7 +51,7 @@ export default function Terminal({ selectedServer }) {
             }
         }, 50);
 
-        return () => { stop && stop(); clearInterval(interval); };
+        return () => { stop && stop(); stopConsole && stopConsole(); clearInterval(interval); };
     }, []);
 
     // --- 2. Auto-Scroll ---
@@ -59,9 +73,10 @@ export default function Terminal({ selectedServer }) {
 
             // Echo command to UI immediately
             const now = new Date().toLocaleTimeString('en-GB', { hour12: false });
-            setLogs(prev => [...prev, { id: Date.now(), text: `> ${commandInput}`, time: now }]);
+            setLogs(prev => [...prev, { id: Date.now(), text: `> ${commandInput}`, time: now, console: true, serverId: selectedServer.id }]);
 
-            await SendConsoleCommand(selectedServer.id, commandInput);
+            const sessionToken = sessionStorage.getItem("mc_token") || "";
+            await SendConsoleCommand(selectedServer.id, sessionToken, commandInput);
             setCommandInput("");
         }
     };
@@ -69,12 +84,13 @@ export default function Terminal({ selectedServer }) {
     // --- 4. Smart Filtering ---
     const filteredLogs = useMemo(() => {
         if (activeTab === 'terminal') {
-            // Console: Show ONLY Minecraft logs (starts with [MC]:)
-            return logs.filter(log => log.text.includes("[MC]:"));
+            // Console: Show ONLY this server's Minecraft output (and our own commands)
+            return logs.filter(log => log.console && log.source !== 'playit' &&
+                (!selectedServer || log.serverId === selectedServer.id));
         }
-        // System Logs: Show EVERYTHING
-        return logs;
-    }, [logs, activeTab]);
+        // System Logs: Show EVERYTHING (console lines also arrive here as text)
+        return logs.filter(log => !log.console);
+    }, [logs, activeTab, selectedServer]);
 
     return (
         <div className="terminal-wrapper" style={{ height: isMinimized ? "35px" : "200px", display: 'flex', flexDirection: 'column' }}>
@@ -138,7 +154,9 @@ const LogLine = ({ log }) => {
     let color = "#d4d4d4";
 
     // Color Logic
-    if (t.startsWith('>')) color = "#fff";
+    if (log.console && log.severity === 'warn') color = "#fca5a5";
+    else if (log.console) color = t.startsWith('>') ? "#fff" : "#f1c40f"; // Gold
+    else if (t.startsWith('>')) color = "#fff";
     else if (t.includes("Error") || t.includes("fail") || t.includes("❌")) color = "#ff6b6b";
     else if (t.includes("Warn")) color = "#fca5a5";
     else if (t.includes("[MC]:")) color = "#f1c40f"; // Gold
@@ -1,5 +1,5 @@
 import { useState, useEffect } from 'react';
-import { SendConsoleCommand, SaveWorldSetting } from '../../wailsjs/go/backend/App';
+import { GetGameRules, SetGameRule } from '../../wailsjs/go/backend/App';
 
 // --- CONFIGURATION: MAPS UI TO COMMANDS ---
 const RULE_CATEGORIES = {
@@ -42,11 +42,10 @@ const RULE_CATEGORIES = {
         { id: "fireDamage", label: "Fire Damage", type: "boolean" },
         { id: "drowningDamage", label: "Drowning Damage", type: "boolean" },
         { id: "freezeDamage", label: "Freeze Damage", type: "boolean" },
-        { id: "pvp", label: "PVP (Player vs Player)", type: "boolean" }, // Note: In vanilla this is server.properties, but some forks allow gamerule
     ]
 };
 
-export default function WorldModal({ server, currentUser, onClose }) {
+export default function WorldModal({ server, currentUser, sessionToken, onClose }) {
     // Add custom scrollbar styles
     useEffect(() => {
         const styleId = 'world-modal-scrollbar';
@@ -93,7 +92,7 @@ export default function WorldModal({ server, currentUser, onClose }) {
                 if(rule.id === 'difficulty') state[rule.id] = 'easy';
                 
                 // Specific Overrides for common rules usually ON by default
-                if(['doDaylightCycle', 'doWeatherCycle', 'doMobSpawning', 'mobGriefing', 'doTileDrops', 'naturalRegeneration', 'pvp'].includes(rule.id)) {
+                if(['doDaylightCycle', 'doWeatherCycle', 'doMobSpawning', 'mobGriefing', 'doTileDrops', 'naturalRegeneration'].includes(rule.id)) {
                     if(savedSettings[rule.id] === undefined) state[rule.id] = true;
                 }
             }
@@ -103,44 +102,52 @@ export default function WorldModal({ server, currentUser, onClose }) {
 
     const [settings, setSettings] = useState(getInitialState());
     const [activeTab, setActiveTab] = useState("PLAYER"); // Default tab
+    const [available, setAvailable] = useState(null); // Rules this server's version has
+
+    // Live values when the server runs on this PC, saved ones otherwise
+    useEffect(() => {
+        GetGameRules(server.id, sessionToken).then(rules => {
+            const values = {};
+            (rules || []).forEach(rule => {
+                values[rule.name] = rule.type === 'bool' ? rule.value === 'true' : parseInt(rule.value);
+            });
+            setSettings(prev => ({ ...prev, ...values }));
+            setAvailable(new Set((rules || []).map(rule => rule.name)));
+        });
+        // eslint-disable-next-line
+    }, []);
+
+    const isAvailable = (rule) => !available || rule.id === 'difficulty' || available.has(rule.id);
 
     // --- HANDLERS ---
 
-    const handleToggle = async (id) => {
-        const newVal = !settings[id];
-        setSettings(prev => ({ ...prev, [id]: newVal })); // Optimistic Update
-        
-        // Command: /gamerule ruleName true/false
-        // Exception: PVP usually requires property change, but we'll try gamerule first or ignore if vanilla
-        if (id === 'pvp') {
-             // PVP is tricky in real-time on Vanilla. On Paper/Spigot, simply toggling variable isn't enough usually.
-             // We will try changing difficulty to peaceful and back to reset aggro if needed, 
-             // but strictly speaking 'pvp' is a server.property. 
-             // FOR NOW: We just save state. 
-             // To support Real-Time PVP, plugins are usually needed.
-        } else {
-            await SendConsoleCommand(server.id, currentUser, `gamerule ${id} ${newVal}`);
+    // The backend validates, saves for the group and applies it live
+    const applyRule = async (id, value, previous) => {
+        const result = await SetGameRule(server.id, sessionToken, id, String(value));
+        if (result.startsWith('Error')) {
+            setSettings(prev => ({ ...prev, [id]: previous })); // Roll back the optimistic update
+            alert(result);
         }
-        
-        await SaveWorldSetting(server.id, currentUser, id, newVal);
+    };
+
+    const handleToggle = async (id) => {
+        const previous = settings[id];
+        setSettings(prev => ({ ...prev, [id]: !previous })); // Optimistic Update
+        await applyRule(id, !previous, previous);
     };
 
     const handleSelect = async (id, val) => {
+        const previous = settings[id];
         setSettings(prev => ({ ...prev, [id]: val }));
-        
-        if (id === 'difficulty') {
-            await SendConsoleCommand(server.id, currentUser, `difficulty ${val}`);
-        }
-        await SaveWorldSetting(server.id, currentUser, id, val);
+        await applyRule(id, val, previous);
     };
 
     const handleIntegerChange = async (id, val) => {
         const num = parseInt(val);
         if (isNaN(num)) return;
-        
+
         setSettings(prev => ({ ...prev, [id]: num }));
-        await SendConsoleCommand(server.id, currentUser, `gamerule ${id} ${num}`);
-        await SaveWorldSetting(server.id, currentUser, id, num);
+        await applyRule(id, num, savedSettings[id]);
     };
 
     return (
@@ -174,7 +181,7 @@ export default function WorldModal({ server, currentUser, onClose }) {
                 {/* CONTENT AREA */}
                 <div style={styles.content} className="world-modal-scroll">
                     <div style={styles.grid}>
-                        {RULE_CATEGORIES[activeTab].map((rule) => (
+                        {RULE_CATEGORIES[activeTab].filter(isAvailable).map((rule) => (
                             <div key={rule.id} style={styles.card}>
                                 <div style={styles.labelGroup}>
                                     <div style={styles.label}>{rule.label}</div>
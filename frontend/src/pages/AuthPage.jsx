@@ -15,10 +15,10 @@ export default function AuthPage() {
         // Call Go Backend
         const result = await Login(username, password);
 
-        if (result.startsWith("Success")) {
-            // Save user info locally if needed (optional)
-            // Navigate to Dashboard
+        if (result.startsWith("Success:")) {
+            // Keep the session token; the backend derives the user from it
             sessionStorage.setItem("mc_username", username);
+            sessionStorage.setItem("mc_token", result.slice("Success:".length));
             navigate("/dashboard");
         } else {
             setStatus(result);
@@ -20,11 +20,13 @@ const RefreshIcon = ({ spinning, color = '#888' }) => (
 );
 import { useNavigate } from 'react-router-dom';
 // Backend
-import { GetMyServers, CreateServer, JoinServer, StartServer, StopServer, AuthorizeDrive, InstallServer, DeleteServer, GetVersions, LaunchPlayitExternally, ImportPlayitConfig, ForceSyncUp, CheckDependencies, InstallDependencies } from '../../wailsjs/go/backend/App';
+import { GetMyServers, CreateServer, JoinServer, StartServer, StopServer, AuthorizeDrive, InstallServer, DeleteServer, GetVersions, LaunchPlayitExternally, ImportPlayitConfig, ChooseModpackFile, CreateServerFromModpack, ChooseImportSource, ImportServer, ForceSyncUp, CheckDependencies, InstallDependencies, Logout, SaveLocalCopyAsSnapshot, AbandonPendingUpload } from '../../wailsjs/go/backend/App';
 import { EventsOn } from '../../wailsjs/runtime/runtime';
 // Components
 import SettingsModal from '../components/SettingsModal';
 import WorldModal from '../components/WorldModal';
+import PluginsModal from '../components/PluginsModal';
+import ExportModal from '../components/ExportModal';
 import PlayerModal from '../components/PlayerModal';
 import AdminModal from '../components/AdminModal';
 import Terminal from '../components/Terminal';
@@ -72,6 +74,10 @@ export default function Dashboard() {
 
     // Wizard State
     const [createStep, setCreateStep] = useState(1);
+    const [modpackPath, setModpackPath] = useState(""); // Create from a modpack instead of a type/version
+    const [importPath, setImportPath] = useState(""); // Create from an existing server/world
+    const [overrideDetected, setOverrideDetected] = useState(false); // Pick type/version for an import
+    const [isCreating, setIsCreating] = useState(false);
     const [createdServerId, setCreatedServerId] = useState(null);
 
     // Modal State
@@ -80,10 +86,13 @@ export default function Dashboard() {
     const [isInstalling, setIsInstalling] = useState(false);
     const [settingsServerId, setSettingsServerId] = useState(null);
     const [worldSettingsId, setWorldSettingsId] = useState(null);
+    const [pluginsId, setPluginsId] = useState(null);
+    const [exportId, setExportId] = useState(null);
     const [playerId, setPlayerId] = useState(null);
     const [adminModalId, setAdminModalId] = useState(null);
 
     const currentUser = sessionStorage.getItem("mc_username") || "Unknown";
+    const sessionToken = sessionStorage.getItem("mc_token") || "";
     const navigate = useNavigate();
 
     // Listen to system logs from backend
@@ -125,12 +134,12 @@ export default function Dashboard() {
 
     // Listen for public address events
     useEffect(() => {
-        const stop = EventsOn("public-address", (addr) => setPublicAddress(addr));
+        const stop = EventsOn("bus:tunnel", (event) => setPublicAddress(event.payload?.address));
         return () => stop && stop();
     }, []);
 
     const loadServers = async () => {
-        const list = await GetMyServers(currentUser);
+        const list = await GetMyServers(sessionToken);
         // Map owner_id to owner and _id to id for compatibility everywhere
         const mappedList = (list || []).map(server => ({
             ...server,
@@ -187,7 +196,7 @@ export default function Dashboard() {
 
     const handleCreate = async () => {
         if (!newServerName || !rcloneConf || !selectedVersion) return;
-        const res = await CreateServer(newServerName, selectedType, selectedVersion, currentUser, rcloneConf);
+        const res = await CreateServer(newServerName, selectedType, selectedVersion, sessionToken, rcloneConf);
 
         if (res.startsWith("Error")) {
             alert(res);
@@ -204,7 +213,7 @@ export default function Dashboard() {
 
     const handleJoin = async () => {
         if (!inviteCode) return;
-        const res = await JoinServer(inviteCode, currentUser);
+        const res = await JoinServer(inviteCode, sessionToken);
         alert(res);
         setInviteCode("");
         setView("dashboard");
@@ -214,7 +223,7 @@ export default function Dashboard() {
     const handleStart = async (serverId) => {
         setActivePort(null);
         setPublicAddress(null);
-        const res = await StartServer(serverId, currentUser);
+        const res = await StartServer(serverId, sessionToken);
 
         if (res.startsWith("Success:")) {
             setActivePort(res.split(":")[1]);
@@ -233,18 +242,31 @@ export default function Dashboard() {
     };
 
     const handleStop = async (serverId) => {
-        await StopServer(serverId, currentUser);
+        const res = await StopServer(serverId, sessionToken);
+        if (res.includes("Sync conflict")) {
+            // Someone else uploaded since we downloaded; don't lose either copy
+            if (confirm(`⚠️ ${res.replace(/^Error:\s*/, "")}\n\nYour copy was NOT uploaded. Save it as a snapshot so it can be restored later?`)) {
+                alert(await SaveLocalCopyAsSnapshot(serverId, sessionToken));
+            }
+        }
         setActivePort(null);
         setPublicAddress(null);
         loadServers();
     };
 
+    const handleAbandonUpload = async (serverId) => {
+        if (!confirm("⚠️ Abandon the pending upload?\n\nThe progress from that session will be lost and the server goes back to its last uploaded state.")) return;
+        const res = await AbandonPendingUpload(serverId, sessionToken);
+        if (!res.startsWith("Success")) alert(res);
+        loadServers();
+    };
+
     const handleInstall = async () => {
         setIsInstalling(true);
-        const res = await InstallServer(setupServerId);
+        const res = await InstallServer(setupServerId, sessionToken);
         if (res.startsWith("Error")) alert(res);
         else {
-            await StopServer(setupServerId, currentUser); // Sync up
+            await StopServer(setupServerId, sessionToken); // Sync up
             setNeedsSetup(false);
             alert("Installed! You can now start the server.");
         }
@@ -256,7 +278,7 @@ export default function Dashboard() {
             return;
         }
 
-        const res = await DeleteServer(serverId, currentUser);
+        const res = await DeleteServer(serverId, sessionToken);
         if (res === "Success") {
             loadServers(); // Refresh list immediately
         } else {
@@ -350,7 +372,7 @@ export default function Dashboard() {
                     <button style={view === "account" ? styles.navBtnActive : styles.navBtn} onClick={() => setView("account")}>
                         👤 Account
                     </button>
-                    <button onClick={() => { sessionStorage.clear(); navigate("/"); }} style={{
+                    <button onClick={async () => { await Logout(sessionToken); sessionStorage.clear(); navigate("/"); }} style={{
                         ...styles.logoutBtn,
                         position: "static",
                         marginTop: "20px",
@@ -410,9 +432,12 @@ export default function Dashboard() {
                                     onStop={() => handleStop(server.id)}
                                     onSettings={() => setSettingsServerId(server.id)}
                                     onWorld={() => setWorldSettingsId(server.id)}
+                                    onPlugins={() => setPluginsId(server.id)}
+                                    onExport={() => setExportId(server.id)}
                                     onPlayers={() => setPlayerId(server.id)}
                                     onAdmins={() => setAdminModalId(server.id)}
                                     onDelete={() => handleDelete(server.id)}
+                                    onAbandonUpload={() => handleAbandonUpload(server.id)}
                                 />
                             ))}
                         </div>
@@ -439,47 +464,103 @@ export default function Dashboard() {
                                     <input style={styles.input} value={newServerName} onChange={e => setNewServerName(e.target.value)} placeholder="e.g. Survival World" />
                                 </div>
 
-                                {/* VERSION SELECTION ROW */}
-                                <div style={{ display: "flex", gap: "15px", marginBottom: "20px" }}>
-
-                                    {/* 1. TYPE SELECTOR */}
-                                    <div style={{ flex: 1 }}>
-                                        <label style={{ display: "block", marginBottom: "8px", fontSize: "0.9rem", color: "#aaa" }}>Server Type</label>
-                                        <select
-                                            style={styles.input}
-                                            value={selectedType}
-                                            onChange={(e) => handleTypeChange(e.target.value)}
-                                            disabled={availableTypes.length === 0}
-                                        >
-                                            {availableTypes.length === 0 && <option>Loading...</option>}
-                                            {availableTypes.map(t => (
-                                                <option key={t} value={t}>{t}</option>
-                                            ))}
-                                        </select>
+                                {/* MODPACK (replaces the type/version choice) */}
+                                {!importPath && (
+                                    <div style={styles.formGroup}>
+                                        <label>Modpack (Optional)</label>
+                                        {modpackPath ? (
+                                            <div style={{ display: 'flex', gap: '10px', alignItems: 'center' }}>
+                                                <div style={{ ...styles.input, flex: 1, overflow: 'hidden', textOverflow: 'ellipsis', whiteSpace: 'nowrap' }}>
+                                                    📦 {modpackPath.split(/[\\/]/).pop()}
+                                                </div>
+                                                <button style={styles.secondaryBtn} onClick={() => setModpackPath("")}>×</button>
+                                            </div>
+                                        ) : (
+                                            <button
+                                                style={styles.secondaryBtn}
+                                                onClick={async () => setModpackPath(await ChooseModpackFile())}
+                                            >
+                                                📦 Import .mrpack or CurseForge server pack...
+                                            </button>
+                                        )}
                                     </div>
-
-                                    {/* 2. VERSION SELECTOR */}
-                                    <div style={{ flex: 1 }}>
-                                        <label style={{ display: "block", marginBottom: "8px", fontSize: "0.9rem", color: "#aaa" }}>Game Version</label>
-                                        <select
-                                            style={styles.input}
-                                            value={selectedVersion}
-                                            onChange={(e) => setSelectedVersion(e.target.value)}
-                                            disabled={!selectedType}
-                                        >
-                                            {allVersions
-                                                .filter(v => v.type === selectedType)
-                                                .map(v => (
-                                                    <option key={v.id} value={v.version}>{v.version}</option>
-                                                ))
-                                            }
-                                        </select>
+                                )}
+
+                                {/* EXISTING WORLD (type/version are read from level.dat) */}
+                                {!modpackPath && (
+                                    <div style={styles.formGroup}>
+                                        <label>Existing World (Optional)</label>
+                                        {importPath ? (
+                                            <>
+                                                <div style={{ display: 'flex', gap: '10px', alignItems: 'center' }}>
+                                                    <div style={{ ...styles.input, flex: 1, overflow: 'hidden', textOverflow: 'ellipsis', whiteSpace: 'nowrap' }}>
+                                                        🌍 {importPath.split(/[\\/]/).pop()}
+                                                    </div>
+                                                    <button style={styles.secondaryBtn} onClick={() => { setImportPath(""); setOverrideDetected(false); }}>×</button>
+                                                </div>
+                                                <label style={{ display: 'flex', gap: '8px', alignItems: 'center', marginTop: '10px', fontSize: '0.85rem', color: '#aaa' }}>
+                                                    <input type="checkbox" checked={overrideDetected} onChange={e => setOverrideDetected(e.target.checked)} />
+                                                    Choose the server type and version instead of detecting them
+                                                </label>
+                                            </>
+                                        ) : (
+                                            <div style={{ display: 'flex', gap: '10px' }}>
+                                                <button style={styles.secondaryBtn} onClick={async () => setImportPath(await ChooseImportSource(true))}>
+                                                    📁 Server or World Folder...
+                                                </button>
+                                                <button style={styles.secondaryBtn} onClick={async () => setImportPath(await ChooseImportSource(false))}>
+                                                    🗜 World Archive...
+                                                </button>
+                                            </div>
+                                        )}
                                     </div>
-                                </div>
+                                )}
+
+                                {!modpackPath && (!importPath || overrideDetected) && (
+                                    <>
+                                    {/* VERSION SELECTION ROW */}
+                                    <div style={{ display: "flex", gap: "15px", marginBottom: "20px" }}>
+
+                                        {/* 1. TYPE SELECTOR */}
+                                        <div style={{ flex: 1 }}>
+                                            <label style={{ display: "block", marginBottom: "8px", fontSize: "0.9rem", color: "#aaa" }}>Server Type</label>
+                                            <select
+                                                style={styles.input}
+                                                value={selectedType}
+                                                onChange={(e) => handleTypeChange(e.target.value)}
+                                                disabled={availableTypes.length === 0}
+                                            >
+                                                {availableTypes.length === 0 && <option>Loading...</option>}
+                                                {availableTypes.map(t => (
+                                                    <option key={t} value={t}>{t}</option>
+                                                ))}
+                                            </select>
+                                        </div>
+
+                                        {/* 2. VERSION SELECTOR */}
+                                        <div style={{ flex: 1 }}>
+                                            <label style={{ display: "block", marginBottom: "8px", fontSize: "0.9rem", color: "#aaa" }}>Game Version</label>
+                                            <select
+                                                style={styles.input}
+                                                value={selectedVersion}
+                                                onChange={(e) => setSelectedVersion(e.target.value)}
+                                                disabled={!selectedType}
+                                            >
+                                                {allVersions
+                                                    .filter(v => v.type === selectedType)
+                                                    .map(v => (
+                                                        <option key={v.id} value={v.version}>{v.version}</option>
+                                                    ))
+                                                }
+                                            </select>
+                                        </div>
+                                    </div>
+                                    </>
+                                )}
 
                                 <button
                                     onClick={() => setCreateStep(2)}
-                                    disabled={!newServerName || !selectedVersion}
+                                    disabled={(modpackPath || (importPath && !overrideDetected)) ? false : (!newServerName || !selectedVersion)}
                                     style={styles.primaryBtn}
                                 >
                                     Next: Cloud Sync →
@@ -513,9 +594,19 @@ export default function Dashboard() {
                                     <button style={styles.secondaryBtn} onClick={() => setCreateStep(1)}>← Back</button>
                                     <button
                                         style={styles.primaryBtn}
-                                        disabled={!rcloneConf}
+                                        disabled={!rcloneConf || isCreating}
                                         onClick={async () => {
-                                            const id = await CreateServer(newServerName, selectedType, selectedVersion, currentUser, rcloneConf);
+                                            // Modpacks and imports are installed and uploaded right away
+                                            setIsCreating(true);
+                                            let id;
+                                            if (modpackPath) {
+                                                id = await CreateServerFromModpack(newServerName, modpackPath, sessionToken, rcloneConf);
+                                            } else if (importPath) {
+                                                id = await ImportServer(newServerName, importPath, overrideDetected ? selectedType : "", overrideDetected ? selectedVersion : "", sessionToken, rcloneConf);
+                                            } else {
+                                                id = await CreateServer(newServerName, selectedType, selectedVersion, sessionToken, rcloneConf);
+                                            }
+                                            setIsCreating(false);
                                             if (id.startsWith("Error")) {
                                                 alert(id);
                                                 return;
@@ -524,13 +615,16 @@ export default function Dashboard() {
                                             alert("✅ Server created successfully!");
                                             setNewServerName("");
                                             setRcloneConf("");
+                                            setModpackPath("");
+                                            setImportPath("");
+                                            setOverrideDetected(false);
                                             setCreateStep(1);
                                             setCreatedServerId(null);
                                             loadServers();
                                             setView("dashboard");
                                         }}
                                     >
-                                        ✅ Create Server
+                                        {isCreating ? (modpackPath ? "Installing modpack..." : importPath ? "Importing world..." : "Creating...") : "✅ Create Server"}
                                     </button>
                                 </div>
                             </>
@@ -583,7 +677,7 @@ export default function Dashboard() {
                                     marginBottom: '15px'
                                 }}
                                 onClick={async () => {
-                                    const result = await LaunchPlayitExternally("temp");
+                                    const result = await LaunchPlayitExternally(sessionToken);
                                     if (result.startsWith("Error")) {
                                         alert(result);
                                     }
@@ -599,7 +693,7 @@ export default function Dashboard() {
                                     justifyContent: 'center'
                                 }}
                                 onClick={async () => {
-                                    const res = await ImportPlayitConfig(currentUser);
+                                    const res = await ImportPlayitConfig(sessionToken);
 
                                     if (res === "Success") {
                                         alert("✅ Playit config saved to your account! Your tunnel will work on any server you host.");
@@ -628,18 +722,34 @@ export default function Dashboard() {
             <Terminal selectedServer={servers.find(s => s.lock?.is_running) || null} />
 
             {/* MODALS */}
-            {settingsServerId && <SettingsModal serverId={settingsServerId} currentUser={currentUser} onClose={() => setSettingsServerId(null)} />}
+            {settingsServerId && <SettingsModal serverId={settingsServerId} currentUser={currentUser} sessionToken={sessionToken} onClose={() => setSettingsServerId(null)} />}
             {worldSettingsId && (
                 <WorldModal
                     server={servers.find(s => s.id === worldSettingsId)}
                     currentUser={currentUser}
+                    sessionToken={sessionToken}
                     onClose={() => setWorldSettingsId(null)}
                 />
             )}
+            {pluginsId && (
+                <PluginsModal
+                    server={servers.find(s => s.id === pluginsId)}
+                    sessionToken={sessionToken}
+                    onClose={() => setPluginsId(null)}
+                />
+            )}
+            {exportId && (
+                <ExportModal
+                    server={servers.find(s => s.id === exportId)}
+                    sessionToken={sessionToken}
+                    onClose={() => setExportId(null)}
+                />
+            )}
             {playerId && (
                 <PlayerModal
                     server={servers.find(s => s.id === playerId)}
                     currentUser={currentUser}
+                    sessionToken={sessionToken}
                     onClose={() => setPlayerId(null)}
                 />
             )}
@@ -647,6 +757,7 @@ export default function Dashboard() {
                 <AdminModal
                     server={servers.find(s => s.id === adminModalId)}
                     currentUser={currentUser}
+                    sessionToken={sessionToken}
                     onClose={() => setAdminModalId(null)}
                 />
             )}
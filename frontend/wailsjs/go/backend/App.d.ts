@@ -2,37 +2,61 @@
 // This file is automatically generated. DO NOT EDIT
 import {backend} from '../models';
 
+export function AbandonPendingUpload(arg1:string,arg2:string):Promise<string>;
+
 export function AuthorizeDrive(arg1:string,arg2:string):Promise<string>;
 
 export function ChangeServerVersion(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
 export function ChangeServerVersionWails(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
-export function CheckCloudExists(arg1:string):Promise<boolean>;
-
 export function CheckDependencies():Promise<boolean>;
 
 export function CheckUserHasPlayit(arg1:string):Promise<boolean>;
 
-export function CleanLocks(arg1:string):Promise<void>;
+export function ChooseExportFile(arg1:string):Promise<string>;
+
+export function ChooseImportSource(arg1:boolean):Promise<string>;
+
+export function ChooseModpackFile():Promise<string>;
 
 export function CreateServer(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
 
+export function CreateServerFromModpack(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
 export function DeleteServer(arg1:string,arg2:string):Promise<string>;
 
-export function FindProcessLockingFile(arg1:string):Promise<Array<number>>;
+export function ExportWorld(arg1:string,arg2:string,arg3:string,arg4:any):Promise<string>;
+
+export function ForceSyncUp(arg1:string,arg2:string):Promise<string>;
+
+export function GetAPIStatus():Promise<any>;
+
+export function GetAdmins(arg1:string,arg2:string):Promise<Array<string>>;
 
-export function ForceKillPort(arg1:number):Promise<void>;
+export function GetChatHistory(arg1:string,arg2:string):Promise<Array<any>>;
 
-export function ForceSyncUp(arg1:string):Promise<string>;
+export function GetGameRules(arg1:string,arg2:string):Promise<Array<any>>;
 
-export function GetAdmins(arg1:string):Promise<Array<string>>;
+export function GetHostSettings():Promise<any>;
 
 export function GetMyServers(arg1:string):Promise<Array<backend.ServerGroup>>;
 
-export function GetPlayerLists(arg1:string):Promise<backend.PlayerLists>;
+export function GetOnlinePlayers(arg1:string,arg2:string):Promise<Array<any>>;
 
-export function GetServerOptions(arg1:string):Promise<backend.ServerProps>;
+export function GetPendingUploads(arg1:string):Promise<Array<any>>;
+
+export function GetPlayerLists(arg1:string,arg2:string):Promise<backend.PlayerLists>;
+
+export function GetPluginProviders():Promise<Array<string>>;
+
+export function GetServerOptions(arg1:string,arg2:string):Promise<backend.ServerProps>;
+
+export function GetServerProperties(arg1:string,arg2:string):Promise<Array<any>>;
+
+export function GetSessionUser(arg1:string):Promise<string>;
+
+export function GetSyncStatus(arg1:string,arg2:string):Promise<any>;
 
 export function GetVersions():Promise<Array<backend.ServerVersion>>;
 
@@ -40,56 +64,84 @@ export function Greet(arg1:string):Promise<string>;
 
 export function ImportPlayitConfig(arg1:string):Promise<string>;
 
-export function InjectConfig(arg1:string):Promise<void>;
+export function ImportServer(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string):Promise<string>;
 
 export function InstallDependencies():Promise<void>;
 
-export function InstallServer(arg1:string):Promise<string>;
+export function InstallJava(arg1:string,arg2:number):Promise<string>;
+
+export function InstallPlugin(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function InstallServer(arg1:string,arg2:string):Promise<string>;
 
 export function IsAdmin(arg1:string,arg2:string):Promise<boolean>;
 
 export function JoinServer(arg1:string,arg2:string):Promise<string>;
 
-export function KillMinecraftServer():Promise<void>;
+export function KillZombie(arg1:string,arg2:string):Promise<string>;
 
-export function KillProcessesLockingLogs(arg1:string):Promise<void>;
+export function LaunchPlayitExternally(arg1:string):Promise<string>;
 
-export function KillZombie(arg1:string):Promise<void>;
+export function ListJavaRuntimes():Promise<Array<any>>;
 
-export function LaunchPlayitExternally(arg1:string):Promise<string>;
+export function ListPlugins(arg1:string,arg2:string):Promise<Array<any>>;
+
+export function ListSnapshots(arg1:string,arg2:string):Promise<Array<any>>;
 
 export function Log(arg1:string):Promise<void>;
 
 export function Login(arg1:string,arg2:string):Promise<string>;
 
-export function ManagePlayer(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
+export function Logout(arg1:string):Promise<string>;
 
-export function PurgeRemote(arg1:string):Promise<void>;
+export function LogoutEverywhere(arg1:string):Promise<string>;
+
+export function ManagePlayer(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
 
 export function Register(arg1:string,arg2:string):Promise<string>;
 
 export function RemoveAdmin(arg1:string,arg2:string,arg3:string):Promise<string>;
 
-export function RunMinecraftServer(arg1:string,arg2:number):Promise<void>;
+export function RemoveMember(arg1:string,arg2:string,arg3:string):Promise<string>;
 
-export function RunSync(arg1:backend.SyncDirection,arg2:string,arg3:string):Promise<void>;
+export function RestoreSnapshot(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function SaveLocalCopyAsSnapshot(arg1:string,arg2:string):Promise<string>;
 
 export function SaveServerOptions(arg1:string,arg2:string,arg3:backend.ServerProps):Promise<string>;
 
 export function SaveWorldSetting(arg1:string,arg2:string,arg3:string,arg4:any):Promise<string>;
 
-export function SeedVersions():Promise<void>;
+export function SearchPlugins(arg1:string,arg2:string,arg3:string,arg4:string):Promise<Array<any>>;
 
 export function SendConsoleCommand(arg1:string,arg2:string,arg3:string):Promise<string>;
 
+export function SetAPIAddress(arg1:string,arg2:string,arg3:boolean):Promise<string>;
+
 export function SetAdmin(arg1:string,arg2:string,arg3:string):Promise<string>;
 
-export function StartPlayitTunnel(arg1:string):Promise<void>;
+export function SetGameRule(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function SetHostMemoryCap(arg1:string,arg2:number):Promise<string>;
+
+export function SetServerProperties(arg1:string,arg2:string,arg3:Record<string, string>):Promise<string>;
+
+export function StartAPI(arg1:string,arg2:string,arg3:boolean):Promise<string>;
 
 export function StartServer(arg1:string,arg2:string):Promise<string>;
 
+export function StopAPI(arg1:string):Promise<string>;
+
 export function StopServer(arg1:string,arg2:string):Promise<string>;
 
-export function StopTunnel():Promise<void>;
+export function SyncNow(arg1:string,arg2:string,arg3:backend.SyncDirection):Promise<string>;
+
+export function UninstallPlugin(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function UnlockKeys(arg1:string,arg2:string):Promise<string>;
+
+export function UpdateCloudConfig(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function UpdateLaunchProfile(arg1:string,arg2:string,arg3:any):Promise<string>;
 
-export function UpdateServerProperties(arg1:string,arg2:number):Promise<void>;
+export function UpdatePlugins(arg1:string,arg2:string):Promise<string>;
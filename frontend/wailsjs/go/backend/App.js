@@ -2,6 +2,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AbandonPendingUpload(arg1, arg2) {
+  return window['go']['backend']['App']['AbandonPendingUpload'](arg1, arg2);
+}
+
 export function AuthorizeDrive(arg1, arg2) {
   return window['go']['backend']['App']['AuthorizeDrive'](arg1, arg2);
 }
@@ -14,10 +18,6 @@ export function ChangeServerVersionWails(arg1, arg2, arg3, arg4) {
   return window['go']['backend']['App']['ChangeServerVersionWails'](arg1, arg2, arg3, arg4);
 }
 
-export function CheckCloudExists(arg1) {
-  return window['go']['backend']['App']['CheckCloudExists'](arg1);
-}
-
 export function CheckDependencies() {
   return window['go']['backend']['App']['CheckDependencies']();
 }
@@ -26,44 +26,92 @@ export function CheckUserHasPlayit(arg1) {
   return window['go']['backend']['App']['CheckUserHasPlayit'](arg1);
 }
 
-export function CleanLocks(arg1) {
-  return window['go']['backend']['App']['CleanLocks'](arg1);
+export function ChooseExportFile(arg1) {
+  return window['go']['backend']['App']['ChooseExportFile'](arg1);
+}
+
+export function ChooseImportSource(arg1) {
+  return window['go']['backend']['App']['ChooseImportSource'](arg1);
+}
+
+export function ChooseModpackFile() {
+  return window['go']['backend']['App']['ChooseModpackFile']();
 }
 
 export function CreateServer(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['backend']['App']['CreateServer'](arg1, arg2, arg3, arg4, arg5);
 }
 
+export function CreateServerFromModpack(arg1, arg2, arg3, arg4) {
+  return window['go']['backend']['App']['CreateServerFromModpack'](arg1, arg2, arg3, arg4);
+}
+
 export function DeleteServer(arg1, arg2) {
   return window['go']['backend']['App']['DeleteServer'](arg1, arg2);
 }
 
-export function FindProcessLockingFile(arg1) {
-  return window['go']['backend']['App']['FindProcessLockingFile'](arg1);
+export function ExportWorld(arg1, arg2, arg3, arg4) {
+  return window['go']['backend']['App']['ExportWorld'](arg1, arg2, arg3, arg4);
+}
+
+export function ForceSyncUp(arg1, arg2) {
+  return window['go']['backend']['App']['ForceSyncUp'](arg1, arg2);
+}
+
+export function GetAPIStatus() {
+  return window['go']['backend']['App']['GetAPIStatus']();
+}
+
+export function GetAdmins(arg1, arg2) {
+  return window['go']['backend']['App']['GetAdmins'](arg1, arg2);
 }
 
-export function ForceKillPort(arg1) {
-  return window['go']['backend']['App']['ForceKillPort'](arg1);
+export function GetChatHistory(arg1, arg2) {
+  return window['go']['backend']['App']['GetChatHistory'](arg1, arg2);
 }
 
-export function ForceSyncUp(arg1) {
-  return window['go']['backend']['App']['ForceSyncUp'](arg1);
+export function GetGameRules(arg1, arg2) {
+  return window['go']['backend']['App']['GetGameRules'](arg1, arg2);
 }
 
-export function GetAdmins(arg1) {
-  return window['go']['backend']['App']['GetAdmins'](arg1);
+export function GetHostSettings() {
+  return window['go']['backend']['App']['GetHostSettings']();
 }
 
 export function GetMyServers(arg1) {
   return window['go']['backend']['App']['GetMyServers'](arg1);
 }
 
-export function GetPlayerLists(arg1) {
-  return window['go']['backend']['App']['GetPlayerLists'](arg1);
+export function GetOnlinePlayers(arg1, arg2) {
+  return window['go']['backend']['App']['GetOnlinePlayers'](arg1, arg2);
 }
 
-export function GetServerOptions(arg1) {
-  return window['go']['backend']['App']['GetServerOptions'](arg1);
+export function GetPendingUploads(arg1) {
+  return window['go']['backend']['App']['GetPendingUploads'](arg1);
+}
+
+export function GetPlayerLists(arg1, arg2) {
+  return window['go']['backend']['App']['GetPlayerLists'](arg1, arg2);
+}
+
+export function GetPluginProviders() {
+  return window['go']['backend']['App']['GetPluginProviders']();
+}
+
+export function GetServerOptions(arg1, arg2) {
+  return window['go']['backend']['App']['GetServerOptions'](arg1, arg2);
+}
+
+export function GetServerProperties(arg1, arg2) {
+  return window['go']['backend']['App']['GetServerProperties'](arg1, arg2);
+}
+
+export function GetSessionUser(arg1) {
+  return window['go']['backend']['App']['GetSessionUser'](arg1);
+}
+
+export function GetSyncStatus(arg1, arg2) {
+  return window['go']['backend']['App']['GetSyncStatus'](arg1, arg2);
 }
 
 export function GetVersions() {
@@ -78,16 +126,24 @@ export function ImportPlayitConfig(arg1) {
   return window['go']['backend']['App']['ImportPlayitConfig'](arg1);
 }
 
-export function InjectConfig(arg1) {
-  return window['go']['backend']['App']['InjectConfig'](arg1);
+export function ImportServer(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['backend']['App']['ImportServer'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
 
 export function InstallDependencies() {
   return window['go']['backend']['App']['InstallDependencies']();
 }
 
-export function InstallServer(arg1) {
-  return window['go']['backend']['App']['InstallServer'](arg1);
+export function InstallJava(arg1, arg2) {
+  return window['go']['backend']['App']['InstallJava'](arg1, arg2);
+}
+
+export function InstallPlugin(arg1, arg2, arg3, arg4) {
+  return window['go']['backend']['App']['InstallPlugin'](arg1, arg2, arg3, arg4);
+}
+
+export function InstallServer(arg1, arg2) {
+  return window['go']['backend']['App']['InstallServer'](arg1, arg2);
 }
 
 export function IsAdmin(arg1, arg2) {
@@ -98,20 +154,24 @@ export function JoinServer(arg1, arg2) {
   return window['go']['backend']['App']['JoinServer'](arg1, arg2);
 }
 
-export function KillMinecraftServer() {
-  return window['go']['backend']['App']['KillMinecraftServer']();
+export function KillZombie(arg1, arg2) {
+  return window['go']['backend']['App']['KillZombie'](arg1, arg2);
 }
 
-export function KillProcessesLockingLogs(arg1) {
-  return window['go']['backend']['App']['KillProcessesLockingLogs'](arg1);
+export function LaunchPlayitExternally(arg1) {
+  return window['go']['backend']['App']['LaunchPlayitExternally'](arg1);
 }
 
-export function KillZombie(arg1) {
-  return window['go']['backend']['App']['KillZombie'](arg1);
+export function ListJavaRuntimes() {
+  return window['go']['backend']['App']['ListJavaRuntimes']();
 }
 
-export function LaunchPlayitExternally(arg1) {
-  return window['go']['backend']['App']['LaunchPlayitExternally'](arg1);
+export function ListPlugins(arg1, arg2) {
+  return window['go']['backend']['App']['ListPlugins'](arg1, arg2);
+}
+
+export function ListSnapshots(arg1, arg2) {
+  return window['go']['backend']['App']['ListSnapshots'](arg1, arg2);
 }
 
 export function Log(arg1) {
@@ -122,12 +182,16 @@ export function Login(arg1, arg2) {
   return window['go']['backend']['App']['Login'](arg1, arg2);
 }
 
-export function ManagePlayer(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['backend']['App']['ManagePlayer'](arg1, arg2, arg3, arg4, arg5);
+export function Logout(arg1) {
+  return window['go']['backend']['App']['Logout'](arg1);
 }
 
-export function PurgeRemote(arg1) {
-  return window['go']['backend']['App']['PurgeRemote'](arg1);
+export function LogoutEverywhere(arg1) {
+  return window['go']['backend']['App']['LogoutEverywhere'](arg1);
+}
+
+export function ManagePlayer(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['backend']['App']['ManagePlayer'](arg1, arg2, arg3, arg4, arg5);
 }
 
 export function Register(arg1, arg2) {
@@ -138,12 +202,16 @@ export function RemoveAdmin(arg1, arg2, arg3) {
   return window['go']['backend']['App']['RemoveAdmin'](arg1, arg2, arg3);
 }
 
-export function RunMinecraftServer(arg1, arg2) {
-  return window['go']['backend']['App']['RunMinecraftServer'](arg1, arg2);
+export function RemoveMember(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['RemoveMember'](arg1, arg2, arg3);
 }
 
-export function RunSync(arg1, arg2, arg3) {
-  return window['go']['backend']['App']['RunSync'](arg1, arg2, arg3);
+export function RestoreSnapshot(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['RestoreSnapshot'](arg1, arg2, arg3);
+}
+
+export function SaveLocalCopyAsSnapshot(arg1, arg2) {
+  return window['go']['backend']['App']['SaveLocalCopyAsSnapshot'](arg1, arg2);
 }
 
 export function SaveServerOptions(arg1, arg2, arg3) {
@@ -154,34 +222,70 @@ export function SaveWorldSetting(arg1, arg2, arg3, arg4) {
   return window['go']['backend']['App']['SaveWorldSetting'](arg1, arg2, arg3, arg4);
 }
 
-export function SeedVersions() {
-  return window['go']['backend']['App']['SeedVersions']();
+export function SearchPlugins(arg1, arg2, arg3, arg4) {
+  return window['go']['backend']['App']['SearchPlugins'](arg1, arg2, arg3, arg4);
 }
 
 export function SendConsoleCommand(arg1, arg2, arg3) {
   return window['go']['backend']['App']['SendConsoleCommand'](arg1, arg2, arg3);
 }
 
+export function SetAPIAddress(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['SetAPIAddress'](arg1, arg2, arg3);
+}
+
 export function SetAdmin(arg1, arg2, arg3) {
   return window['go']['backend']['App']['SetAdmin'](arg1, arg2, arg3);
 }
 
-export function StartPlayitTunnel(arg1) {
-  return window['go']['backend']['App']['StartPlayitTunnel'](arg1);
+export function SetGameRule(arg1, arg2, arg3, arg4) {
+  return window['go']['backend']['App']['SetGameRule'](arg1, arg2, arg3, arg4);
+}
+
+export function SetHostMemoryCap(arg1, arg2) {
+  return window['go']['backend']['App']['SetHostMemoryCap'](arg1, arg2);
+}
+
+export function SetServerProperties(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['SetServerProperties'](arg1, arg2, arg3);
+}
+
+export function StartAPI(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['StartAPI'](arg1, arg2, arg3);
 }
 
 export function StartServer(arg1, arg2) {
   return window['go']['backend']['App']['StartServer'](arg1, arg2);
 }
 
+export function StopAPI(arg1) {
+  return window['go']['backend']['App']['StopAPI'](arg1);
+}
+
 export function StopServer(arg1, arg2) {
   return window['go']['backend']['App']['StopServer'](arg1, arg2);
 }
 
-export function StopTunnel() {
-  return window['go']['backend']['App']['StopTunnel']();
+export function SyncNow(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['SyncNow'](arg1, arg2, arg3);
+}
+
+export function UninstallPlugin(arg1, arg2, arg3, arg4) {
+  return window['go']['backend']['App']['UninstallPlugin'](arg1, arg2, arg3, arg4);
+}
+
+export function UnlockKeys(arg1, arg2) {
+  return window['go']['backend']['App']['UnlockKeys'](arg1, arg2);
+}
+
+export function UpdateCloudConfig(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['UpdateCloudConfig'](arg1, arg2, arg3);
+}
+
+export function UpdateLaunchProfile(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['UpdateLaunchProfile'](arg1, arg2, arg3);
 }
 
-export function UpdateServerProperties(arg1, arg2) {
-  return window['go']['backend']['App']['UpdateServerProperties'](arg1, arg2);
+export function UpdatePlugins(arg1, arg2) {
+  return window['go']['backend']['App']['UpdatePlugins'](arg1, arg2);
 }